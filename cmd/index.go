@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"zfs-file-history/internal/configuration"
+	"zfs-file-history/internal/index"
+	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	indexHash    bool
+	indexSince   string
+	indexMinSize string
+)
+
+var indexCmd = &cobra.Command{
+	Use:   "index [path]",
+	Short: "Record the files of all snapshots of a dataset in the index",
+	Long: `Record the files of all snapshots of the dataset containing the given path (default is the current working directory)
+in the index, so the history of files and files which only exist within snapshots can be queried without walking the snapshots.
+Snapshots are immutable, so each snapshot is only indexed once, running the command again indexes new snapshots.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		idx := loadIndex()
+		dataset := findDatasetOfPathArg(args)
+		snapshots := getSnapshotsForIndex(dataset)
+
+		options := index.Options{Hash: indexHash || configuration.CurrentConfig.Index.Hash}
+		indexed, err := idx.Update(context.Background(), snapshots, options, func(done int, total int, snapshot *zfs.Snapshot) {
+			logging.Info("Indexing %s (%d/%d)", snapshot.FullName, done+1, total)
+		})
+		if err != nil {
+			logging.Error("%v", err)
+		}
+		logging.Info("Indexed %d snapshots, %d snapshots are not indexed", indexed, len(idx.Unindexed(snapshots)))
+		if err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+var indexVersionsCmd = &cobra.Command{
+	Use:   "versions <file>",
+	Short: "List all indexed versions of a file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		idx := loadIndex()
+		dataset := findDatasetOfPathArg(args)
+		path := absolutePathArg(args)
+		snapshots := getSnapshotsForIndex(dataset)
+		warnAboutUnindexedSnapshots(idx, snapshots)
+
+		for _, version := range idx.Versions(snapshots, path) {
+			logging.Printfln("%s  %s  %10s  %s",
+				version.Entry.ModTime.Format(time.DateTime),
+				version.Entry.Mode.String(),
+				humanize.IBytes(uint64(version.Entry.Size)),
+				version.Snapshot.Name,
+			)
+		}
+	},
+}
+
+var indexDeletedCmd = &cobra.Command{
+	Use:   "deleted [path]",
+	Short: "List indexed files which have been deleted since a date",
+	Long: `List the files below the given path (default is the current working directory) which existed at the date given by --since,
+or were created later on, but do not exist anymore. Only indexed snapshots are considered.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := parseIndexDate(indexSince)
+		if err != nil {
+			logging.FatalWithoutStacktrace("Invalid date '%s', expected e.g. 2026-10-01 or 2026-10-01T12:00:00Z", indexSince)
+		}
+		idx := loadIndex()
+		dataset := findDatasetOfPathArg(args)
+		snapshots := getSnapshotsForIndex(dataset)
+		warnAboutUnindexedSnapshots(idx, snapshots)
+
+		files, err := idx.DeletedSince(snapshots, since)
+		if err != nil {
+			logging.FatalWithoutStacktrace("Couldn't query index: %v", err)
+		}
+		printMissingFiles(files, absolutePathArg(args))
+	},
+}
+
+var indexSnapshotOnlyCmd = &cobra.Command{
+	Use:   "snapshot-only [path]",
+	Short: "List indexed files which only exist within snapshots",
+	Long: `List the files below the given path (default is the current working directory) which exist within snapshots,
+but not in the working copy, e.g. to find large deleted files which still occupy space. Only indexed snapshots are considered.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		minSize, err := humanize.ParseBytes(indexMinSize)
+		if err != nil {
+			logging.FatalWithoutStacktrace("Invalid size '%s': %v", indexMinSize, err)
+		}
+		idx := loadIndex()
+		dataset := findDatasetOfPathArg(args)
+		snapshots := getSnapshotsForIndex(dataset)
+		warnAboutUnindexedSnapshots(idx, snapshots)
+
+		files, err := idx.OnlyInSnapshots(snapshots, int64(minSize))
+		if err != nil {
+			logging.FatalWithoutStacktrace("Couldn't query index: %v", err)
+		}
+		printMissingFiles(files, absolutePathArg(args))
+	},
+}
+
+// loadIndex reads the configuration and returns the configured index
+func loadIndex() *index.Index {
+	loadAndValidateConfig()
+	idx := index.Get()
+	if idx == nil {
+		logging.FatalWithoutStacktrace("No index directory available, see the index.path setting of the configuration")
+	}
+	return idx
+}
+
+func getSnapshotsForIndex(dataset *zfs.Dataset) []*zfs.Snapshot {
+	snapshots, warnings, err := dataset.GetSnapshotsWithReplicas()
+	if err != nil {
+		logging.FatalWithoutStacktrace("Couldn't list snapshots of '%s': %v", dataset.GetName(), err)
+	}
+	for _, warning := range warnings {
+		logging.Warning("Skipped replica snapshots: %v", warning)
+	}
+	return snapshots
+}
+
+func warnAboutUnindexedSnapshots(idx *index.Index, snapshots []*zfs.Snapshot) {
+	if unindexed := idx.Unindexed(snapshots); len(unindexed) > 0 {
+		logging.Warning("%d of %d snapshots are not indexed and have been skipped, run the index command to index them", len(unindexed), len(snapshots))
+	}
+}
+
+// absolutePathArg returns the absolute path given as the first argument, defaulting to the current working directory
+func absolutePathArg(args []string) string {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		logging.FatalWithoutStacktrace("Couldn't resolve path: %v", err)
+	}
+	return path
+}
+
+func printMissingFiles(files []index.MissingFile, below string) {
+	for _, file := range files {
+		if file.Path != below && !strings.HasPrefix(file.Path, below+"/") {
+			continue
+		}
+		logging.Printfln("%10s  %s  (last seen in %s)",
+			humanize.IBytes(uint64(file.Latest.Entry.Size)),
+			file.Path,
+			file.Latest.Snapshot.Name,
+		)
+	}
+}
+
+func parseIndexDate(value string) (time.Time, error) {
+	date, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func init() {
+	indexCmd.Flags().BoolVar(&indexHash, "hash", false, "Record a checksum of every file, overrides index.hash of the configuration")
+	indexDeletedCmd.Flags().StringVar(&indexSince, "since", "", "Date since which files have been deleted, e.g. 2026-10-01")
+	_ = indexDeletedCmd.MarkFlagRequired("since")
+	indexSnapshotOnlyCmd.Flags().StringVar(&indexMinSize, "min-size", "0", "Only list files of at least this size, e.g. 100MiB")
+
+	indexCmd.AddCommand(indexVersionsCmd)
+	indexCmd.AddCommand(indexDeletedCmd)
+	indexCmd.AddCommand(indexSnapshotOnlyCmd)
+	rootCmd.AddCommand(indexCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"os"
+	"zfs-file-history/internal/configuration"
+	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/retention"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var pruneDryRun bool
+
+var snapshotPruneCmd = &cobra.Command{
+	Use:   "prune [path]",
+	Short: "Destroy the snapshots which expired according to the retention policy",
+	Long: `Destroy the snapshots of the dataset containing the given path (default is the current working directory)
+which are not kept by the retention policy of the configuration.
+Use --dry-run to only show which snapshots would be destroyed and how much space would be freed.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadAndValidateConfig()
+
+		retentionConfig := configuration.CurrentConfig.Retention
+		if !retentionConfig.IsEnabled() {
+			logging.FatalWithoutStacktrace("No retention policy configured, see the retention section of the configuration")
+		}
+		policy, err := retention.NewPolicy(retentionConfig)
+		if err != nil {
+			logging.FatalWithoutStacktrace("Invalid retention policy: %v", err)
+		}
+
+		dataset := findDatasetOfPathArg(args)
+		requireZfsDataset(dataset, "prune snapshots")
+		snapshots, err := dataset.GetSnapshots()
+		if err != nil {
+			logging.FatalWithoutStacktrace("Couldn't list snapshots of '%s': %v", dataset.GetName(), err)
+		}
+
+		decisions := policy.Evaluate(snapshots)
+		for _, decision := range decisions {
+			if decision.Keep {
+				logging.Printfln("keep   %s (%s)", decision.Snapshot.Name, decision.Reason)
+			} else {
+				logging.Printfln("prune  %s (%s)", decision.Snapshot.Name, decision.Reason)
+			}
+		}
+
+		expired := retention.Expired(decisions)
+		if len(expired) == 0 {
+			logging.Info("Nothing to prune")
+			return
+		}
+
+		estimate, err := zfs.EstimateDestroy(expired, false)
+		if err != nil {
+			logging.Warning("Couldn't estimate the space freed: %v", err)
+		} else {
+			logging.Info("Pruning %d snapshots frees approximately %s", len(expired), humanize.IBytes(estimate.Reclaimable))
+			for _, skipped := range estimate.Skipped {
+				logging.Warning("%s cannot be destroyed: %s", skipped.Snapshot.Name, skipped.Reason)
+			}
+		}
+
+		if pruneDryRun {
+			return
+		}
+
+		failed := 0
+		for _, snapshot := range expired {
+			if retention.HasUnknownCreationDate(snapshot) {
+				// the policy never expires these, this only guards against destroying a snapshot of unknown age
+				logging.Error("Refusing to destroy %s: %s", snapshot.FullName, retention.ReasonUnknownCreationDate)
+				failed++
+				continue
+			}
+			err = dataset.DestroySnapshot(snapshot.Name, false, false)
+			if err != nil {
+				logging.Error("Failed to destroy %s: %v", snapshot.FullName, err)
+				failed++
+				continue
+			}
+			logging.Printfln("destroyed %s", snapshot.FullName)
+		}
+		if failed > 0 {
+			logging.Error("%d of %d snapshots could not be destroyed", failed, len(expired))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	snapshotPruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "Only show what would be destroyed")
+
+	snapshotCmd.AddCommand(snapshotPruneCmd)
+}
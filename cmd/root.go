@@ -7,7 +7,9 @@ import (
 	"zfs-file-history/cmd/global"
 	"zfs-file-history/internal"
 	"zfs-file-history/internal/configuration"
+	"zfs-file-history/internal/index"
 	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/zfs"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -29,6 +31,7 @@ var rootCmd = &cobra.Command{
 			logging.Error("Config Validation Error: %v", err.Error())
 			return
 		}
+		applyConfig()
 
 		var path string
 		if len(args) > 0 {
@@ -56,6 +59,56 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&global.Verbose, "verbose", "v", false, "More verbose output")
 }
 
+// applyConfig passes the configuration to the packages which do not read it themselves
+func applyConfig() {
+	creationDate := configuration.CurrentConfig.Snapshot.CreationDate
+	var customParsers []*zfs.NameTimestampParser
+	for _, parserConfig := range creationDate.CustomParsers {
+		parser, err := zfs.NewNameTimestampParser(parserConfig.Name, parserConfig.Pattern, parserConfig.Layout, parserConfig.UTC)
+		if err != nil {
+			logging.FatalWithoutStacktrace("Config Validation Error: %v", err)
+		}
+		customParsers = append(customParsers, parser)
+	}
+	err := zfs.ConfigureNameTimestamps(creationDate.Parsers, customParsers, creationDate.PreferName)
+	if err != nil {
+		logging.FatalWithoutStacktrace("Config Validation Error: %v", err)
+	}
+
+	providersConfig := configuration.CurrentConfig.Providers
+	var providers []zfs.SnapshotProvider
+	if providersConfig.Snapper {
+		providers = append(providers, zfs.NewSnapperProvider())
+	}
+	if providersConfig.NetApp {
+		providers = append(providers, zfs.NewNetAppProvider())
+	}
+	for _, directory := range providersConfig.Directories {
+		provider, err := zfs.NewDirectoryProvider(directory.Path, directory.Snapshots)
+		if err != nil {
+			logging.FatalWithoutStacktrace("Config Validation Error: %v", err)
+		}
+		providers = append(providers, provider)
+	}
+	zfs.ConfigureSnapshotProviders(providers...)
+
+	replicas := map[string][]string{}
+	for _, replica := range configuration.CurrentConfig.Replicas {
+		replicas[replica.Dataset] = append(replicas[replica.Dataset], replica.Sources...)
+	}
+	zfs.ConfigureReplicas(replicas)
+
+	zfs.ConfigureMetadataCache(configuration.CurrentConfig.Cache.GetPath())
+	index.Configure(configuration.CurrentConfig.Index.GetPath())
+
+	restoreConfig := configuration.CurrentConfig.Restore
+	zfs.ConfigureRestore(zfs.RestoreSettings{
+		Workers:        restoreConfig.Workers,
+		BandwidthLimit: restoreConfig.GetBandwidthLimit(),
+		IdleIoPriority: restoreConfig.IdleIoPriority,
+	})
+}
+
 func setupUi() {
 	logging.SetDebugEnabled(global.Verbose)
 
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"zfs-file-history/internal/configuration"
+	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotName       string
+	snapshotPrompt     string
+	snapshotRecursive  bool
+	snapshotProperties []string
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Manage snapshots of a ZFS dataset",
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create [path]",
+	Short: "Create a snapshot of the dataset containing the given path",
+	Long: `Create a snapshot of the dataset containing the given path (default is the current working directory).
+Unless --name is given, the snapshot is named using the snapshot.nameTemplate of the configuration.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		loadAndValidateConfig()
+		dataset := findDatasetOfPathArg(args)
+		requireZfsDataset(dataset, "create snapshot")
+
+		var err error
+		name := snapshotName
+		if name == "" {
+			name, err = zfs.RenderSnapshotName(configuration.CurrentConfig.Snapshot.GetNameTemplate(), snapshotPrompt)
+			if err != nil {
+				logging.FatalWithoutStacktrace("Couldn't render snapshot name: %v", err)
+			}
+		}
+
+		properties := map[string]string{}
+		for _, assignment := range snapshotProperties {
+			key, value, err := zfs.ParseUserProperty(assignment)
+			if err != nil {
+				logging.FatalWithoutStacktrace("Invalid property: %v", err)
+			}
+			properties[key] = value
+		}
+
+		err = dataset.CreateSnapshot(name, snapshotRecursive, properties)
+		if err != nil {
+			logging.FatalWithoutStacktrace("Failed to create snapshot: %v", err)
+		}
+		logging.Printfln("%s@%s", dataset.GetName(), name)
+	},
+}
+
+// loadAndValidateConfig reads the configuration file and exits if it is invalid
+func loadAndValidateConfig() {
+	configPath := configuration.DetectAndReadConfigFile()
+	configuration.LoadConfig()
+	err := configuration.Validate(configPath)
+	if err != nil {
+		logging.FatalWithoutStacktrace("Config Validation Error: %v", err.Error())
+	}
+	applyConfig()
+}
+
+// findDatasetOfPathArg returns the dataset containing the path given as the first argument,
+// defaulting to the current working directory
+func findDatasetOfPathArg(args []string) *zfs.Dataset {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		logging.FatalWithoutStacktrace("Couldn't resolve path: %v", err)
+	}
+	if _, err = os.Stat(path); err != nil {
+		logging.FatalWithoutStacktrace("Couldn't access path: %v", err)
+	}
+
+	dataset, err := zfs.FindHostDataset(path)
+	if err != nil {
+		logging.FatalWithoutStacktrace("Couldn't find dataset of '%s': %v", path, err)
+	}
+	return dataset
+}
+
+// requireZfsDataset exits if the given dataset is not a zfs dataset, as snapshots of other providers are read-only
+func requireZfsDataset(dataset *zfs.Dataset, action string) {
+	if !dataset.IsZfs() {
+		logging.FatalWithoutStacktrace("Cannot %s: snapshots of '%s' are provided by %s, which is only supported for browsing and restoring", action, dataset.Path, dataset.GetProvider().Name())
+	}
+}
+
+func init() {
+	snapshotCreateCmd.Flags().StringVarP(&snapshotName, "name", "n", "", "Name of the snapshot, overrides the configured name template")
+	snapshotCreateCmd.Flags().StringVarP(&snapshotPrompt, "prompt", "p", "", "Value of the {prompt} placeholder of the name template")
+	snapshotCreateCmd.Flags().BoolVarP(&snapshotRecursive, "recursive", "r", false, "Also snapshot all child datasets")
+	snapshotCreateCmd.Flags().StringArrayVarP(&snapshotProperties, "property", "o", nil, "User property to set on the snapshot, in the form key=value (can be repeated)")
+
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
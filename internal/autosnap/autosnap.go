@@ -0,0 +1,92 @@
+package autosnap
+
+import (
+	"regexp"
+	"time"
+)
+
+const (
+	ToolSanoid          = "sanoid"
+	ToolZfsAutoSnapshot = "zfs-auto-snapshot"
+)
+
+// Naming schemes of the supported tools, the "time" group is parsed using the corresponding layout.
+// These are also used to derive the creation date of snapshots from their name.
+const (
+	// SanoidPattern matches e.g. "autosnap_2026-10-01_00:00:00_daily"
+	SanoidPattern    = `^autosnap_(?P<time>\d{4}-\d{2}-\d{2}_\d{2}:\d{2}:\d{2})_(?P<interval>[a-z]+)$`
+	SanoidTimeLayout = "2006-01-02_15:04:05"
+	// ZfsAutoSnapshotPattern matches e.g. "zfs-auto-snap_hourly-2026-10-01-0017", zfs-auto-snapshot uses UTC timestamps
+	ZfsAutoSnapshotPattern    = `^zfs-auto-snap[_-](?P<interval>[a-z]+)-(?P<time>\d{4}-\d{2}-\d{2}-\d{4})$`
+	ZfsAutoSnapshotTimeLayout = "2006-01-02-1504"
+)
+
+// Origin describes which snapshot tool created a snapshot, as encoded in its name
+type Origin struct {
+	Tool string
+	// Interval is the snapshot type of the tool, e.g. "hourly" or "daily"
+	Interval string
+	// Created is the time encoded in the snapshot name
+	Created time.Time
+	// Expires is the time at which the tool destroys the snapshot, zero if unknown
+	Expires time.Time
+}
+
+// namingScheme matches snapshot names of a single tool
+type namingScheme struct {
+	tool       string
+	expression *regexp.Regexp
+	timeLayout string
+	location   *time.Location
+}
+
+var namingSchemes = []namingScheme{
+	{
+		tool:       ToolSanoid,
+		expression: regexp.MustCompile(SanoidPattern),
+		timeLayout: SanoidTimeLayout,
+		location:   time.Local,
+	},
+	{
+		tool:       ToolZfsAutoSnapshot,
+		expression: regexp.MustCompile(ZfsAutoSnapshotPattern),
+		timeLayout: ZfsAutoSnapshotTimeLayout,
+		location:   time.UTC,
+	},
+}
+
+// ParseName determines the origin of a snapshot from its name.
+// Returns false if the name does not follow any of the known naming schemes.
+func ParseName(name string) (*Origin, bool) {
+	for _, scheme := range namingSchemes {
+		match := scheme.expression.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		created, err := time.ParseInLocation(scheme.timeLayout, match[scheme.expression.SubexpIndex("time")], scheme.location)
+		if err != nil {
+			continue
+		}
+		return &Origin{
+			Tool:     scheme.tool,
+			Interval: match[scheme.expression.SubexpIndex("interval")],
+			Created:  created,
+		}, true
+	}
+	return nil, false
+}
+
+// Resolve determines the origin of the snapshot with the given name on the given dataset.
+// If sanoid is not nil, the expiry of sanoid snapshots is derived from its retention settings.
+func Resolve(datasetName string, snapshotName string, sanoid *SanoidConfig) (*Origin, bool) {
+	origin, ok := ParseName(snapshotName)
+	if !ok {
+		return nil, false
+	}
+	if origin.Tool == ToolSanoid && sanoid != nil {
+		if maxAge, ok := sanoid.MaxAge(datasetName, origin.Interval); ok {
+			origin.Expires = origin.Created.Add(maxAge)
+		}
+	}
+	return origin, true
+}
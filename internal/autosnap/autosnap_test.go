@@ -0,0 +1,56 @@
+package autosnap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseName(t *testing.T) {
+	origin, ok := ParseName("autosnap_2026-10-01_00:00:00_daily")
+	assert.True(t, ok)
+	assert.Equal(t, &Origin{
+		Tool:     ToolSanoid,
+		Interval: "daily",
+		Created:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+	}, origin)
+
+	origin, ok = ParseName("zfs-auto-snap_hourly-2026-10-01-0017")
+	assert.True(t, ok)
+	assert.Equal(t, &Origin{
+		Tool:     ToolZfsAutoSnapshot,
+		Interval: "hourly",
+		Created:  time.Date(2026, 10, 1, 0, 17, 0, 0, time.UTC),
+	}, origin)
+
+	origin, ok = ParseName("zfs-auto-snap-frequent-2026-10-01-0015")
+	assert.True(t, ok)
+	assert.Equal(t, "frequent", origin.Interval)
+
+	for _, name := range []string{"zfh-2026-10-01-000000", "autosnap_2026-13-01_00:00:00_daily", "autosnap_2026-10-01_daily", ""} {
+		_, ok = ParseName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	sanoid := &SanoidConfig{datasets: map[string]map[string]string{
+		"tank/data": {"daily": "30", "recursive": "no"},
+	}}
+
+	origin, ok := Resolve("tank/data", "autosnap_2026-10-01_00:00:00_daily", sanoid)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 31, 0, 0, 0, 0, time.Local), origin.Expires)
+
+	origin, ok = Resolve("tank/other", "autosnap_2026-10-01_00:00:00_daily", sanoid)
+	assert.True(t, ok)
+	assert.True(t, origin.Expires.IsZero())
+
+	origin, ok = Resolve("tank/data", "autosnap_2026-10-01_00:00:00_daily", nil)
+	assert.True(t, ok)
+	assert.True(t, origin.Expires.IsZero())
+
+	_, ok = Resolve("tank/data", "manual", sanoid)
+	assert.False(t, ok)
+}
@@ -0,0 +1,170 @@
+package autosnap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const sanoidTemplatePrefix = "template_"
+
+// sanoidDefaults are the retention values sanoid uses if neither the dataset nor its templates define them
+var sanoidDefaults = map[string]string{
+	"frequent_period": "15",
+	"frequently":      "0",
+	"hourly":          "48",
+	"daily":           "90",
+	"weekly":          "0",
+	"monthly":         "6",
+	"yearly":          "0",
+	"recursive":       "no",
+}
+
+// SanoidConfig contains the dataset sections of a sanoid.conf, with their templates already applied
+type SanoidConfig struct {
+	datasets map[string]map[string]string
+}
+
+// LoadSanoidConfig reads the sanoid configuration at the given path
+func LoadSanoidConfig(path string) (*SanoidConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseSanoidConfig(file)
+}
+
+// ParseSanoidConfig parses the ini style format of sanoid.conf
+func ParseSanoidConfig(reader io.Reader) (*SanoidConfig, error) {
+	sections := map[string]map[string]string{}
+	var current map[string]string
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			current = map[string]string{}
+			sections[name] = current
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected 'key = value': %s", lineNumber, line)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: value outside of a section: %s", lineNumber, line)
+		}
+		current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	config := &SanoidConfig{datasets: map[string]map[string]string{}}
+	for name, values := range sections {
+		if strings.HasPrefix(name, sanoidTemplatePrefix) {
+			continue
+		}
+		resolved, err := resolveSanoidSection(values, sections)
+		if err != nil {
+			return nil, fmt.Errorf("[%s]: %w", name, err)
+		}
+		config.datasets[name] = resolved
+	}
+	return config, nil
+}
+
+// resolveSanoidSection merges the defaults, the templates referenced by use_template and the values of the section itself
+func resolveSanoidSection(values map[string]string, sections map[string]map[string]string) (map[string]string, error) {
+	result := map[string]string{}
+	for key, value := range sanoidDefaults {
+		result[key] = value
+	}
+	if template, ok := sections[sanoidTemplatePrefix+"default"]; ok {
+		for key, value := range template {
+			result[key] = value
+		}
+	}
+	if useTemplate, ok := values["use_template"]; ok {
+		for _, name := range strings.Split(useTemplate, ",") {
+			template, ok := sections[sanoidTemplatePrefix+strings.TrimSpace(name)]
+			if !ok {
+				return nil, errors.New("unknown template: " + strings.TrimSpace(name))
+			}
+			for key, value := range template {
+				result[key] = value
+			}
+		}
+	}
+	for key, value := range values {
+		result[key] = value
+	}
+	return result, nil
+}
+
+// findDataset returns the settings which apply to the given dataset: either its own section,
+// or the section of the closest parent which is configured recursively
+func (config *SanoidConfig) findDataset(datasetName string) (map[string]string, bool) {
+	if values, ok := config.datasets[datasetName]; ok {
+		return values, true
+	}
+	for parent := datasetName; strings.Contains(parent, "/"); {
+		parent = parent[:strings.LastIndex(parent, "/")]
+		values, ok := config.datasets[parent]
+		if !ok {
+			continue
+		}
+		if recursive := values["recursive"]; recursive == "yes" || recursive == "zfs" {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+// MaxAge returns how long sanoid keeps snapshots of the given interval on the given dataset.
+// Like sanoid itself, this is the amount of snapshots to keep multiplied by the length of the interval.
+func (config *SanoidConfig) MaxAge(datasetName string, interval string) (time.Duration, bool) {
+	values, ok := config.findDataset(datasetName)
+	if !ok {
+		return 0, false
+	}
+	count, err := strconv.Atoi(values[interval])
+	if err != nil || count <= 0 {
+		return 0, false
+	}
+
+	var period time.Duration
+	switch interval {
+	case "frequently":
+		minutes, err := strconv.Atoi(values["frequent_period"])
+		if err != nil || minutes <= 0 {
+			return 0, false
+		}
+		period = time.Duration(minutes) * time.Minute
+	case "hourly":
+		period = time.Hour
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	case "monthly":
+		period = 31 * 24 * time.Hour
+	case "yearly":
+		period = 365 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+	return time.Duration(count) * period, true
+}
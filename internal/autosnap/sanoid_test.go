@@ -0,0 +1,81 @@
+package autosnap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSanoidConfig = `
+# datasets
+[tank/home]
+	use_template = production
+	recursive = yes
+	daily = 14
+
+[tank/home/scratch]
+	use_template = production, scratch
+
+[tank/vm]
+	hourly = 12
+
+[template_production]
+	frequently = 4
+	frequent_period = 10
+	hourly = 36
+	daily = 30
+	monthly = 3
+
+; no long term snapshots
+[template_scratch]
+	monthly = 0
+`
+
+func TestParseSanoidConfig(t *testing.T) {
+	config, err := ParseSanoidConfig(strings.NewReader(testSanoidConfig))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		dataset  string
+		interval string
+		maxAge   time.Duration
+		ok       bool
+	}{
+		{"tank/home", "hourly", 36 * time.Hour, true},
+		// values of the section override the template
+		{"tank/home", "daily", 14 * 24 * time.Hour, true},
+		{"tank/home", "frequently", 40 * time.Minute, true},
+		{"tank/home", "monthly", 3 * 31 * 24 * time.Hour, true},
+		{"tank/home", "weekly", 0, false},
+		// inherited from the recursive parent
+		{"tank/home/user/documents", "daily", 14 * 24 * time.Hour, true},
+		// templates are applied in order
+		{"tank/home/scratch", "daily", 30 * 24 * time.Hour, true},
+		{"tank/home/scratch", "monthly", 0, false},
+		// sanoid defaults
+		{"tank/vm", "hourly", 12 * time.Hour, true},
+		{"tank/vm", "daily", 90 * 24 * time.Hour, true},
+		// not recursive
+		{"tank/vm/disk0", "hourly", 0, false},
+		{"other", "daily", 0, false},
+		{"tank/home", "unknown", 0, false},
+	}
+	for _, test := range tests {
+		maxAge, ok := config.MaxAge(test.dataset, test.interval)
+		assert.Equal(t, test.ok, ok, "%s %s", test.dataset, test.interval)
+		assert.Equal(t, test.maxAge, maxAge, "%s %s", test.dataset, test.interval)
+	}
+}
+
+func TestParseSanoidConfig_Invalid(t *testing.T) {
+	_, err := ParseSanoidConfig(strings.NewReader("hourly = 1\n"))
+	assert.Error(t, err)
+
+	_, err = ParseSanoidConfig(strings.NewReader("[tank]\nhourly\n"))
+	assert.Error(t, err)
+
+	_, err = ParseSanoidConfig(strings.NewReader("[tank]\nuse_template = missing\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,28 @@
+package configuration
+
+import (
+	"os"
+	path2 "path"
+)
+
+// CacheConfig controls the persistent cache of snapshot metadata, which speeds up subsequent runs
+type CacheConfig struct {
+	Enabled bool `json:"enabled"`
+	// Path is the cache directory, defaults to "$XDG_CACHE_HOME/zfs-file-history"
+	Path string `json:"path"`
+}
+
+// GetPath returns the cache directory, or an empty string if the cache is disabled or there is no cache directory
+func (config CacheConfig) GetPath() string {
+	if !config.Enabled {
+		return ""
+	}
+	if config.Path != "" {
+		return config.Path
+	}
+	cacheHome, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return path2.Join(cacheHome, "zfs-file-history")
+}
@@ -4,15 +4,24 @@ import (
 	"os"
 	path2 "path"
 	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/util"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
 
 type Configuration struct {
+	Cache       CacheConfig       `json:"cache"`
+	DatasetInfo DatasetInfoConfig `json:"datasetInfo"`
 	Diff        DiffConfig        `json:"diff"`
 	FileBrowser FileBrowserConfig `json:"fileBrowser"`
+	Index       IndexConfig       `json:"index"`
 	Profiling   ProfilingConfig   `json:"profiling"`
+	Providers   ProvidersConfig   `json:"providers"`
+	Replicas    []ReplicaConfig   `json:"replicas"`
+	Restore     RestoreConfig     `json:"restore"`
+	Retention   RetentionConfig   `json:"retention"`
+	Snapshot    SnapshotConfig    `json:"snapshot"`
 }
 
 var CurrentConfig Configuration
@@ -45,6 +54,17 @@ func InitConfig(cfgFile string) {
 }
 
 func setDefaultValues() {
+	viper.SetDefault("Cache", CacheConfig{
+		Enabled: true,
+	})
+	viper.SetDefault("Cache.Enabled", true)
+	viper.SetDefault("Cache.Path", "")
+
+	viper.SetDefault("DatasetInfo", DatasetInfoConfig{
+		UsageBars: true,
+	})
+	viper.SetDefault("DatasetInfo.UsageBars", true)
+
 	viper.SetDefault("Diff", DiffConfig{
 		Mode:     DiffModeExternal,
 		External: nil,
@@ -62,6 +82,11 @@ func setDefaultValues() {
 	viper.SetDefault("FileBrowser.Permissions", FileBrowserPermissionsFormatSymbolic)
 	viper.SetDefault("FileBrowser.Owner", FileBrowserOwnerFormatName)
 
+	viper.SetDefault("Index", IndexConfig{})
+	viper.SetDefault("Index.Background", false)
+	viper.SetDefault("Index.Hash", false)
+	viper.SetDefault("Index.Path", "")
+
 	viper.SetDefault("Profiling", ProfilingConfig{
 		Enabled: false,
 		Host:    "localhost",
@@ -69,6 +94,47 @@ func setDefaultValues() {
 	})
 	viper.SetDefault("Profiling.Host", "localhost")
 	viper.SetDefault("Profiling.Port", 6060)
+
+	viper.SetDefault("Providers", ProvidersConfig{
+		Snapper: true,
+		NetApp:  true,
+	})
+	viper.SetDefault("Providers.Snapper", true)
+	viper.SetDefault("Providers.NetApp", true)
+	viper.SetDefault("Providers.Directories", []DirectoryProviderConfig{})
+
+	viper.SetDefault("Replicas", []ReplicaConfig{})
+
+	viper.SetDefault("Restore", RestoreConfig{
+		Workers: util.DefaultRestoreWorkers,
+	})
+	viper.SetDefault("Restore.Workers", util.DefaultRestoreWorkers)
+	viper.SetDefault("Restore.BandwidthLimit", 0)
+	viper.SetDefault("Restore.IdleIoPriority", false)
+
+	viper.SetDefault("Retention", RetentionConfig{})
+	viper.SetDefault("Retention.Hourly", 0)
+	viper.SetDefault("Retention.Daily", 0)
+	viper.SetDefault("Retention.Weekly", 0)
+	viper.SetDefault("Retention.Monthly", 0)
+	viper.SetDefault("Retention.Include", []string{})
+	viper.SetDefault("Retention.Exclude", []string{})
+
+	viper.SetDefault("Snapshot", SnapshotConfig{
+		NameTemplate:   DefaultSnapshotNameTemplate,
+		UserProperties: []string{NoteUserProperty},
+		SanoidConfig:   DefaultSanoidConfig,
+		CreationDate: SnapshotCreationDateConfig{
+			PreferName: false,
+			Parsers:    DefaultSnapshotNameParsers,
+		},
+	})
+	viper.SetDefault("Snapshot.NameTemplate", DefaultSnapshotNameTemplate)
+	viper.SetDefault("Snapshot.UserProperties", []string{NoteUserProperty})
+	viper.SetDefault("Snapshot.SanoidConfig", DefaultSanoidConfig)
+	viper.SetDefault("Snapshot.CreationDate.PreferName", false)
+	viper.SetDefault("Snapshot.CreationDate.Parsers", DefaultSnapshotNameParsers)
+	viper.SetDefault("Snapshot.CreationDate.CustomParsers", []SnapshotNameParserConfig{})
 }
 
 // DetectAndReadConfigFile detects the path of the first existing config file
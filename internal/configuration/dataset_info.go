@@ -0,0 +1,6 @@
+package configuration
+
+type DatasetInfoConfig struct {
+	// UsageBars shows the pool capacity and the space used by snapshots as bars below the dataset properties
+	UsageBars bool `json:"usageBars"`
+}
@@ -0,0 +1,28 @@
+package configuration
+
+import (
+	"os"
+	path2 "path"
+)
+
+// IndexConfig controls the index of the files within snapshots, see the "index" command
+type IndexConfig struct {
+	// Background indexes the snapshots of the browsed dataset while the UI is running
+	Background bool `json:"background"`
+	// Hash records a checksum of every file, which requires reading the content of all snapshots
+	Hash bool `json:"hash"`
+	// Path is the index directory, defaults to "$XDG_CACHE_HOME/zfs-file-history/index"
+	Path string `json:"path"`
+}
+
+// GetPath returns the index directory, or an empty string if there is no cache directory
+func (config IndexConfig) GetPath() string {
+	if config.Path != "" {
+		return config.Path
+	}
+	cacheHome, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return path2.Join(cacheHome, "zfs-file-history", "index")
+}
@@ -0,0 +1,20 @@
+package configuration
+
+// ProvidersConfig configures where snapshots are looked for besides ZFS
+type ProvidersConfig struct {
+	// Snapper enables btrfs snapshots managed by snapper, found in "<subvolume>/.snapshots/<number>/snapshot"
+	Snapper bool `json:"snapper"`
+	// NetApp enables the ".snapshot/<name>" directories of NFS shares, e.g. from NetApp filers
+	NetApp bool `json:"netApp"`
+	// Directories lists backup trees (e.g. of rsnapshot or a restic mount) which contain one copy of a directory per snapshot
+	Directories []DirectoryProviderConfig `json:"directories"`
+}
+
+// DirectoryProviderConfig maps the dated copies of a backup tree to the directory they are a backup of
+type DirectoryProviderConfig struct {
+	// Path is the directory which is backed up, e.g. "/home"
+	Path string `json:"path"`
+	// Snapshots is a glob pattern matching the copies of Path, e.g. "/backup/rsnapshot/*/localhost/home".
+	// The path segment matched by the first wildcard is used as the name of the snapshot.
+	Snapshots string `json:"snapshots"`
+}
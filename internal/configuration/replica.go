@@ -0,0 +1,10 @@
+package configuration
+
+// ReplicaConfig maps a dataset to copies of it, e.g. received with "zfs recv" on a backup pool.
+// The snapshots of the replicas are shown alongside those of the dataset.
+type ReplicaConfig struct {
+	// Dataset is the name or mountpoint of the primary dataset
+	Dataset string `json:"dataset"`
+	// Sources are the names or mountpoints of the replicas of Dataset
+	Sources []string `json:"sources"`
+}
@@ -0,0 +1,21 @@
+package configuration
+
+import "github.com/dustin/go-humanize"
+
+// RestoreConfig limits how much of the I/O capacity of the system a restore may use.
+// All values can be adjusted from the progress dialog while a restore is running.
+type RestoreConfig struct {
+	// Workers is the number of files which are copied in parallel, 1 copies one file after another.
+	// At most util.MaxRestoreWorkers are allowed.
+	Workers int `json:"workers"`
+	// BandwidthLimit is the maximum number of megabytes per second which are copied, 0 is unlimited
+	BandwidthLimit int `json:"bandwidthLimit"`
+	// IdleIoPriority copies files with the idle I/O scheduling class (see ioprio_set(2)),
+	// so the disks are only used when no other process needs them
+	IdleIoPriority bool `json:"idleIoPriority"`
+}
+
+// GetBandwidthLimit returns the bandwidth limit in bytes per second, 0 is unlimited
+func (config RestoreConfig) GetBandwidthLimit() int64 {
+	return int64(config.BandwidthLimit) * humanize.MByte
+}
@@ -0,0 +1,22 @@
+package configuration
+
+// RetentionConfig describes which snapshots are kept when pruning a dataset.
+// For each of the most recent Hourly hours, Daily days, Weekly weeks and Monthly months,
+// the newest snapshot of that period is kept. All other snapshots expire.
+type RetentionConfig struct {
+	Hourly  int `json:"hourly"`
+	Daily   int `json:"daily"`
+	Weekly  int `json:"weekly"`
+	Monthly int `json:"monthly"`
+	// Include is a list of regular expressions, only snapshots whose name matches one of them are subject to
+	// the policy. If empty, all snapshots are.
+	Include []string `json:"include"`
+	// Exclude is a list of regular expressions, snapshots whose name matches one of them are always kept
+	Exclude []string `json:"exclude"`
+}
+
+// IsEnabled returns true if at least one period is configured.
+// Without any period every snapshot would expire, so an empty policy is treated as disabled.
+func (config RetentionConfig) IsEnabled() bool {
+	return config.Hourly > 0 || config.Daily > 0 || config.Weekly > 0 || config.Monthly > 0
+}
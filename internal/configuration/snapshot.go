@@ -0,0 +1,69 @@
+package configuration
+
+import "slices"
+
+// DefaultSnapshotNameParsers contains all built-in parsers for timestamps in snapshot names
+var DefaultSnapshotNameParsers = []string{"sanoid", "zfs-auto-snapshot", "zrepl", "zfs-file-history"}
+
+const (
+	DefaultSnapshotNameTemplate = "zfh-{date}"
+	DefaultSanoidConfig         = "/etc/sanoid/sanoid.conf"
+
+	// NoteUserProperty is the user property edited by the "Edit note" action
+	NoteUserProperty = "zfh:note"
+)
+
+type SnapshotConfig struct {
+	// NameTemplate is used to generate the default name of new snapshots.
+	// Supported placeholders: {date}, {date:<go time layout>}, {user}, {hostname} and {prompt}
+	NameTemplate string `json:"nameTemplate"`
+	// UserProperties is a list of user properties which are shown as columns in the snapshot browser
+	UserProperties []string `json:"userProperties"`
+	// SanoidConfig is the path of the sanoid configuration used to determine when sanoid snapshots expire.
+	// An empty value or a missing file disables this.
+	SanoidConfig string `json:"sanoidConfig"`
+	// CreationDate configures how the creation date of snapshots is determined
+	CreationDate SnapshotCreationDateConfig `json:"creationDate"`
+}
+
+type SnapshotCreationDateConfig struct {
+	// PreferName uses the timestamp within the snapshot name, if there is one, instead of the "creation" property.
+	// This is useful for received snapshots, whose "creation" property is the time they were received.
+	PreferName bool `json:"preferName"`
+	// Parsers are the names of the built-in parsers used to read timestamps from snapshot names,
+	// see DefaultSnapshotNameParsers
+	Parsers []string `json:"parsers"`
+	// CustomParsers are tried before the built-in parsers
+	CustomParsers []SnapshotNameParserConfig `json:"customParsers"`
+}
+
+// SnapshotNameParserConfig describes how to read the timestamp from the names of snapshots following a custom naming scheme
+type SnapshotNameParserConfig struct {
+	Name string `json:"name"`
+	// Pattern is a regular expression, the text of its "time" group (or of the whole match) is parsed using Layout
+	Pattern string `json:"pattern"`
+	// Layout is a Go time layout, e.g. "2006-01-02_15.04"
+	Layout string `json:"layout"`
+	// UTC interprets the timestamp as UTC instead of local time
+	UTC bool `json:"utc"`
+}
+
+// GetNameTemplate returns the configured name template, falling back to DefaultSnapshotNameTemplate
+func (config SnapshotConfig) GetNameTemplate() string {
+	if config.NameTemplate == "" {
+		return DefaultSnapshotNameTemplate
+	}
+	return config.NameTemplate
+}
+
+// GetEditableUserProperties returns the user properties which can be edited via the "Edit note" action:
+// NoteUserProperty followed by all other configured UserProperties
+func (config SnapshotConfig) GetEditableUserProperties() []string {
+	result := []string{NoteUserProperty}
+	for _, property := range config.UserProperties {
+		if !slices.Contains(result, property) {
+			result = append(result, property)
+		}
+	}
+	return result
+}
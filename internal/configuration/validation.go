@@ -2,7 +2,11 @@ package configuration
 
 import (
 	"fmt"
+	path2 "path"
+	"regexp"
+	"slices"
 	"strings"
+	"zfs-file-history/internal/util"
 )
 
 func Validate(configPath string) error {
@@ -29,6 +33,167 @@ func validateConfig(config *Configuration, path string) error {
 		return fmt.Errorf("%s: %w", prefix, err)
 	}
 
+	err = validateSnapshot(config.Snapshot)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	err = validateRetention(config.Retention)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	err = validateProviders(config.Providers)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	err = validateReplicas(config.Replicas)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	err = validateCache(config.Cache)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	err = validateIndex(config.Index)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	err = validateRestore(config.Restore)
+	if err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+
+	return nil
+}
+
+func validateSnapshot(snapshot SnapshotConfig) error {
+	if snapshot.NameTemplate != "" {
+		if err := util.ValidateNameTemplate(snapshot.NameTemplate); err != nil {
+			return fmt.Errorf("snapshot.nameTemplate: %w", err)
+		}
+	}
+	for _, property := range snapshot.UserProperties {
+		if err := util.ValidateUserPropertyName(property); err != nil {
+			return fmt.Errorf("snapshot.userProperties: %w", err)
+		}
+	}
+	for _, parser := range snapshot.CreationDate.Parsers {
+		if !slices.Contains(DefaultSnapshotNameParsers, parser) {
+			return fmt.Errorf("snapshot.creationDate.parsers: unknown parser '%s', must be one of: %s", parser, strings.Join(DefaultSnapshotNameParsers, ", "))
+		}
+	}
+	for _, parser := range snapshot.CreationDate.CustomParsers {
+		if strings.TrimSpace(parser.Name) == "" {
+			return fmt.Errorf("snapshot.creationDate.customParsers: name must not be empty")
+		}
+		if _, err := regexp.Compile(parser.Pattern); err != nil {
+			return fmt.Errorf("snapshot.creationDate.customParsers (%s): %w", parser.Name, err)
+		}
+		if parser.Layout == "" {
+			return fmt.Errorf("snapshot.creationDate.customParsers (%s): layout must not be empty", parser.Name)
+		}
+	}
+	return nil
+}
+
+func validateRetention(retention RetentionConfig) error {
+	periods := []struct {
+		name  string
+		count int
+	}{
+		{"hourly", retention.Hourly},
+		{"daily", retention.Daily},
+		{"weekly", retention.Weekly},
+		{"monthly", retention.Monthly},
+	}
+	for _, period := range periods {
+		if period.count < 0 {
+			return fmt.Errorf("retention.%s must not be negative", period.name)
+		}
+	}
+	for _, pattern := range retention.Include {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("retention.include: %w", err)
+		}
+	}
+	for _, pattern := range retention.Exclude {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("retention.exclude: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateProviders(providers ProvidersConfig) error {
+	for _, directory := range providers.Directories {
+		if !path2.IsAbs(directory.Path) {
+			return fmt.Errorf("providers.directories: path must be absolute: '%s'", directory.Path)
+		}
+		if !path2.IsAbs(directory.Snapshots) {
+			return fmt.Errorf("providers.directories (%s): snapshots must be an absolute path: '%s'", directory.Path, directory.Snapshots)
+		}
+		if _, err := path2.Match(directory.Snapshots, ""); err != nil {
+			return fmt.Errorf("providers.directories (%s): invalid snapshots pattern: %w", directory.Path, err)
+		}
+		if !strings.ContainsAny(directory.Snapshots, "*?[") {
+			return fmt.Errorf("providers.directories (%s): snapshots pattern must contain a wildcard for the snapshot name", directory.Path)
+		}
+	}
+	return nil
+}
+
+func validateReplicas(replicas []ReplicaConfig) error {
+	for _, replica := range replicas {
+		if strings.TrimSpace(replica.Dataset) == "" {
+			return fmt.Errorf("replicas: dataset must not be empty")
+		}
+		if len(replica.Sources) == 0 {
+			return fmt.Errorf("replicas (%s): sources must not be empty", replica.Dataset)
+		}
+		for _, source := range replica.Sources {
+			if strings.TrimSpace(source) == "" {
+				return fmt.Errorf("replicas (%s): source must not be empty", replica.Dataset)
+			}
+			if strings.Contains(source, "@") {
+				return fmt.Errorf("replicas (%s): source must be a dataset or mountpoint, not a snapshot: '%s'", replica.Dataset, source)
+			}
+			if source == replica.Dataset {
+				return fmt.Errorf("replicas (%s): dataset must not be a replica of itself", replica.Dataset)
+			}
+		}
+	}
+	return nil
+}
+
+func validateCache(cache CacheConfig) error {
+	if cache.Path != "" && !path2.IsAbs(cache.Path) {
+		return fmt.Errorf("cache.path: must be absolute: '%s'", cache.Path)
+	}
+	return nil
+}
+
+func validateIndex(index IndexConfig) error {
+	if index.Path != "" && !path2.IsAbs(index.Path) {
+		return fmt.Errorf("index.path: must be absolute: '%s'", index.Path)
+	}
+	return nil
+}
+
+func validateRestore(restore RestoreConfig) error {
+	if restore.Workers < 0 {
+		return fmt.Errorf("restore.workers must not be negative")
+	}
+	if restore.Workers > util.MaxRestoreWorkers {
+		return fmt.Errorf("restore.workers must not be greater than %d", util.MaxRestoreWorkers)
+	}
+	if restore.BandwidthLimit < 0 {
+		return fmt.Errorf("restore.bandwidthLimit must not be negative")
+	}
 	return nil
 }
 
@@ -113,6 +113,203 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid snapshot name template",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Snapshot:    SnapshotConfig{NameTemplate: "{hostname}-{prompt}-{date:20060102}"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid snapshot name template",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Snapshot:    SnapshotConfig{NameTemplate: "zfh-{unknown}"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid snapshot user properties",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Snapshot:    SnapshotConfig{UserProperties: []string{"zfh:note", "com.sun:auto-snapshot"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "native property as snapshot user property",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Snapshot:    SnapshotConfig{UserProperties: []string{"compression"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid snapshot name parsers",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Snapshot: SnapshotConfig{CreationDate: SnapshotCreationDateConfig{
+					Parsers:       []string{"sanoid", "zrepl"},
+					CustomParsers: []SnapshotNameParserConfig{{Name: "backup", Pattern: `backup-(?P<time>\d{8})`, Layout: "20060102"}},
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown snapshot name parser",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Snapshot:    SnapshotConfig{CreationDate: SnapshotCreationDateConfig{Parsers: []string{"zfsnap"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom snapshot name parser without layout",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Snapshot: SnapshotConfig{CreationDate: SnapshotCreationDateConfig{
+					CustomParsers: []SnapshotNameParserConfig{{Name: "backup", Pattern: `\d{8}`}},
+				}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid retention policy",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Retention:   RetentionConfig{Hourly: 24, Daily: 7, Include: []string{"^zfh-"}, Exclude: []string{"keep"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative retention period",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Retention:   RetentionConfig{Daily: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid retention pattern",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Retention:   RetentionConfig{Daily: 7, Exclude: []string{"("}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid replicas",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Replicas:    []ReplicaConfig{{Dataset: "tank/home", Sources: []string{"backup/home", "/mnt/backup/home"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "replica without sources",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Replicas:    []ReplicaConfig{{Dataset: "tank/home"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "snapshot as replica source",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Replicas:    []ReplicaConfig{{Dataset: "tank/home", Sources: []string{"backup/home@daily"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative cache path",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Cache:       CacheConfig{Enabled: true, Path: "cache"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative index path",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Index:       IndexConfig{Path: "index"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid restore settings",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Restore:     RestoreConfig{Workers: 8, BandwidthLimit: 100, IdleIoPriority: true},
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative restore workers",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Restore:     RestoreConfig{Workers: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "maximum restore workers",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Restore:     RestoreConfig{Workers: 64},
+			},
+			wantErr: false,
+		},
+		{
+			name: "too many restore workers",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Restore:     RestoreConfig{Workers: 65},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative restore bandwidth limit",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Restore:     RestoreConfig{BandwidthLimit: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid snapshot directory provider",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Providers:   ProvidersConfig{Directories: []DirectoryProviderConfig{{Path: "/home", Snapshots: "/backup/*/localhost/home"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "relative snapshot directory provider path",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Providers:   ProvidersConfig{Directories: []DirectoryProviderConfig{{Path: "home", Snapshots: "/backup/*/localhost/home"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "snapshot directory provider pattern without wildcard",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Providers:   ProvidersConfig{Directories: []DirectoryProviderConfig{{Path: "/home", Snapshots: "/backup/daily.0/localhost/home"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid snapshot directory provider pattern",
+			config: &Configuration{
+				FileBrowser: FileBrowserConfig{Permissions: FileBrowserPermissionsFormatOctal, Owner: FileBrowserOwnerFormatID},
+				Providers:   ProvidersConfig{Directories: []DirectoryProviderConfig{{Path: "/home", Snapshots: "/backup/[*/home"}}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tc := range tests {
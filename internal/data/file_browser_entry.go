@@ -56,6 +56,8 @@ type FileBrowserEntry struct {
 	Type          FileBrowserEntryType
 	DiffState     diff_state.DiffState
 	IsLoading     bool
+	// IsDatasetBoundary indicates that this entry is the mountpoint of a child dataset
+	IsDatasetBoundary bool
 }
 
 func (entry FileBrowserEntry) TableRowId() string {
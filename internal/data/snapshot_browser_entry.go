@@ -1,7 +1,9 @@
 package data
 
 import (
+	"zfs-file-history/internal/autosnap"
 	"zfs-file-history/internal/data/diff_state"
+	"zfs-file-history/internal/retention"
 	"zfs-file-history/internal/zfs"
 )
 
@@ -10,6 +12,10 @@ type SnapshotBrowserEntry struct {
 	DiffState            diff_state.DiffState
 	WorkingCopyDiffState diff_state.DiffState
 	IsLoading            bool
+	// Retention is the outcome of the configured retention policy, nil if no policy is configured
+	Retention *retention.Decision
+	// Origin is the snapshot tool which created the snapshot, nil if it is unknown
+	Origin *autosnap.Origin
 }
 
 func (s SnapshotBrowserEntry) TableRowId() string {
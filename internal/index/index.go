@@ -0,0 +1,309 @@
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	gopath "path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/zfs"
+)
+
+// indexVersion is part of the index path, it has to be increased whenever the layout of the index changes
+const indexVersion = "v2"
+
+// Index records the files of every snapshot once, to answer questions about all versions of a file,
+// or files which only exist within snapshots, without walking the snapshots again.
+// Snapshots are immutable, so the index only ever grows: each snapshot is stored in its own segment,
+// keyed by its guid, which is written once and never modified.
+// Snapshots without a guid (e.g. of other snapshot providers) are not indexed.
+type Index struct {
+	dir string
+
+	backgroundMtx     sync.Mutex
+	backgroundRunning bool
+	backgroundPending []*zfs.Snapshot
+	backgroundOptions Options
+}
+
+// Options control what is recorded while indexing
+type Options struct {
+	// Hash records the SHA-256 of the content of regular files, which requires reading all of them
+	Hash bool
+}
+
+var (
+	currentIndex *Index
+	indexMtx     sync.RWMutex
+)
+
+// Configure sets the directory of the index, an empty dir disables it
+func Configure(dir string) {
+	var index *Index
+	if dir != "" {
+		index = Open(dir)
+	}
+	indexMtx.Lock()
+	defer indexMtx.Unlock()
+	currentIndex = index
+}
+
+// Get returns the configured index, or nil if there is none
+func Get() *Index {
+	indexMtx.RLock()
+	defer indexMtx.RUnlock()
+	return currentIndex
+}
+
+// Open returns the index stored in the given directory, which is created when the first snapshot is indexed
+func Open(dir string) *Index {
+	return &Index{
+		dir: gopath.Join(dir, indexVersion),
+	}
+}
+
+func (index *Index) segmentPath(guid string) string {
+	return gopath.Join(index.dir, guid+".seg")
+}
+
+// IsIndexed reports whether the files of the given snapshot have been recorded
+func (index *Index) IsIndexed(snapshot *zfs.Snapshot) bool {
+	guid := snapshot.Properties.Guid
+	if guid == "" {
+		return false
+	}
+	_, err := os.Stat(index.segmentPath(guid))
+	return err == nil
+}
+
+// Unindexed returns the snapshots whose files have not been recorded yet
+func (index *Index) Unindexed(snapshots []*zfs.Snapshot) []*zfs.Snapshot {
+	var result []*zfs.Snapshot
+	for _, snapshot := range snapshots {
+		if !index.IsIndexed(snapshot) {
+			result = append(result, snapshot)
+		}
+	}
+	return result
+}
+
+// Update indexes all given snapshots which have not been indexed yet.
+// progress is called before each snapshot is indexed, it may be nil.
+// Returns the number of indexed snapshots, snapshots which could not be indexed are reported in the error.
+func (index *Index) Update(ctx context.Context, snapshots []*zfs.Snapshot, options Options, progress func(done int, total int, snapshot *zfs.Snapshot)) (int, error) {
+	var pending []*zfs.Snapshot
+	seen := map[string]bool{}
+	for _, snapshot := range index.Unindexed(snapshots) {
+		guid := snapshot.Properties.Guid
+		// replicas share the guid, and therefore the content, of their source snapshot
+		if guid == "" || seen[guid] {
+			continue
+		}
+		seen[guid] = true
+		pending = append(pending, snapshot)
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	if err := os.MkdirAll(index.dir, 0700); err != nil {
+		return 0, fmt.Errorf("cannot create index directory: %w", err)
+	}
+
+	indexed := 0
+	var errs []error
+	for i, snapshot := range pending {
+		if ctx.Err() != nil {
+			return indexed, ctx.Err()
+		}
+		if progress != nil {
+			progress(i, len(pending), snapshot)
+		}
+		err := index.indexSnapshot(ctx, snapshot, options)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot index %s: %w", snapshot.FullName, err))
+			continue
+		}
+		indexed++
+	}
+	return indexed, errors.Join(errs...)
+}
+
+func (index *Index) indexSnapshot(ctx context.Context, snapshot *zfs.Snapshot, options Options) error {
+	var entries []Entry
+	// incomplete contains the relative paths of directories whose content could not be read
+	incomplete := map[string]bool{}
+	err := filepath.WalkDir(snapshot.Path, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// unreadable directories are recorded without their content, lookups below them are unknown
+			logging.Warning("Cannot index %s: %s", path, err.Error())
+			if d != nil && d.IsDir() && path != snapshot.Path {
+				incomplete[strings.TrimPrefix(path, snapshot.Path)] = true
+				return fs.SkipDir
+			}
+			return err
+		}
+		relativePath := strings.TrimPrefix(path, snapshot.Path)
+		if relativePath == "" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// the file exists, but its state is unknown, lookups of it (or below it) are unknown as well
+			logging.Warning("Cannot index %s: %s", path, err.Error())
+			entries = append(entries, Entry{Path: relativePath, Mode: d.Type(), Incomplete: true})
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		entry := Entry{
+			Path:    relativePath,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Mode:    info.Mode(),
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			entry.Inode = stat.Ino
+		}
+		if options.Hash && info.Mode().IsRegular() {
+			entry.Hash, err = hashFile(path)
+			if err != nil {
+				logging.Warning("Cannot hash %s: %s", path, err.Error())
+			}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if incomplete[entries[i].Path] {
+			entries[i].Incomplete = true
+			delete(incomplete, entries[i].Path)
+		}
+	}
+	// directories which could not even be stat'ed
+	for path := range incomplete {
+		entries = append(entries, Entry{Path: path, Mode: fs.ModeDir, Incomplete: true})
+	}
+	return writeSegment(index.segmentPath(snapshot.Properties.Guid), entries)
+}
+
+func hashFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// UpdateInBackground indexes the given snapshots in a background goroutine.
+// If an update is already running, the snapshots are indexed after it, replacing previously queued ones.
+func (index *Index) UpdateInBackground(snapshots []*zfs.Snapshot, options Options) {
+	index.backgroundMtx.Lock()
+	index.backgroundPending = snapshots
+	index.backgroundOptions = options
+	if index.backgroundRunning {
+		index.backgroundMtx.Unlock()
+		return
+	}
+	index.backgroundRunning = true
+	index.backgroundMtx.Unlock()
+
+	go func() {
+		for {
+			index.backgroundMtx.Lock()
+			snapshots, options := index.backgroundPending, index.backgroundOptions
+			index.backgroundPending = nil
+			if snapshots == nil {
+				index.backgroundRunning = false
+				index.backgroundMtx.Unlock()
+				return
+			}
+			index.backgroundMtx.Unlock()
+
+			indexed, err := index.Update(context.Background(), snapshots, options, nil)
+			if err != nil {
+				logging.Warning("Background indexing failed: %s", err.Error())
+			}
+			if indexed > 0 {
+				logging.Info("Indexed %d snapshots in the background", indexed)
+			}
+		}
+	}()
+}
+
+// Lookup returns the recorded state of the file at the given path of the dataset within the snapshot.
+// indexed is false if the snapshot has not been indexed, the state of the file could not be read while indexing,
+// or the file is located below a directory which could not be read. exists is false if the file is not contained
+// in the snapshot.
+func (index *Index) Lookup(snapshot *zfs.Snapshot, path string) (entry Entry, exists bool, indexed bool) {
+	guid := snapshot.Properties.Guid
+	if guid == "" {
+		return Entry{}, false, false
+	}
+	relativePath, ok := strings.CutPrefix(snapshot.GetSnapshotPath(path), snapshot.Path)
+	if !ok {
+		return Entry{}, false, false
+	}
+
+	seg, err := openSegment(index.segmentPath(guid))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Warning("Cannot read index of %s: %s", snapshot.FullName, err.Error())
+		}
+		return Entry{}, false, false
+	}
+	defer func() { _ = seg.Close() }()
+
+	entry, exists, err = seg.find(relativePath)
+	if err == nil && exists && entry.Incomplete && !entry.Mode.IsDir() {
+		// the file exists, but its state could not be recorded
+		return Entry{}, false, false
+	}
+	if err == nil && !exists {
+		var unknown bool
+		unknown, err = seg.isBelowIncomplete(relativePath)
+		if err == nil && unknown {
+			return Entry{}, false, false
+		}
+	}
+	if err != nil {
+		logging.Warning("Cannot read index of %s: %s", snapshot.FullName, err.Error())
+		return Entry{}, false, false
+	}
+	return entry, exists, true
+}
+
+// Stat returns the recorded state of the file at the given path of the dataset within the snapshot,
+// like zfs.Snapshot.StatFile. indexed is false if the state of the file is not recorded, see Lookup.
+func (index *Index) Stat(snapshot *zfs.Snapshot, path string) (meta zfs.FileMeta, indexed bool) {
+	entry, exists, indexed := index.Lookup(snapshot, path)
+	if !exists {
+		return zfs.FileMeta{}, indexed
+	}
+	return zfs.FileMeta{
+		Exists:  true,
+		IsDir:   entry.Mode.IsDir(),
+		Size:    entry.Size,
+		Mode:    entry.Mode,
+		ModTime: entry.ModTime,
+	}, true
+}
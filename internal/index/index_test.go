@@ -0,0 +1,179 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createSnapshot creates a fake snapshot of the dataset containing the given files
+func createSnapshot(t *testing.T, dataset *zfs.Dataset, name string, guid string, created time.Time, files map[string]string) *zfs.Snapshot {
+	snapshotPath := filepath.Join(dataset.GetSnapshotsDir(), name)
+	assert.NoError(t, os.MkdirAll(snapshotPath, 0755))
+	for file, content := range files {
+		path := filepath.Join(snapshotPath, file)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return &zfs.Snapshot{
+		Name:          name,
+		FullName:      "pool/ds@" + name,
+		Path:          snapshotPath,
+		ParentDataset: dataset,
+		Properties:    zfs.SnapshotProperties{Guid: guid, CreationDate: created},
+	}
+}
+
+func TestIndex(t *testing.T) {
+	root := t.TempDir()
+	dataset := &zfs.Dataset{Path: root, HiddenZfsPath: filepath.Join(root, ".zfs")}
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+
+	first := createSnapshot(t, dataset, "first", "1", day(1), map[string]string{
+		"docs/report.txt": "v1",
+		"docs/old.txt":    "old",
+	})
+	second := createSnapshot(t, dataset, "second", "2", day(5), map[string]string{
+		"docs/report.txt": "version 2",
+		"docs/draft.txt":  "draft",
+	})
+	third := createSnapshot(t, dataset, "third", "3", day(9), map[string]string{
+		"docs/report.txt": "version 2",
+		"big.bin":         "0123456789",
+	})
+	withoutGuid := createSnapshot(t, dataset, "other", "", day(10), map[string]string{})
+	// the working copy only contains the report
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "docs", "report.txt"), []byte("current"), 0644))
+	snapshots := []*zfs.Snapshot{third, first, second, withoutGuid}
+
+	idx := Open(t.TempDir())
+	assert.Len(t, idx.Unindexed(snapshots), 4)
+
+	indexed, err := idx.Update(context.Background(), snapshots, Options{Hash: true}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, indexed)
+	assert.Equal(t, []*zfs.Snapshot{withoutGuid}, idx.Unindexed(snapshots))
+
+	// indexed snapshots are not indexed again
+	indexed, err = idx.Update(context.Background(), snapshots, Options{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, indexed)
+
+	report := filepath.Join(root, "docs", "report.txt")
+	meta, isIndexed := idx.Stat(second, report)
+	assert.True(t, isIndexed)
+	assert.True(t, meta.Exists)
+	assert.Equal(t, int64(9), meta.Size)
+	meta, isIndexed = idx.Stat(first, filepath.Join(root, "docs", "draft.txt"))
+	assert.True(t, isIndexed)
+	assert.False(t, meta.Exists)
+	_, isIndexed = idx.Stat(withoutGuid, report)
+	assert.False(t, isIndexed)
+
+	entry, exists, _ := idx.Lookup(first, report)
+	assert.True(t, exists)
+	assert.Len(t, entry.Hash, 32)
+
+	var versions []string
+	for _, version := range idx.Versions(snapshots, report) {
+		versions = append(versions, version.Snapshot.Name)
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, versions)
+
+	deleted, err := idx.DeletedSince(snapshots, day(3))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "big.bin"),
+		filepath.Join(root, "docs", "draft.txt"),
+		filepath.Join(root, "docs", "old.txt"),
+	}, missingPaths(deleted))
+
+	deleted, err = idx.DeletedSince(snapshots, day(6))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "big.bin"),
+		filepath.Join(root, "docs", "draft.txt"),
+	}, missingPaths(deleted))
+
+	large, err := idx.OnlyInSnapshots(snapshots, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "big.bin")}, missingPaths(large))
+	assert.Equal(t, "third", large[0].Latest.Snapshot.Name)
+}
+
+func missingPaths(files []MissingFile) []string {
+	var result []string
+	for _, file := range files {
+		result = append(result, file.Path)
+	}
+	return result
+}
+
+func TestRealPathOf_BindMount(t *testing.T) {
+	dataset := &zfs.Dataset{Path: "/srv/www", HiddenZfsPath: "/tank/.zfs", BindRoot: "/www"}
+	snapshot := &zfs.Snapshot{Name: "daily", Path: "/tank/.zfs/snapshot/daily", ParentDataset: dataset}
+
+	path, ok := realPathOf(snapshot, "/www/index.html")
+	assert.True(t, ok)
+	assert.Equal(t, "/srv/www/index.html", path)
+
+	_, ok = realPathOf(snapshot, "/other/file")
+	assert.False(t, ok)
+	_, ok = realPathOf(snapshot, "/wwwdata/file")
+	assert.False(t, ok)
+}
+
+func TestIndex_Lookup_BelowIncompleteDirectory(t *testing.T) {
+	root := t.TempDir()
+	dataset := &zfs.Dataset{Path: root, HiddenZfsPath: filepath.Join(root, ".zfs")}
+	snapshot := createSnapshot(t, dataset, "daily", "1", time.Now(), map[string]string{})
+	idx := Open(t.TempDir())
+	assert.NoError(t, os.MkdirAll(idx.dir, 0700))
+	// the content of /private could not be read while indexing
+	assert.NoError(t, writeSegment(idx.segmentPath("1"), []Entry{
+		{Path: "/private", Mode: os.ModeDir | 0700, Incomplete: true},
+		{Path: "/public", Mode: os.ModeDir | 0755},
+	}))
+
+	_, exists, indexed := idx.Lookup(snapshot, filepath.Join(root, "private", "file"))
+	assert.False(t, exists)
+	assert.False(t, indexed)
+
+	_, exists, indexed = idx.Lookup(snapshot, filepath.Join(root, "private"))
+	assert.True(t, exists)
+	assert.True(t, indexed)
+
+	_, exists, indexed = idx.Lookup(snapshot, filepath.Join(root, "public", "file"))
+	assert.False(t, exists)
+	assert.True(t, indexed)
+
+	assert.Empty(t, idx.Versions([]*zfs.Snapshot{snapshot}, filepath.Join(root, "private", "file")))
+}
+
+func TestIndex_Lookup_IncompleteFile(t *testing.T) {
+	root := t.TempDir()
+	dataset := &zfs.Dataset{Path: root, HiddenZfsPath: filepath.Join(root, ".zfs")}
+	snapshot := createSnapshot(t, dataset, "daily", "1", time.Now(), map[string]string{})
+	idx := Open(t.TempDir())
+	assert.NoError(t, os.MkdirAll(idx.dir, 0700))
+	// the state of /vanished could not be read while indexing, but it exists
+	assert.NoError(t, writeSegment(idx.segmentPath("1"), []Entry{
+		{Path: "/vanished", Incomplete: true},
+		{Path: "/file", Size: 3, Mode: 0644},
+	}))
+
+	_, exists, indexed := idx.Lookup(snapshot, filepath.Join(root, "vanished"))
+	assert.False(t, exists)
+	assert.False(t, indexed)
+
+	entry, exists, indexed := idx.Lookup(snapshot, filepath.Join(root, "file"))
+	assert.True(t, exists)
+	assert.True(t, indexed)
+	assert.Equal(t, int64(3), entry.Size)
+}
@@ -0,0 +1,126 @@
+package index
+
+import (
+	"os"
+	gopath "path"
+	"slices"
+	"strings"
+	"time"
+	"zfs-file-history/internal/zfs"
+)
+
+// Version is the state of a file within a snapshot
+type Version struct {
+	Snapshot *zfs.Snapshot
+	Entry    Entry
+}
+
+// MissingFile is a file which exists within snapshots, but not in the working copy
+type MissingFile struct {
+	// Path is the path of the file in the working copy
+	Path string
+	// Latest is the most recent snapshot containing the file
+	Latest Version
+}
+
+// Versions returns the state of the file at the given path of the dataset within every indexed snapshot
+// containing it, oldest first. Snapshots which have not been indexed are skipped, see Unindexed,
+// as well as snapshots in which the file is located below a directory which could not be read while indexing.
+func (index *Index) Versions(snapshots []*zfs.Snapshot, path string) []Version {
+	var result []Version
+	for _, snapshot := range sortByCreation(snapshots) {
+		entry, exists, _ := index.Lookup(snapshot, path)
+		if exists {
+			result = append(result, Version{Snapshot: snapshot, Entry: entry})
+		}
+	}
+	return result
+}
+
+// DeletedSince returns the files which existed at the given date, or were created later on,
+// and do not exist in the working copy anymore. Directories are not reported, only the files within them.
+// Snapshots which have not been indexed are skipped, see Unindexed.
+func (index *Index) DeletedSince(snapshots []*zfs.Snapshot, since time.Time) ([]MissingFile, error) {
+	sorted := sortByCreation(snapshots)
+	// the newest snapshot before the date reflects the state at the date
+	first := 0
+	for i, snapshot := range sorted {
+		if snapshot.Properties.CreationDate.Before(since) && index.IsIndexed(snapshot) {
+			first = i
+		}
+	}
+	return index.findMissingFiles(sorted[first:], func(entry Entry) bool {
+		return !entry.Mode.IsDir()
+	})
+}
+
+// OnlyInSnapshots returns the files of at least minSize bytes which exist within snapshots,
+// but not in the working copy. Snapshots which have not been indexed are skipped, see Unindexed.
+func (index *Index) OnlyInSnapshots(snapshots []*zfs.Snapshot, minSize int64) ([]MissingFile, error) {
+	return index.findMissingFiles(sortByCreation(snapshots), func(entry Entry) bool {
+		return !entry.Mode.IsDir() && entry.Size >= minSize
+	})
+}
+
+// findMissingFiles returns the files matching the filter which exist within any of the given snapshots
+// (sorted oldest first), but not in the working copy
+func (index *Index) findMissingFiles(snapshots []*zfs.Snapshot, filter func(entry Entry) bool) ([]MissingFile, error) {
+	latest := map[string]Version{}
+	for _, snapshot := range snapshots {
+		if snapshot.Properties.Guid == "" {
+			continue
+		}
+		seg, err := openSegment(index.segmentPath(snapshot.Properties.Guid))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		err = seg.each(func(entry Entry) bool {
+			if !filter(entry) {
+				return true
+			}
+			if realPath, ok := realPathOf(snapshot, entry.Path); ok {
+				latest[realPath] = Version{Snapshot: snapshot, Entry: entry}
+			}
+			return true
+		})
+		_ = seg.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []MissingFile
+	for path, version := range latest {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			result = append(result, MissingFile{Path: path, Latest: version})
+		}
+	}
+	slices.SortFunc(result, func(a, b MissingFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return result, nil
+}
+
+// realPathOf returns the path of a file of the snapshot in the working copy.
+// Files outside the bind mounted part of the dataset have no such path.
+func realPathOf(snapshot *zfs.Snapshot, relativePath string) (string, bool) {
+	bindRoot := snapshot.ParentDataset.BindRoot
+	if bindRoot != "" && bindRoot != "/" {
+		var ok bool
+		relativePath, ok = strings.CutPrefix(relativePath, bindRoot)
+		if !ok || (relativePath != "" && !strings.HasPrefix(relativePath, "/")) {
+			return "", false
+		}
+	}
+	return gopath.Join(snapshot.ParentDataset.Path, relativePath), true
+}
+
+func sortByCreation(snapshots []*zfs.Snapshot) []*zfs.Snapshot {
+	sorted := slices.Clone(snapshots)
+	slices.SortStableFunc(sorted, func(a, b *zfs.Snapshot) int {
+		return a.Properties.CreationDate.Compare(b.Properties.CreationDate)
+	})
+	return sorted
+}
@@ -0,0 +1,242 @@
+package index
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	gopath "path"
+	"sort"
+	"time"
+)
+
+// segmentMagic identifies segment files, the trailing digit is the version of the format
+const segmentMagic = "ZFHIDX2\n"
+
+// A segment file contains the entries of a single snapshot, sorted by path:
+//
+//	magic | count (uint64) | count record offsets (uint64, relative to the first record) | records
+//
+// Each record consists of uvarint encoded fields: path length, path, size, mtime (unix nanoseconds, zigzag),
+// mode, inode, hash length, hash, flags. The offset table allows looking up a path with a binary search
+// without reading the whole segment.
+const segmentHeaderSize = int64(len(segmentMagic) + 8)
+
+var errInvalidSegment = errors.New("invalid index segment")
+
+// entryFlagIncomplete marks a directory whose content, or a file whose state, could not be read
+const entryFlagIncomplete = 1 << 0
+
+// Entry is the recorded state of a file within a snapshot
+type Entry struct {
+	// Path is relative to the root of the snapshot, starting with "/"
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Mode    os.FileMode
+	Inode   uint64
+	// Hash is the SHA-256 of the content of regular files, if hashing was enabled while indexing
+	Hash []byte
+	// Incomplete is set for directories whose content could not be read while indexing,
+	// nothing below them is recorded. For other files, only their existence is recorded.
+	Incomplete bool
+}
+
+// writeSegment writes the given entries to a segment file, replacing it atomically.
+// The records are streamed to the file, only their offsets are computed upfront.
+func writeSegment(path string, entries []Entry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	file, err := os.CreateTemp(gopath.Dir(path), "."+gopath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	writer := bufio.NewWriter(file)
+	_, _ = writer.WriteString(segmentMagic)
+	_ = binary.Write(writer, binary.LittleEndian, uint64(len(entries)))
+	var record []byte
+	offset := uint64(0)
+	for _, entry := range entries {
+		_ = binary.Write(writer, binary.LittleEndian, offset)
+		record = appendRecord(record[:0], entry)
+		offset += uint64(len(record))
+	}
+	for _, entry := range entries {
+		record = appendRecord(record[:0], entry)
+		_, _ = writer.Write(record)
+	}
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// appendRecord appends the encoded record of the given entry to dst
+func appendRecord(dst []byte, entry Entry) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(entry.Path)))
+	dst = append(dst, entry.Path...)
+	dst = binary.AppendUvarint(dst, uint64(entry.Size))
+	dst = binary.AppendVarint(dst, entry.ModTime.UnixNano())
+	dst = binary.AppendUvarint(dst, uint64(entry.Mode))
+	dst = binary.AppendUvarint(dst, entry.Inode)
+	dst = binary.AppendUvarint(dst, uint64(len(entry.Hash)))
+	dst = append(dst, entry.Hash...)
+	var flags uint64
+	if entry.Incomplete {
+		flags |= entryFlagIncomplete
+	}
+	return binary.AppendUvarint(dst, flags)
+}
+
+// segment provides read access to a segment file
+type segment struct {
+	file         *os.File
+	count        int
+	recordsStart int64
+}
+
+func openSegment(path string) (*segment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, segmentHeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil || string(header[:len(segmentMagic)]) != segmentMagic {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: %s", errInvalidSegment, path)
+	}
+	count := binary.LittleEndian.Uint64(header[len(segmentMagic):])
+	return &segment{
+		file:         file,
+		count:        int(count),
+		recordsStart: segmentHeaderSize + int64(count)*8,
+	}, nil
+}
+
+func (seg *segment) Close() error {
+	return seg.file.Close()
+}
+
+// entryAt reads the i-th entry
+func (seg *segment) entryAt(i int) (Entry, error) {
+	offset := make([]byte, 8)
+	if _, err := seg.file.ReadAt(offset, segmentHeaderSize+int64(i)*8); err != nil {
+		return Entry{}, err
+	}
+	start := seg.recordsStart + int64(binary.LittleEndian.Uint64(offset))
+	reader := bufio.NewReader(io.NewSectionReader(seg.file, start, 1<<62))
+	return readEntry(reader)
+}
+
+// find returns the entry with the given path
+func (seg *segment) find(path string) (Entry, bool, error) {
+	var searchErr error
+	i := sort.Search(seg.count, func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		entry, err := seg.entryAt(i)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return entry.Path >= path
+	})
+	if searchErr != nil {
+		return Entry{}, false, searchErr
+	}
+	if i >= seg.count {
+		return Entry{}, false, nil
+	}
+	entry, err := seg.entryAt(i)
+	if err != nil || entry.Path != path {
+		return Entry{}, false, err
+	}
+	return entry, true, nil
+}
+
+// isBelowIncomplete reports whether the given path is located below a directory whose content is not recorded
+func (seg *segment) isBelowIncomplete(path string) (bool, error) {
+	for dir := gopath.Dir(path); dir != "/" && dir != "."; dir = gopath.Dir(dir) {
+		entry, found, err := seg.find(dir)
+		if err != nil {
+			return false, err
+		}
+		if found && entry.Incomplete {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// each calls action for every entry in order, until it returns false
+func (seg *segment) each(action func(entry Entry) bool) error {
+	reader := bufio.NewReader(io.NewSectionReader(seg.file, seg.recordsStart, 1<<62))
+	for i := 0; i < seg.count; i++ {
+		entry, err := readEntry(reader)
+		if err != nil {
+			return err
+		}
+		if !action(entry) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func readEntry(reader *bufio.Reader) (Entry, error) {
+	var entry Entry
+	pathLength, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return entry, err
+	}
+	path := make([]byte, pathLength)
+	if _, err := io.ReadFull(reader, path); err != nil {
+		return entry, err
+	}
+	entry.Path = string(path)
+	size, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return entry, err
+	}
+	entry.Size = int64(size)
+	modTime, err := binary.ReadVarint(reader)
+	if err != nil {
+		return entry, err
+	}
+	entry.ModTime = time.Unix(0, modTime)
+	mode, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return entry, err
+	}
+	entry.Mode = os.FileMode(mode)
+	if entry.Inode, err = binary.ReadUvarint(reader); err != nil {
+		return entry, err
+	}
+	hashLength, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return entry, err
+	}
+	if hashLength > 0 {
+		entry.Hash = make([]byte, hashLength)
+		if _, err := io.ReadFull(reader, entry.Hash); err != nil {
+			return entry, err
+		}
+	}
+	flags, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return entry, err
+	}
+	entry.Incomplete = flags&entryFlagIncomplete != 0
+	return entry, nil
+}
@@ -0,0 +1,96 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegment_WriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.seg")
+	modTime := time.Date(2026, 10, 1, 12, 0, 0, 123, time.UTC)
+	entries := []Entry{
+		{Path: "/b/file", Size: 42, ModTime: modTime, Mode: 0644, Inode: 7, Hash: []byte{1, 2, 3}},
+		{Path: "/a", Size: 4096, ModTime: modTime, Mode: os.ModeDir | 0755, Inode: 3},
+		{Path: "/b", Size: 4096, ModTime: modTime, Mode: os.ModeDir | 0700, Inode: 5},
+	}
+	assert.NoError(t, writeSegment(path, entries))
+
+	seg, err := openSegment(path)
+	assert.NoError(t, err)
+	defer func() { _ = seg.Close() }()
+
+	var paths []string
+	assert.NoError(t, seg.each(func(entry Entry) bool {
+		paths = append(paths, entry.Path)
+		return true
+	}))
+	assert.Equal(t, []string{"/a", "/b", "/b/file"}, paths)
+
+	entry, found, err := seg.find("/b/file")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(42), entry.Size)
+	assert.True(t, modTime.Equal(entry.ModTime))
+	assert.Equal(t, os.FileMode(0644), entry.Mode)
+	assert.Equal(t, uint64(7), entry.Inode)
+	assert.Equal(t, []byte{1, 2, 3}, entry.Hash)
+
+	for _, missing := range []string{"/", "/a/x", "/c"} {
+		_, found, err = seg.find(missing)
+		assert.NoError(t, err)
+		assert.False(t, found, missing)
+	}
+}
+
+func TestSegment_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.seg")
+	assert.NoError(t, writeSegment(path, nil))
+
+	seg, err := openSegment(path)
+	assert.NoError(t, err)
+	defer func() { _ = seg.Close() }()
+	_, found, err := seg.find("/a")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestOpenSegment_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.seg")
+	assert.NoError(t, os.WriteFile(path, []byte("not a segment"), 0644))
+
+	_, err := openSegment(path)
+	assert.ErrorIs(t, err, errInvalidSegment)
+}
+
+func TestSegment_IsBelowIncomplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.seg")
+	assert.NoError(t, writeSegment(path, []Entry{
+		{Path: "/private", Mode: os.ModeDir | 0700, Incomplete: true},
+		{Path: "/public", Mode: os.ModeDir | 0755},
+		{Path: "/public/file", Mode: 0644},
+	}))
+
+	seg, err := openSegment(path)
+	assert.NoError(t, err)
+	defer func() { _ = seg.Close() }()
+
+	entry, found, err := seg.find("/private")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, entry.Incomplete)
+
+	for path, expected := range map[string]bool{
+		"/private/file":       true,
+		"/private/dir/nested": true,
+		"/public/missing":     false,
+		"/missing":            false,
+	} {
+		below, err := seg.isBelowIncomplete(path)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, below, path)
+	}
+}
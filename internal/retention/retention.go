@@ -0,0 +1,173 @@
+package retention
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"zfs-file-history/internal/configuration"
+	"zfs-file-history/internal/zfs"
+)
+
+// period groups snapshots by a time span, of which only the newest snapshot is kept
+type period struct {
+	name  string
+	count int
+	key   func(t time.Time) string
+}
+
+// Policy decides which snapshots of a dataset are kept and which ones expire
+type Policy struct {
+	periods []period
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// ReasonUnknownCreationDate is the Reason of snapshots which are kept because their creation date is unknown
+const ReasonUnknownCreationDate = "unknown creation date"
+
+// Decision is the outcome of evaluating a Policy for a single snapshot
+type Decision struct {
+	Snapshot *zfs.Snapshot
+	Keep     bool
+	// Reason lists the periods a snapshot is kept for, or explains why it is not subject to the policy or expired
+	Reason string
+}
+
+// NewPolicy creates a Policy from the given configuration
+func NewPolicy(config configuration.RetentionConfig) (*Policy, error) {
+	policy := &Policy{
+		periods: []period{
+			{name: "hourly", count: config.Hourly, key: func(t time.Time) string { return t.Format("2006-01-02 15") }},
+			{name: "daily", count: config.Daily, key: func(t time.Time) string { return t.Format("2006-01-02") }},
+			{name: "weekly", count: config.Weekly, key: func(t time.Time) string {
+				year, week := t.ISOWeek()
+				return fmt.Sprintf("%d-W%02d", year, week)
+			}},
+			{name: "monthly", count: config.Monthly, key: func(t time.Time) string { return t.Format("2006-01") }},
+		},
+	}
+
+	var err error
+	policy.include, err = compilePatterns(config.Include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+	policy.exclude, err = compilePatterns(config.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+	return policy, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, pattern := range patterns {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, expression)
+	}
+	return result, nil
+}
+
+// Evaluate decides for each of the given snapshots whether it is kept.
+// The result is ordered like the given snapshots.
+func (policy *Policy) Evaluate(snapshots []*zfs.Snapshot) []*Decision {
+	result := make([]*Decision, 0, len(snapshots))
+	decisions := map[*zfs.Snapshot]*Decision{}
+
+	var candidates []*zfs.Snapshot
+	for _, snapshot := range snapshots {
+		decision := &Decision{Snapshot: snapshot}
+		result = append(result, decision)
+		decisions[snapshot] = decision
+
+		if reason, managed := policy.isManaged(snapshot.Name); !managed {
+			decision.Keep = true
+			decision.Reason = reason
+			continue
+		}
+		if HasUnknownCreationDate(snapshot) {
+			// without a creation date, the snapshot cannot be assigned to a period and would share one with all others
+			decision.Keep = true
+			decision.Reason = ReasonUnknownCreationDate
+			continue
+		}
+		candidates = append(candidates, snapshot)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Properties.CreationDate.After(candidates[j].Properties.CreationDate)
+	})
+
+	keptFor := map[*zfs.Snapshot][]string{}
+	for _, period := range policy.periods {
+		if period.count <= 0 {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, snapshot := range candidates {
+			key := period.key(snapshot.Properties.CreationDate)
+			if seen[key] {
+				continue
+			}
+			if len(seen) >= period.count {
+				break
+			}
+			seen[key] = true
+			keptFor[snapshot] = append(keptFor[snapshot], period.name)
+		}
+	}
+
+	for _, snapshot := range candidates {
+		decision := decisions[snapshot]
+		if periods, ok := keptFor[snapshot]; ok {
+			decision.Keep = true
+			decision.Reason = strings.Join(periods, ", ")
+		} else {
+			decision.Reason = "expired"
+		}
+	}
+
+	return result
+}
+
+// HasUnknownCreationDate returns whether the creation date of the given snapshot could not be determined,
+// such snapshots are never expired by a Policy
+func HasUnknownCreationDate(snapshot *zfs.Snapshot) bool {
+	return snapshot.Properties.CreationDate.IsZero() ||
+		snapshot.Properties.CreationDateSource == zfs.CreationDateSourceUnknown
+}
+
+// isManaged returns whether a snapshot with the given name is subject to this policy,
+// and the reason if it is not
+func (policy *Policy) isManaged(name string) (string, bool) {
+	for _, expression := range policy.exclude {
+		if expression.MatchString(name) {
+			return fmt.Sprintf("excluded by '%s'", expression.String()), false
+		}
+	}
+	if len(policy.include) == 0 {
+		return "", true
+	}
+	for _, expression := range policy.include {
+		if expression.MatchString(name) {
+			return "", true
+		}
+	}
+	return "not included", false
+}
+
+// Expired returns the snapshots of the given decisions which are not kept
+func Expired(decisions []*Decision) []*zfs.Snapshot {
+	var result []*zfs.Snapshot
+	for _, decision := range decisions {
+		if !decision.Keep {
+			result = append(result, decision.Snapshot)
+		}
+	}
+	return result
+}
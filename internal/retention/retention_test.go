@@ -0,0 +1,140 @@
+package retention
+
+import (
+	"testing"
+	"time"
+	"zfs-file-history/internal/configuration"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSnapshot(name string, creation time.Time) *zfs.Snapshot {
+	return &zfs.Snapshot{Name: name, Properties: zfs.SnapshotProperties{
+		CreationDate:       creation,
+		CreationDateSource: zfs.CreationDateSourceProperty,
+	}}
+}
+
+func reasonsByName(decisions []*Decision) map[string]string {
+	result := map[string]string{}
+	for _, decision := range decisions {
+		if decision.Keep {
+			result[decision.Snapshot.Name] = "keep: " + decision.Reason
+		} else {
+			result[decision.Snapshot.Name] = "prune: " + decision.Reason
+		}
+	}
+	return result
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	base := time.Date(2024, 3, 15, 12, 45, 0, 0, time.Local)
+	snapshots := []*zfs.Snapshot{
+		newSnapshot("h0", base),
+		newSnapshot("h0-early", base.Add(-30*time.Minute)),
+		newSnapshot("h1", base.Add(-1*time.Hour)),
+		newSnapshot("h2", base.Add(-2*time.Hour)),
+		newSnapshot("yesterday", base.Add(-24*time.Hour)),
+		newSnapshot("last-month", base.AddDate(0, -1, 0)),
+	}
+
+	policy, err := NewPolicy(configuration.RetentionConfig{Hourly: 2, Daily: 2, Monthly: 2})
+	assert.NoError(t, err)
+
+	decisions := policy.Evaluate(snapshots)
+	assert.Len(t, decisions, len(snapshots))
+	assert.Equal(t, "h0", decisions[0].Snapshot.Name)
+	assert.Equal(t, map[string]string{
+		"h0":         "keep: hourly, daily, monthly",
+		"h0-early":   "prune: expired",
+		"h1":         "keep: hourly",
+		"h2":         "prune: expired",
+		"yesterday":  "keep: daily",
+		"last-month": "keep: monthly",
+	}, reasonsByName(decisions))
+
+	expired := Expired(decisions)
+	assert.Len(t, expired, 2)
+	assert.Equal(t, "h0-early", expired[0].Name)
+	assert.Equal(t, "h2", expired[1].Name)
+}
+
+func TestPolicyEvaluate_Filters(t *testing.T) {
+	base := time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)
+	snapshots := []*zfs.Snapshot{
+		newSnapshot("zfh-new", base),
+		newSnapshot("zfh-old", base.Add(-48*time.Hour)),
+		newSnapshot("zfh-old-keep", base.Add(-72*time.Hour)),
+		newSnapshot("manual", base.Add(-96*time.Hour)),
+	}
+
+	policy, err := NewPolicy(configuration.RetentionConfig{
+		Daily:   1,
+		Include: []string{"^zfh-"},
+		Exclude: []string{"-keep$"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"zfh-new":      "keep: daily",
+		"zfh-old":      "prune: expired",
+		"zfh-old-keep": "keep: excluded by '-keep$'",
+		"manual":       "keep: not included",
+	}, reasonsByName(policy.Evaluate(snapshots)))
+}
+
+func TestPolicyEvaluate_Weekly(t *testing.T) {
+	// 2024-03-11 is a monday
+	monday := time.Date(2024, 3, 11, 8, 0, 0, 0, time.Local)
+	snapshots := []*zfs.Snapshot{
+		newSnapshot("sunday", monday.Add(-24*time.Hour)),
+		newSnapshot("monday", monday),
+		newSnapshot("friday", monday.AddDate(0, 0, 4)),
+	}
+
+	policy, err := NewPolicy(configuration.RetentionConfig{Weekly: 2})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"sunday": "keep: weekly",
+		"monday": "prune: expired",
+		"friday": "keep: weekly",
+	}, reasonsByName(policy.Evaluate(snapshots)))
+}
+
+func TestNewPolicy_InvalidPattern(t *testing.T) {
+	_, err := NewPolicy(configuration.RetentionConfig{Daily: 1, Include: []string{"("}})
+	assert.Error(t, err)
+	_, err = NewPolicy(configuration.RetentionConfig{Daily: 1, Exclude: []string{"["}})
+	assert.Error(t, err)
+}
+
+func TestPolicyEvaluate_UnknownCreationDate(t *testing.T) {
+	base := time.Date(2024, 3, 15, 12, 45, 0, 0, time.Local)
+	unknownSource := newSnapshot("unknown-source", base.Add(-2*time.Hour))
+	unknownSource.Properties.CreationDateSource = zfs.CreationDateSourceUnknown
+	snapshots := []*zfs.Snapshot{
+		newSnapshot("h0", base),
+		newSnapshot("h1", base.Add(-1*time.Hour)),
+		newSnapshot("zero-1", time.Time{}),
+		newSnapshot("zero-2", time.Time{}),
+		unknownSource,
+	}
+
+	policy, err := NewPolicy(configuration.RetentionConfig{Hourly: 1})
+	assert.NoError(t, err)
+
+	decisions := policy.Evaluate(snapshots)
+	assert.Equal(t, map[string]string{
+		"h0":             "keep: hourly",
+		"h1":             "prune: expired",
+		"zero-1":         "keep: unknown creation date",
+		"zero-2":         "keep: unknown creation date",
+		"unknown-source": "keep: unknown creation date",
+	}, reasonsByName(decisions))
+
+	expired := Expired(decisions)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "h1", expired[0].Name)
+}
@@ -17,7 +17,12 @@ func AddActor(g *run.Group, ctx context.Context, path string) {
 		logging.Info("Launching UI...")
 
 		application := CreateUi(path, true)
-		return application.Run()
+		err := application.Run()
+		// entries which have been skipped by throttled flushes while the UI was running
+		if flushErr := zfs.FlushMetadataCache(); flushErr != nil {
+			logging.Warning("Could not write metadata cache: %s", flushErr.Error())
+		}
+		return err
 	}, func(err error) {
 		if err != nil {
 			logging.Warning("Error stopping UI: %s", err.Error())
@@ -5,26 +5,91 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"zfs-file-history/internal/configuration"
+	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/ui/shortcut_helper"
 	"zfs-file-history/internal/ui/theme"
 	"zfs-file-history/internal/ui/txwidgets"
 	uiutil "zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/util"
 	"zfs-file-history/internal/zfs"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/navidys/tvxwidgets"
 	"github.com/rivo/tview"
 )
 
+var datasetInfoShortcutLoadKey = shortcut_helper.ShortcutEntry{KeyCombo: []string{"l"}, Name: "Load key"}
+
 type DatasetInfoComponent struct {
+	Events util.Emitter[Event]
+
 	path        string
 	application *tview.Application
 	dataset     *zfs.Dataset
+	poolSpace   *zfs.PoolSpace
+	layout      *tview.Flex
 	textView    *tview.TextView
+	usageBars   *tview.Flex
+	poolGauge   *tvxwidgets.UtilModeGauge
+	snapGauge   *tvxwidgets.UtilModeGauge
 	container   *uiutil.LoadingContainer
-	loader      *uiutil.DataLoader[*zfs.Dataset]
+	loader      *uiutil.DataLoader[*datasetInfoLoadResult]
+
+	// lockedDatasets are the encrypted datasets at or below path whose key is not loaded
+	lockedDatasets []*zfs.LockedDataset
+}
+
+type datasetInfoLoadResult struct {
+	dataset *zfs.Dataset
+	// poolSpace is nil if the capacity of the pool could not be determined
+	poolSpace      *zfs.PoolSpace
+	lockedDatasets []*zfs.LockedDataset
+}
+
+func loadDatasetInfo(path string) (*datasetInfoLoadResult, error) {
+	dataset, err := zfs.FindHostDataset(path)
+	if err != nil {
+		return nil, err
+	}
+	if !dataset.IsZfs() {
+		return &datasetInfoLoadResult{dataset: dataset}, nil
+	}
+	poolSpace, err := dataset.GetPoolSpace()
+	if err != nil {
+		logging.Warning("Could not determine pool space of %s: %s", dataset.GetName(), err.Error())
+	}
+	return &datasetInfoLoadResult{
+		dataset:        dataset,
+		poolSpace:      poolSpace,
+		lockedDatasets: loadLockedDatasets(dataset, path),
+	}, nil
+}
+
+// loadLockedDatasets returns the encrypted datasets at or below path whose key is not loaded.
+// A locked dataset is never mounted, so the host dataset of a path is usually its mounted parent
+// and the locked dataset has to be looked up by its mountpoint instead.
+func loadLockedDatasets(host *zfs.Dataset, path string) []*zfs.LockedDataset {
+	var result []*zfs.LockedDataset
+	if host.IsKeyUnavailable() {
+		result = append(result, host.AsLockedDataset())
+	}
+	locked, err := zfs.FindLockedDatasets(path)
+	if err != nil {
+		logging.Warning("Could not determine locked datasets below %s: %s", path, err.Error())
+		return result
+	}
+	for _, dataset := range locked {
+		if dataset.Name != host.GetName() {
+			result = append(result, dataset)
+		}
+	}
+	return result
 }
 
 func NewDatasetInfo(application *tview.Application) *DatasetInfoComponent {
 	datasetInfo := &DatasetInfoComponent{
+		Events:      *util.NewEmitter[Event](),
 		application: application,
 	}
 
@@ -32,25 +97,54 @@ func NewDatasetInfo(application *tview.Application) *DatasetInfoComponent {
 		SetDynamicColors(true).
 		SetWrap(false).
 		SetScrollable(true)
-	datasetInfo.textView.SetBorder(true)
-	uiutil.SetupWindow(datasetInfo.textView, "Dataset")
 
-	datasetInfo.container = uiutil.NewLoadingContainer(application, datasetInfo.textView, "Dataset", "Loading dataset info...")
+	datasetInfo.poolGauge = tvxwidgets.NewUtilModeGauge()
+	datasetInfo.snapGauge = tvxwidgets.NewUtilModeGauge()
+	// a high share of snapshot space is not critical, it only hints at snapshots worth pruning
+	datasetInfo.snapGauge.SetWarnPercentage(50)
+	datasetInfo.snapGauge.SetCritPercentage(100)
+	datasetInfo.usageBars = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(datasetInfo.poolGauge, 1, 0, false).
+		AddItem(datasetInfo.snapGauge, 1, 0, false)
+
+	datasetInfo.layout = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(datasetInfo.textView, 0, 1, true)
+	datasetInfo.layout.SetBorder(true)
+	uiutil.SetupWindow(datasetInfo.layout, "Dataset")
+	datasetInfo.textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'l' && datasetInfo.IsKeyUnavailable() {
+			// the datasets are ordered by mountpoint, so parents are unlocked before their children
+			datasetInfo.Events.Emit(RequestLoadKeyEvent{Dataset: datasetInfo.lockedDatasets[0]})
+			return nil
+		}
+		return event
+	})
+
+	datasetInfo.container = uiutil.NewLoadingContainer(application, datasetInfo.layout, "Dataset", "Loading dataset info...")
 
-	datasetInfo.loader = uiutil.NewDataLoader[*zfs.Dataset](application).
+	datasetInfo.loader = uiutil.NewDataLoader[*datasetInfoLoadResult](application).
 		OnStart(func() {
 			datasetInfo.container.SetIsLoading(true)
 		}).
-		OnLoad(func(ds *zfs.Dataset) {
-			datasetInfo.dataset = ds
+		OnLoad(func(result *datasetInfoLoadResult) {
+			datasetInfo.dataset = result.dataset
+			datasetInfo.poolSpace = result.poolSpace
+			datasetInfo.lockedDatasets = result.lockedDatasets
 			datasetInfo.container.SetIsLoading(false)
 			datasetInfo.updateUi()
+			datasetInfo.Events.Emit(LockedDatasetsChangedEvent{Datasets: result.lockedDatasets})
+			if datasetInfo.IsKeyUnavailable() {
+				datasetInfo.Events.Emit(KeyUnavailableEvent{Datasets: result.lockedDatasets})
+			}
 		}).
 		OnError(func(err error) {
 			datasetInfo.container.SetIsLoading(false)
 			// Handle error if needed, for now just clear
 			datasetInfo.dataset = nil
+			datasetInfo.poolSpace = nil
+			datasetInfo.lockedDatasets = nil
 			datasetInfo.updateUi()
+			datasetInfo.Events.Emit(LockedDatasetsChangedEvent{})
 		})
 
 	return datasetInfo
@@ -62,8 +156,8 @@ func (datasetInfo *DatasetInfoComponent) SetPath(path string) {
 		return
 	}
 
-	loadFunc := func(ctx context.Context) (*zfs.Dataset, error) {
-		return zfs.FindHostDataset(path)
+	loadFunc := func(ctx context.Context) (*datasetInfoLoadResult, error) {
+		return loadDatasetInfo(path)
 	}
 
 	if datasetInfo.dataset != nil {
@@ -74,14 +168,19 @@ func (datasetInfo *DatasetInfoComponent) SetPath(path string) {
 }
 
 func (datasetInfo *DatasetInfoComponent) Refresh() {
-	loadFunc := func(ctx context.Context) (*zfs.Dataset, error) {
-		return zfs.FindHostDataset(datasetInfo.path)
+	loadFunc := func(ctx context.Context) (*datasetInfoLoadResult, error) {
+		return loadDatasetInfo(datasetInfo.path)
 	}
 	datasetInfo.loader.Load(loadFunc)
 }
 
 func (datasetInfo *DatasetInfoComponent) SetDataset(dataset *zfs.Dataset) {
 	datasetInfo.dataset = dataset
+	datasetInfo.poolSpace = nil
+	datasetInfo.lockedDatasets = nil
+	if dataset != nil && dataset.IsKeyUnavailable() {
+		datasetInfo.lockedDatasets = []*zfs.LockedDataset{dataset.AsLockedDataset()}
+	}
 	datasetInfo.container.SetIsLoading(false)
 	datasetInfo.updateUi()
 }
@@ -97,13 +196,68 @@ func (datasetInfo *DatasetInfoComponent) updateUi() {
 	titleText := "Dataset"
 	if dataset == nil {
 		datasetInfo.textView.Clear()
-		uiutil.SetupWindow(datasetInfo.textView, titleText)
+		datasetInfo.updateUsageBars(nil)
+		uiutil.SetupWindow(datasetInfo.layout, titleText)
 		return
 	}
 
 	titleText = fmt.Sprintf("%s: %s", titleText, dataset.Path)
-	uiutil.SetupWindow(datasetInfo.textView, titleText)
+	uiutil.SetupWindow(datasetInfo.layout, titleText)
+
+	var properties []*DatasetInfoTableEntry
+	if dataset.IsZfs() {
+		var spaceUsage *zfs.SpaceUsage
+		properties, spaceUsage = datasetInfo.getZfsProperties(dataset)
+		datasetInfo.updateUsageBars(spaceUsage)
+	} else {
+		// datasets of other providers have no properties besides where their snapshots come from
+		properties = []*DatasetInfoTableEntry{{Name: "Provider", Value: dataset.GetProvider().Name()}}
+		datasetInfo.updateUsageBars(nil)
+	}
+
+	datasetInfo.textView.Clear()
+
+	// Calculate alignment padding dynamically based on longest key name
+	maxKeyLen := 0
+	for _, prop := range properties {
+		if len(prop.Name) > maxKeyLen {
+			maxKeyLen = len(prop.Name)
+		}
+	}
+
+	// Sort properties by Name for consistent display
+	sort.Slice(properties, func(i, j int) bool {
+		return properties[i].Name < properties[j].Name
+	})
+
+	keyColorTag := txwidgets.ColorTag(theme.Colors.Layout.Table.Header)
+	var out strings.Builder
+	if datasetInfo.IsKeyUnavailable() {
+		// without the key, neither the files nor the snapshots of the dataset can be accessed
+		out.WriteString(fmt.Sprintf(" %s🔒 Encryption key not loaded, files and snapshots are inaccessible:[-]\n", txwidgets.ColorTag(tcell.ColorRed)))
+		for _, locked := range datasetInfo.lockedDatasets {
+			out.WriteString(fmt.Sprintf("   %s%s[-] (%s)\n", txwidgets.ColorTag(tcell.ColorRed), tview.Escape(locked.Name), tview.Escape(locked.Mountpoint)))
+		}
+		out.WriteString(fmt.Sprintf(" %sPress 'l' in this panel to load the key of %s.[-]\n\n", txwidgets.ColorTag(tcell.ColorGray), tview.Escape(datasetInfo.lockedDatasets[0].Name)))
+	}
+	for _, prop := range properties {
+		valueColor := resolveValueColor(prop.Name, prop.Value)
+		valueColorTag := txwidgets.ColorTag(valueColor)
+
+		out.WriteString(fmt.Sprintf(" %s%*s:[-]  %s%s[-]\n",
+			keyColorTag,
+			maxKeyLen,
+			tview.Escape(prop.Name),
+			valueColorTag,
+			tview.Escape(prop.Value),
+		))
+	}
+
+	datasetInfo.textView.SetText(out.String())
+}
 
+// getZfsProperties returns the properties of a zfs dataset and how its space is used
+func (datasetInfo *DatasetInfoComponent) getZfsProperties(dataset *zfs.Dataset) ([]*DatasetInfoTableEntry, *zfs.SpaceUsage) {
 	properties := []*DatasetInfoTableEntry{
 		{Name: "Type", Value: dataset.GetType()},
 		{Name: "Creation", Value: dataset.GetCreationString().Format(theme.Style.Format.DateTime)},
@@ -116,6 +270,21 @@ func (datasetInfo *DatasetInfoComponent) updateUi() {
 		{Name: "Used", Value: uiutil.StableLengthHumanizedBytes(dataset.GetUsed())},
 	}
 
+	spaceUsage := dataset.GetSpaceUsage()
+	properties = append(properties, []*DatasetInfoTableEntry{
+		{Name: "Used by Snapshots", Value: uiutil.StableLengthHumanizedBytes(spaceUsage.Snapshots)},
+		{Name: "Used by Dataset", Value: uiutil.StableLengthHumanizedBytes(spaceUsage.Dataset)},
+		{Name: "Used by Children", Value: uiutil.StableLengthHumanizedBytes(spaceUsage.Children)},
+		{Name: "Used by Refreserv", Value: uiutil.StableLengthHumanizedBytes(spaceUsage.Refreservation)},
+	}...)
+
+	if datasetInfo.poolSpace != nil {
+		properties = append(properties, &DatasetInfoTableEntry{
+			Name:  "Pool Free",
+			Value: fmt.Sprintf("%s of %s", strings.TrimSpace(uiutil.StableLengthHumanizedBytes(datasetInfo.poolSpace.Free)), strings.TrimSpace(uiutil.StableLengthHumanizedBytes(datasetInfo.poolSpace.Size))),
+		})
+	}
+
 	if dataset.GetType() == "volume" {
 		properties = append(properties, &DatasetInfoTableEntry{Name: "Vol Size", Value: uiutil.StableLengthHumanizedBytes(dataset.GetVolSize())})
 	}
@@ -138,37 +307,38 @@ func (datasetInfo *DatasetInfoComponent) updateUi() {
 		})
 	}
 
-	datasetInfo.textView.Clear()
+	return properties, &spaceUsage
+}
 
-	// Calculate alignment padding dynamically based on longest key name
-	maxKeyLen := 0
-	for _, prop := range properties {
-		if len(prop.Name) > maxKeyLen {
-			maxKeyLen = len(prop.Name)
-		}
+// updateUsageBars shows the pool capacity and the share of the used space which is held by snapshots
+func (datasetInfo *DatasetInfoComponent) updateUsageBars(spaceUsage *zfs.SpaceUsage) {
+	datasetInfo.layout.RemoveItem(datasetInfo.usageBars)
+	if !configuration.CurrentConfig.DatasetInfo.UsageBars || spaceUsage == nil {
+		return
 	}
 
-	// Sort properties by Name for consistent display
-	sort.Slice(properties, func(i, j int) bool {
-		return properties[i].Name < properties[j].Name
-	})
+	height := 1
+	if poolSpace := datasetInfo.poolSpace; poolSpace != nil && poolSpace.Size > 0 {
+		datasetInfo.poolGauge.SetLabel(fmt.Sprintf(" Pool %s ", poolSpace.Name))
+		datasetInfo.poolGauge.SetValue(usagePercentage(poolSpace.Size-poolSpace.Free, poolSpace.Size))
+		datasetInfo.usageBars.ResizeItem(datasetInfo.poolGauge, 1, 0)
+		height++
+	} else {
+		datasetInfo.usageBars.ResizeItem(datasetInfo.poolGauge, 0, 0)
+	}
 
-	keyColorTag := txwidgets.ColorTag(theme.Colors.Layout.Table.Header)
-	var out strings.Builder
-	for _, prop := range properties {
-		valueColor := resolveValueColor(prop.Name, prop.Value)
-		valueColorTag := txwidgets.ColorTag(valueColor)
+	datasetInfo.snapGauge.SetLabel(" Snapshots ")
+	datasetInfo.snapGauge.SetValue(usagePercentage(spaceUsage.Snapshots, spaceUsage.Total()))
 
-		out.WriteString(fmt.Sprintf(" %s%*s:[-]  %s%s[-]\n",
-			keyColorTag,
-			maxKeyLen,
-			tview.Escape(prop.Name),
-			valueColorTag,
-			tview.Escape(prop.Value),
-		))
-	}
+	datasetInfo.layout.AddItem(datasetInfo.usageBars, height, 0, false)
+}
 
-	datasetInfo.textView.SetText(out.String())
+// usagePercentage returns part relative to total in percent
+func usagePercentage(part uint64, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
 }
 
 func resolveValueColor(name, value string) tcell.Color {
@@ -201,7 +371,7 @@ func resolveValueColor(name, value string) tcell.Color {
 	}
 
 	// File Sizes & Ratios
-	if lowerName == "vol size" || lowerName == "available" || lowerName == "used" || lowerName == "snapshots" || strings.Contains(lowerValue, "x") {
+	if lowerName == "vol size" || lowerName == "available" || strings.HasPrefix(lowerName, "used") || lowerName == "pool free" || lowerName == "snapshots" || strings.Contains(lowerValue, "x") {
 		return tcell.ColorYellow
 	}
 
@@ -209,6 +379,20 @@ func resolveValueColor(name, value string) tcell.Color {
 	return tcell.ColorWhite
 }
 
+// IsKeyUnavailable reports whether the current dataset or a dataset below the current path is encrypted
+// and its key is not loaded
+func (datasetInfo *DatasetInfoComponent) IsKeyUnavailable() bool {
+	return len(datasetInfo.lockedDatasets) > 0
+}
+
+func (datasetInfo *DatasetInfoComponent) GetShortcutMap() []shortcut_helper.ShortcutEntry {
+	var shortcutMap []shortcut_helper.ShortcutEntry
+	if datasetInfo.IsKeyUnavailable() {
+		shortcutMap = append(shortcutMap, datasetInfoShortcutLoadKey)
+	}
+	return shortcutMap
+}
+
 func (datasetInfo *DatasetInfoComponent) HasFocus() bool {
 	return datasetInfo.container.HasFocus()
 }
@@ -222,15 +406,8 @@ func (datasetInfo *DatasetInfoComponent) GetLayout() *uiutil.LoadingContainer {
 }
 
 func (datasetInfo *DatasetInfoComponent) SetBorderColor(color tcell.Color) {
-	datasetInfo.textView.SetBorderColor(color)
+	datasetInfo.layout.SetBorderColor(color)
 	if datasetInfo.container != nil {
 		datasetInfo.container.SetBorderColor(color)
 	}
 }
-
-func (datasetInfo *DatasetInfoComponent) CreateSnapshot(name string) error {
-	if datasetInfo.dataset == nil {
-		return fmt.Errorf("no dataset selected")
-	}
-	return datasetInfo.dataset.CreateSnapshot(name)
-}
@@ -0,0 +1,19 @@
+package dataset_info
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsagePercentage(t *testing.T) {
+	assert.Equal(t, 0.0, usagePercentage(10, 0))
+	assert.Equal(t, 25.0, usagePercentage(1, 4))
+	assert.Equal(t, 100.0, usagePercentage(4, 4))
+}
+
+func TestResolveValueColor_SpaceUsage(t *testing.T) {
+	assert.Equal(t, tcell.ColorYellow, resolveValueColor("Used by Snapshots", "   1.0 GiB"))
+	assert.Equal(t, tcell.ColorYellow, resolveValueColor("Pool Free", "1.0 TiB of 4.0 TiB"))
+}
@@ -0,0 +1,30 @@
+package dataset_info
+
+import "zfs-file-history/internal/zfs"
+
+type Event interface {
+	isDatasetInfoEvent()
+}
+
+// RequestLoadKeyEvent asks for the encryption key of the given dataset to be loaded
+type RequestLoadKeyEvent struct {
+	Dataset *zfs.LockedDataset
+}
+
+func (RequestLoadKeyEvent) isDatasetInfoEvent() {}
+
+// KeyUnavailableEvent is emitted when the loaded dataset or a dataset below its path is encrypted
+// and its key is not loaded
+type KeyUnavailableEvent struct {
+	Datasets []*zfs.LockedDataset
+}
+
+func (KeyUnavailableEvent) isDatasetInfoEvent() {}
+
+// LockedDatasetsChangedEvent is emitted whenever the dataset info has been loaded,
+// Datasets contains the datasets at or below the path whose key is not loaded
+type LockedDatasetsChangedEvent struct {
+	Datasets []*zfs.LockedDataset
+}
+
+func (LockedDatasetsChangedEvent) isDatasetInfoEvent() {}
@@ -0,0 +1,120 @@
+package dialog
+
+import (
+	"fmt"
+	"slices"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/ui/localization"
+	"zfs-file-history/internal/ui/theme"
+	"zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	CreateBookmarkDialogPage  util.Page = "CreateBookmarkDialog"
+	BookmarksDialogPage       util.Page = "BookmarksDialog"
+	DestroyBookmarkDialogPage util.Page = "DestroyBookmarkDialog"
+
+	CreateBookmarkDialogNameFieldId = "name"
+
+	DestroyBookmarkDialogDestroyActionId DialogActionId = iota
+)
+
+// NewCreateBookmarkDialog prompts for the name of a new bookmark of the given snapshot.
+// existingNames - names of all bookmarks of the same dataset, used to detect conflicts
+func NewCreateBookmarkDialog(
+	application *tview.Application,
+	snapshot *data.SnapshotBrowserEntry,
+	existingNames []string,
+	asyncWork func(d *InputDialog, values InputDialogValues) error,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	fields := []*InputDialogField{
+		{
+			Id:    CreateBookmarkDialogNameFieldId,
+			Label: "Name",
+			Value: snapshot.Snapshot.Name,
+		},
+	}
+
+	return NewInputDialog(
+		application,
+		string(CreateBookmarkDialogPage),
+		" 🔖 Create Bookmark ",
+		fmt.Sprintf("Create a bookmark of '%s'. The bookmark keeps the point in time for incremental sends after the snapshot has been destroyed.", snapshot.Snapshot.FullName),
+		fields,
+		asyncWork,
+		onComplete,
+	).SetValidator(func(values InputDialogValues) error {
+		return validateBookmarkName(values[CreateBookmarkDialogNameFieldId], existingNames)
+	})
+}
+
+func validateBookmarkName(name string, existingNames []string) error {
+	if err := zfs.ValidateBookmarkName(name); err != nil {
+		return err
+	}
+	if slices.Contains(existingNames, name) {
+		return fmt.Errorf("bookmark '%s' already exists", name)
+	}
+	return nil
+}
+
+// NewBookmarksDialog lists the bookmarks of a dataset. Selecting a bookmark offers to destroy it,
+// its option id is the index of the bookmark + 1.
+func NewBookmarksDialog(
+	application *tview.Application,
+	datasetName string,
+	bookmarks []*zfs.Bookmark,
+	onComplete func(d *SelectionDialog, option *DialogOption, err error),
+) *SelectionDialog {
+	var dialogOptions []*DialogOption
+	for i, bookmark := range bookmarks {
+		dialogOptions = append(dialogOptions, &DialogOption{
+			Id:   DialogActionId(i + 1),
+			Name: formatBookmark(bookmark),
+		})
+	}
+	dialogOptions = append(dialogOptions, &DialogOption{
+		Id:   DialogCloseActionId,
+		Name: localization.LocalizationCommonClose,
+	})
+
+	description := fmt.Sprintf("'%s' has no bookmarks.", datasetName)
+	if len(bookmarks) > 0 {
+		description = fmt.Sprintf("Bookmarks of '%s'. Bookmarks hold no data and cannot be browsed or restored, select one to destroy it:", datasetName)
+	}
+
+	return NewSelectionDialog(
+		application,
+		string(BookmarksDialogPage),
+		" 🔖 Bookmarks ",
+		description,
+		dialogOptions,
+		nil,
+		onComplete,
+	)
+}
+
+func formatBookmark(bookmark *zfs.Bookmark) string {
+	return fmt.Sprintf("%s  %s  (guid %s)", bookmark.CreationDate.Format(theme.Style.Format.DateTime), bookmark.Name, bookmark.Guid)
+}
+
+func NewDestroyBookmarkDialog(
+	application *tview.Application,
+	bookmark *zfs.Bookmark,
+	asyncWork func(d *SelectionDialog, action DialogActionId) error,
+	onComplete func(d *SelectionDialog, option *DialogOption, err error),
+) *SelectionDialog {
+	return NewSelectionDialog(
+		application,
+		string(DestroyBookmarkDialogPage),
+		" 💥 Destroy Bookmark ",
+		fmt.Sprintf("Destroy '%s'? Incremental sends based on it will no longer be possible.", bookmark.FullName),
+		buildConfirmDialogOptions(DestroyBookmarkDialogDestroyActionId, "Destroy", true, DialogSeverityDanger),
+		asyncWork,
+		onComplete,
+	)
+}
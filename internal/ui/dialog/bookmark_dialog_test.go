@@ -0,0 +1,46 @@
+package dialog
+
+import (
+	"testing"
+	"time"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBookmarkName(t *testing.T) {
+	existing := []string{"replica-1"}
+
+	assert.NoError(t, validateBookmarkName("replica-2", existing))
+	assert.ErrorContains(t, validateBookmarkName("replica-1", existing), "already exists")
+	assert.ErrorContains(t, validateBookmarkName("tank#b", existing), "invalid character")
+	assert.Error(t, validateBookmarkName("", existing))
+}
+
+func TestNewBookmarksDialog(t *testing.T) {
+	app := tview.NewApplication()
+	bookmarks := []*zfs.Bookmark{
+		{Name: "replica-2", FullName: "tank/data#replica-2", CreationDate: time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local), Guid: "42"},
+		{Name: "replica-1", FullName: "tank/data#replica-1", CreationDate: time.Date(2024, 1, 1, 15, 4, 5, 0, time.Local), Guid: "7"},
+	}
+
+	d := NewBookmarksDialog(app, "tank/data", bookmarks, nil)
+
+	assert.Equal(t, string(BookmarksDialogPage), d.GetName())
+	assert.Len(t, d.options, 3)
+	assert.Equal(t, DialogActionId(1), d.options[0].Id)
+	assert.Equal(t, "2024-01-02 15:04:05  replica-2  (guid 42)", d.options[0].Name)
+	assert.Equal(t, DialogActionId(2), d.options[1].Id)
+	assert.Equal(t, DialogCloseActionId, d.options[2].Id)
+	assert.Contains(t, d.description, "cannot be browsed")
+}
+
+func TestNewBookmarksDialog_Empty(t *testing.T) {
+	app := tview.NewApplication()
+
+	d := NewBookmarksDialog(app, "tank/data", nil, nil)
+
+	assert.Len(t, d.options, 1)
+	assert.Equal(t, "'tank/data' has no bookmarks.", d.description)
+}
@@ -0,0 +1,151 @@
+package dialog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/ui/localization"
+	"zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	CloneSnapshotDialogPage  util.Page = "CloneSnapshotDialog"
+	SnapshotClonesDialogPage util.Page = "SnapshotClonesDialog"
+
+	CloneSnapshotDialogNameFieldId       = "name"
+	CloneSnapshotDialogMountpointFieldId = "mountpoint"
+
+	CloneDialogOpenMountpointActionId DialogActionId = iota
+)
+
+// NewCloneSnapshotDialog prompts for the name and mountpoint of a new clone of the given snapshot.
+func NewCloneSnapshotDialog(
+	application *tview.Application,
+	snapshot *data.SnapshotBrowserEntry,
+	asyncWork func(d *InputDialog, values InputDialogValues) error,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	fields := []*InputDialogField{
+		{
+			Id:    CloneSnapshotDialogNameFieldId,
+			Label: "Dataset",
+			Value: snapshot.Snapshot.SuggestCloneName(),
+		},
+		{
+			Id:          CloneSnapshotDialogMountpointFieldId,
+			Label:       "Mountpoint",
+			Placeholder: "inherit",
+		},
+	}
+
+	return NewInputDialog(
+		application,
+		string(CloneSnapshotDialogPage),
+		" 🐑 Clone Snapshot ",
+		fmt.Sprintf("Create a writable dataset from '%s'.", snapshot.Snapshot.FullName),
+		fields,
+		asyncWork,
+		onComplete,
+	).SetValidator(validateCloneInput)
+}
+
+func validateCloneInput(values InputDialogValues) error {
+	if err := zfs.ValidateDatasetName(strings.TrimSpace(values[CloneSnapshotDialogNameFieldId])); err != nil {
+		return err
+	}
+
+	mountpoint := strings.TrimSpace(values[CloneSnapshotDialogMountpointFieldId])
+	switch {
+	case mountpoint == "", mountpoint == "none", mountpoint == "legacy":
+		return nil
+	case !strings.HasPrefix(mountpoint, "/"):
+		return errors.New("mountpoint must be an absolute path, 'none' or 'legacy'")
+	}
+	return nil
+}
+
+// NewCloneCreatedDialog informs about a newly created clone and offers to open its mountpoint.
+func NewCloneCreatedDialog(
+	application *tview.Application,
+	clone *zfs.SnapshotClone,
+	onComplete func(d *SelectionDialog, option *DialogOption, err error),
+) *SelectionDialog {
+	return NewSelectionDialog(
+		application,
+		string(CloneSnapshotDialogPage),
+		" ✅ Clone Created ",
+		describeClone(clone),
+		buildCloneCreatedDialogOptions(clone),
+		nil,
+		onComplete,
+	)
+}
+
+func buildCloneCreatedDialogOptions(clone *zfs.SnapshotClone) []*DialogOption {
+	dialogOptions := []*DialogOption{}
+	if clone.IsBrowsable() {
+		dialogOptions = append(dialogOptions, &DialogOption{
+			Id:   CloneDialogOpenMountpointActionId,
+			Name: "📂 Open in File Browser",
+		})
+	}
+	return append(dialogOptions, &DialogOption{
+		Id:   DialogCloseActionId,
+		Name: localization.LocalizationCommonClose,
+	})
+}
+
+// NewSnapshotClonesDialog lists all clones of a snapshot. Selecting a clone results in an option
+// with the id `DialogActionId(i + 1)`, where i is the index of the clone within the given list.
+func NewSnapshotClonesDialog(
+	application *tview.Application,
+	snapshot *data.SnapshotBrowserEntry,
+	clones []*zfs.SnapshotClone,
+	onComplete func(d *SelectionDialog, option *DialogOption, err error),
+) *SelectionDialog {
+	var dialogOptions []*DialogOption
+	for i, clone := range clones {
+		name := clone.Name
+		if clone.IsBrowsable() {
+			name = fmt.Sprintf("%s → %s", clone.Name, clone.Mountpoint)
+		}
+		dialogOptions = append(dialogOptions, &DialogOption{
+			Id:   DialogActionId(i + 1),
+			Name: name,
+		})
+	}
+	dialogOptions = append(dialogOptions, &DialogOption{
+		Id:   DialogCloseActionId,
+		Name: localization.LocalizationCommonClose,
+	})
+
+	description := fmt.Sprintf("'%s' has no clones.", snapshot.Snapshot.Name)
+	if len(clones) > 0 {
+		description = fmt.Sprintf("Clones of '%s', select one to open it in the file browser:", snapshot.Snapshot.Name)
+	}
+
+	return NewSelectionDialog(
+		application,
+		string(SnapshotClonesDialogPage),
+		" 🐑 Clones ",
+		description,
+		dialogOptions,
+		nil,
+		onComplete,
+	)
+}
+
+func describeClone(clone *zfs.SnapshotClone) string {
+	switch {
+	case clone.IsBrowsable():
+		return fmt.Sprintf("Clone '%s' is mounted at '%s'.", clone.Name, clone.Mountpoint)
+	case strings.HasPrefix(clone.Mountpoint, "/"):
+		return fmt.Sprintf("Clone '%s' was created, but is not mounted at '%s'.", clone.Name, clone.Mountpoint)
+	default:
+		return fmt.Sprintf("Clone '%s' was created with mountpoint '%s'.", clone.Name, clone.Mountpoint)
+	}
+}
@@ -0,0 +1,53 @@
+package dialog
+
+import (
+	"testing"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCloneInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		dataset    string
+		mountpoint string
+		wantErr    bool
+	}{
+		{name: "Inherited Mountpoint", dataset: "tank/data-snap1", mountpoint: "", wantErr: false},
+		{name: "Absolute Mountpoint", dataset: "tank/data-snap1", mountpoint: "/mnt/old", wantErr: false},
+		{name: "No Mountpoint", dataset: "tank/data-snap1", mountpoint: "none", wantErr: false},
+		{name: "Legacy Mountpoint", dataset: "tank/data-snap1", mountpoint: "legacy", wantErr: false},
+		{name: "Relative Mountpoint", dataset: "tank/data-snap1", mountpoint: "mnt/old", wantErr: true},
+		{name: "Empty Dataset", dataset: " ", mountpoint: "", wantErr: true},
+		{name: "Snapshot As Dataset", dataset: "tank/data@snap1", mountpoint: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCloneInput(InputDialogValues{
+				CloneSnapshotDialogNameFieldId:       tt.dataset,
+				CloneSnapshotDialogMountpointFieldId: tt.mountpoint,
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildCloneCreatedDialogOptions(t *testing.T) {
+	mounted := &zfs.SnapshotClone{Name: "tank/data-snap1", Mountpoint: "/tank/data-snap1", Mounted: true}
+	assert.Equal(t,
+		[]DialogActionId{CloneDialogOpenMountpointActionId, DialogCloseActionId},
+		optionIds(buildCloneCreatedDialogOptions(mounted)),
+	)
+
+	unmounted := &zfs.SnapshotClone{Name: "tank/data-snap1", Mountpoint: "none"}
+	assert.Equal(t,
+		[]DialogActionId{DialogCloseActionId},
+		optionIds(buildCloneCreatedDialogOptions(unmounted)),
+	)
+}
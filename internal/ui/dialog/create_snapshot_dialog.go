@@ -0,0 +1,114 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+	uiutil "zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/util"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	CreateSnapshotDialogPage uiutil.Page = "CreateSnapshotDialog"
+
+	CreateSnapshotDialogPromptFieldId     = "prompt"
+	CreateSnapshotDialogNameFieldId       = "name"
+	CreateSnapshotDialogRecursiveFieldId  = "recursive"
+	CreateSnapshotDialogPropertiesFieldId = "properties"
+)
+
+// NewCreateSnapshotDialog prompts for the name, recursion and user properties of a new snapshot.
+// nameTemplate - the configured template used to prefill the name, see util.ExpandNameTemplate
+func NewCreateSnapshotDialog(
+	application *tview.Application,
+	datasetName string,
+	nameTemplate string,
+	asyncWork func(d *InputDialog, values InputDialogValues) error,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	hasPrompt := util.NameTemplateHasPrompt(nameTemplate)
+
+	// a template with a prompt is rendered once the user typed something
+	suggestedName := ""
+	if !hasPrompt {
+		suggestedName, _ = zfs.RenderSnapshotName(nameTemplate, "")
+	}
+
+	var fields []*InputDialogField
+	if hasPrompt {
+		fields = append(fields, &InputDialogField{
+			Id:    CreateSnapshotDialogPromptFieldId,
+			Label: "Description",
+		})
+	}
+	fields = append(fields,
+		&InputDialogField{
+			Id:    CreateSnapshotDialogNameFieldId,
+			Label: "Name",
+			Value: suggestedName,
+		},
+		&InputDialogField{
+			Id:    CreateSnapshotDialogRecursiveFieldId,
+			Type:  InputDialogFieldTypeCheckbox,
+			Label: "Include child datasets",
+		},
+		&InputDialogField{
+			Id:          CreateSnapshotDialogPropertiesFieldId,
+			Label:       "Properties",
+			Placeholder: "zfh:note=pre-deploy, ...",
+		},
+	)
+
+	d := NewInputDialog(
+		application,
+		string(CreateSnapshotDialogPage),
+		" 📷 Create Snapshot ",
+		fmt.Sprintf("Create a new snapshot of '%s'.", datasetName),
+		fields,
+		asyncWork,
+		onComplete,
+	).SetValidator(validateCreateSnapshotInput)
+
+	if hasPrompt {
+		d.SetChangedFunc(func(d *InputDialog, id string, value string) {
+			if id != CreateSnapshotDialogPromptFieldId {
+				return
+			}
+			name, err := zfs.RenderSnapshotName(nameTemplate, strings.TrimSpace(value))
+			if err != nil {
+				d.ShowError(err)
+				return
+			}
+			d.ShowError(nil)
+			d.SetValue(CreateSnapshotDialogNameFieldId, name)
+		})
+	}
+
+	return d
+}
+
+func validateCreateSnapshotInput(values InputDialogValues) error {
+	if err := zfs.ValidateSnapshotName(strings.TrimSpace(values[CreateSnapshotDialogNameFieldId])); err != nil {
+		return err
+	}
+	_, err := ParseSnapshotProperties(values[CreateSnapshotDialogPropertiesFieldId])
+	return err
+}
+
+// ParseSnapshotProperties parses a comma separated list of "key=value" user property assignments
+func ParseSnapshotProperties(text string) (map[string]string, error) {
+	properties := map[string]string{}
+	for _, assignment := range strings.Split(text, ",") {
+		if strings.TrimSpace(assignment) == "" {
+			continue
+		}
+		key, value, err := zfs.ParseUserProperty(assignment)
+		if err != nil {
+			return nil, err
+		}
+		properties[key] = strings.TrimSpace(value)
+	}
+	return properties, nil
+}
@@ -0,0 +1,56 @@
+package dialog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSnapshotProperties(t *testing.T) {
+	properties, err := ParseSnapshotProperties("zfh:note=pre-deploy, com.example:ticket = OPS-42 ,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"zfh:note":           "pre-deploy",
+		"com.example:ticket": "OPS-42",
+	}, properties)
+
+	properties, err = ParseSnapshotProperties("")
+	assert.NoError(t, err)
+	assert.Empty(t, properties)
+
+	_, err = ParseSnapshotProperties("compression=lz4")
+	assert.Error(t, err)
+}
+
+func TestCreateSnapshotDialog_Fields(t *testing.T) {
+	d := NewCreateSnapshotDialog(tview.NewApplication(), "tank/data", "manual-{date:2006}", nil, nil)
+	values := d.GetValues()
+
+	assert.NotContains(t, values, CreateSnapshotDialogPromptFieldId)
+	assert.True(t, strings.HasPrefix(values[CreateSnapshotDialogNameFieldId], "manual-"))
+	assert.False(t, values.GetBool(CreateSnapshotDialogRecursiveFieldId))
+	assert.NoError(t, validateCreateSnapshotInput(values))
+}
+
+func TestCreateSnapshotDialog_PromptUpdatesName(t *testing.T) {
+	d := NewCreateSnapshotDialog(tview.NewApplication(), "tank/data", "{prompt}-{user}", nil, nil)
+	assert.Empty(t, d.GetValues()[CreateSnapshotDialogNameFieldId])
+
+	d.SetValue(CreateSnapshotDialogPromptFieldId, "before-upgrade")
+	assert.True(t, strings.HasPrefix(d.GetValues()[CreateSnapshotDialogNameFieldId], "before-upgrade-"))
+}
+
+func TestValidateCreateSnapshotInput(t *testing.T) {
+	assert.Error(t, validateCreateSnapshotInput(InputDialogValues{CreateSnapshotDialogNameFieldId: ""}))
+	assert.Error(t, validateCreateSnapshotInput(InputDialogValues{CreateSnapshotDialogNameFieldId: "a@b"}))
+	assert.Error(t, validateCreateSnapshotInput(InputDialogValues{
+		CreateSnapshotDialogNameFieldId:       "snap",
+		CreateSnapshotDialogPropertiesFieldId: "note=x",
+	}))
+	assert.NoError(t, validateCreateSnapshotInput(InputDialogValues{
+		CreateSnapshotDialogNameFieldId:       "snap",
+		CreateSnapshotDialogPropertiesFieldId: "zfh:note=x",
+	}))
+}
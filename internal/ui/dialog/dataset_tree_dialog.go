@@ -0,0 +1,213 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"zfs-file-history/internal/ui/shortcut_helper"
+	"zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	DatasetTreeDialogPage util.Page = "DatasetTreeDialog"
+
+	datasetTreeDialogTitle = " 🌳 Datasets "
+)
+
+// DatasetTreeDialog lists all pools and datasets as a tree and allows jumping to the mountpoint of one of them
+type DatasetTreeDialog struct {
+	application   *tview.Application
+	datasets      []*zfs.DatasetSummary
+	onSelect      func(dataset *zfs.DatasetSummary)
+	layout        *tview.Flex
+	actionChannel chan DialogActionId
+
+	tree       *tview.TreeView
+	statusView *tview.TextView
+}
+
+// NewDatasetTreeDialog
+// currentPath - the dataset containing this path is selected initially
+// onSelect - called on the UI thread when a browsable dataset has been selected
+func NewDatasetTreeDialog(
+	application *tview.Application,
+	datasets []*zfs.DatasetSummary,
+	currentPath string,
+	onSelect func(dataset *zfs.DatasetSummary),
+) *DatasetTreeDialog {
+	d := &DatasetTreeDialog{
+		application:   application,
+		datasets:      datasets,
+		onSelect:      onSelect,
+		actionChannel: make(chan DialogActionId),
+	}
+	d.createLayout(currentPath)
+	return d
+}
+
+func (d *DatasetTreeDialog) createLayout(currentPath string) {
+	root := tview.NewTreeNode("")
+	nodes := map[string]*tview.TreeNode{}
+	var currentNode *tview.TreeNode
+	currentMountpointLength := -1
+
+	maxTextWidth := 0
+	for _, dataset := range d.datasets {
+		text := formatDatasetTreeNode(dataset)
+		depth := strings.Count(dataset.Name, "/")
+		if width := 2*depth + utf8.RuneCountInString(text); width > maxTextWidth {
+			maxTextWidth = width
+		}
+
+		node := tview.NewTreeNode(text).
+			SetReference(dataset).
+			SetSelectable(true)
+		if !dataset.IsBrowsable() {
+			node.SetColor(tcell.ColorGray)
+		}
+
+		parent, ok := nodes[dataset.GetParentName()]
+		if !ok {
+			parent = root
+		}
+		parent.AddChild(node)
+		nodes[dataset.Name] = node
+
+		if dataset.IsBrowsable() && isPathOnMountpoint(currentPath, dataset.Mountpoint) && len(dataset.Mountpoint) > currentMountpointLength {
+			currentNode = node
+			currentMountpointLength = len(dataset.Mountpoint)
+		}
+	}
+
+	d.tree = tview.NewTreeView().
+		SetRoot(root).
+		SetTopLevel(1).
+		SetGraphics(true)
+	d.tree.SetChangedFunc(d.onNodeChanged)
+	d.tree.SetSelectedFunc(d.onNodeSelected)
+
+	d.statusView = tview.NewTextView().SetDynamicColors(false)
+	d.statusView.SetTextColor(tcell.ColorGray)
+
+	shortcutMap := shortcut_helper.NewShortcutMap(d.application)
+	shortcutMap.SetEntries([]shortcut_helper.ShortcutEntry{
+		{KeyCombo: []string{"Enter"}, Name: "Open"},
+		{KeyCombo: []string{"Esc"}, Name: "Close"},
+	})
+
+	content := tview.NewFlex().SetDirection(tview.FlexRow)
+	content.AddItem(d.tree, 0, 1, true)
+	content.AddItem(d.statusView, 1, 0, false)
+	content.AddItem(tview.NewBox(), 1, 0, false)
+	content.AddItem(shortcutMap.GetLayout(), 1, 0, false)
+
+	if len(d.datasets) == 0 {
+		d.statusView.SetText("No datasets found.")
+	}
+
+	d.layout = createModal(datasetTreeDialogTitle, content, DialogSizeConstraints{
+		Title:             datasetTreeDialogTitle,
+		ExtraContentWidth: maxTextWidth + 2,
+		StaticHeight:      len(d.datasets) + 3,
+	})
+	d.layout.SetInputCapture(d.captureInput)
+
+	if currentNode == nil && len(root.GetChildren()) > 0 {
+		currentNode = root.GetChildren()[0]
+	}
+	if currentNode != nil {
+		d.tree.SetCurrentNode(currentNode)
+		d.onNodeChanged(currentNode)
+	}
+}
+
+func formatDatasetTreeNode(dataset *zfs.DatasetSummary) string {
+	name := dataset.Name
+	if index := strings.LastIndex(name, "/"); index >= 0 {
+		name = name[index+1:]
+	}
+
+	parts := []string{name}
+	if dataset.Type == zfs.DatasetTypeFilesystem {
+		parts = append(parts, dataset.Mountpoint)
+	}
+	parts = append(parts,
+		strings.TrimSpace(util.StableLengthHumanizedBytes(dataset.Used)),
+		fmt.Sprintf("%d snapshots", dataset.SnapshotCount),
+	)
+	if dataset.IsEncrypted() {
+		if dataset.KeyStatus == zfs.KeyStatusUnavailable {
+			parts = append(parts, "🔒")
+		} else {
+			parts = append(parts, "🔓")
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// isPathOnMountpoint reports whether path is equal to or located below the given mountpoint
+func isPathOnMountpoint(path string, mountpoint string) bool {
+	return mountpoint == "/" || path == mountpoint || strings.HasPrefix(path, mountpoint+"/")
+}
+
+func getDatasetOfNode(node *tview.TreeNode) *zfs.DatasetSummary {
+	if node == nil {
+		return nil
+	}
+	dataset, _ := node.GetReference().(*zfs.DatasetSummary)
+	return dataset
+}
+
+func (d *DatasetTreeDialog) onNodeChanged(node *tview.TreeNode) {
+	dataset := getDatasetOfNode(node)
+	if dataset == nil {
+		d.statusView.SetText("")
+		return
+	}
+	if reason := dataset.GetUnavailableReason(); reason != "" {
+		d.statusView.SetTextColor(tcell.ColorOrange)
+		d.statusView.SetText(fmt.Sprintf("%s cannot be opened: %s", dataset.Name, reason))
+		return
+	}
+	d.statusView.SetTextColor(tcell.ColorGray)
+	d.statusView.SetText(dataset.Name)
+}
+
+func (d *DatasetTreeDialog) onNodeSelected(node *tview.TreeNode) {
+	dataset := getDatasetOfNode(node)
+	if dataset == nil || !dataset.IsBrowsable() {
+		return
+	}
+	d.Close()
+	if d.onSelect != nil {
+		d.onSelect(dataset)
+	}
+}
+
+func (d *DatasetTreeDialog) captureInput(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyEscape {
+		d.Close()
+		return nil
+	}
+	return event
+}
+
+func (d *DatasetTreeDialog) GetName() string {
+	return string(DatasetTreeDialogPage)
+}
+
+func (d *DatasetTreeDialog) GetLayout() *tview.Flex {
+	return d.layout
+}
+
+func (d *DatasetTreeDialog) GetActionChannel() <-chan DialogActionId {
+	return d.actionChannel
+}
+
+func (d *DatasetTreeDialog) Close() {
+	emitDialogActions(d.actionChannel, DialogCloseActionId)
+}
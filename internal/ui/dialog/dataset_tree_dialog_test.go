@@ -0,0 +1,75 @@
+package dialog
+
+import (
+	"testing"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestDatasetSummaries() []*zfs.DatasetSummary {
+	return []*zfs.DatasetSummary{
+		{Name: "tank", Type: zfs.DatasetTypeFilesystem, Mountpoint: "/tank", Mounted: true, CanMount: "on", Used: 1024},
+		{Name: "tank/data", Type: zfs.DatasetTypeFilesystem, Mountpoint: "/tank/data", Mounted: true, CanMount: "on", SnapshotCount: 3},
+		{Name: "tank/archive", Type: zfs.DatasetTypeFilesystem, Mountpoint: "/tank/archive", CanMount: "off"},
+		{Name: "tank/archive/2023", Type: zfs.DatasetTypeFilesystem, Mountpoint: "/tank/archive/2023", Mounted: true, CanMount: "on"},
+	}
+}
+
+func TestNewDatasetTreeDialog_Hierarchy(t *testing.T) {
+	app := tview.NewApplication()
+
+	d := NewDatasetTreeDialog(app, createTestDatasetSummaries(), "/", nil)
+
+	assert.Equal(t, string(DatasetTreeDialogPage), d.GetName())
+	pools := d.tree.GetRoot().GetChildren()
+	assert.Len(t, pools, 1)
+	assert.Len(t, pools[0].GetChildren(), 2)
+	assert.Equal(t, "tank/archive/2023", getDatasetOfNode(pools[0].GetChildren()[1].GetChildren()[0]).Name)
+}
+
+func TestNewDatasetTreeDialog_SelectsDatasetOfCurrentPath(t *testing.T) {
+	app := tview.NewApplication()
+
+	d := NewDatasetTreeDialog(app, createTestDatasetSummaries(), "/tank/data/projects", nil)
+
+	assert.Equal(t, "tank/data", getDatasetOfNode(d.tree.GetCurrentNode()).Name)
+	assert.Equal(t, "tank/data", d.statusView.GetText(true))
+}
+
+func TestDatasetTreeDialog_OnlyBrowsableDatasetsCanBeSelected(t *testing.T) {
+	app := tview.NewApplication()
+	var selected *zfs.DatasetSummary
+	datasets := createTestDatasetSummaries()
+	d := NewDatasetTreeDialog(app, datasets, "/", func(dataset *zfs.DatasetSummary) {
+		selected = dataset
+	})
+	archiveNode := d.tree.GetRoot().GetChildren()[0].GetChildren()[1]
+
+	d.onNodeChanged(archiveNode)
+	d.onNodeSelected(archiveNode)
+	assert.Nil(t, selected)
+	assert.Contains(t, d.statusView.GetText(true), "canmount=off")
+
+	d.onNodeSelected(archiveNode.GetChildren()[0])
+	assert.Equal(t, datasets[3], selected)
+}
+
+func TestFormatDatasetTreeNode(t *testing.T) {
+	dataset := &zfs.DatasetSummary{
+		Name:          "tank/secure",
+		Type:          zfs.DatasetTypeFilesystem,
+		Mountpoint:    "/tank/secure",
+		SnapshotCount: 2,
+		Encryption:    "aes-256-gcm",
+		KeyStatus:     zfs.KeyStatusUnavailable,
+	}
+
+	text := formatDatasetTreeNode(dataset)
+	assert.Contains(t, text, "secure  /tank/secure  ")
+	assert.Contains(t, text, "2 snapshots  🔒")
+
+	volume := &zfs.DatasetSummary{Name: "tank/vm", Type: zfs.DatasetTypeVolume, Mountpoint: "-"}
+	assert.NotContains(t, formatDatasetTreeNode(volume), " - ")
+}
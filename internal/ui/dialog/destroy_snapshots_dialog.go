@@ -0,0 +1,116 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/dustin/go-humanize"
+	"github.com/rivo/tview"
+)
+
+const (
+	DestroySnapshotsDialogPage       util.Page = "DestroySnapshotsDialog"
+	DestroySnapshotsReportDialogPage util.Page = "DestroySnapshotsReportDialog"
+
+	DestroySnapshotsDialogDestroyActionId DialogActionId = iota
+)
+
+// NewDestroySnapshotsDialog asks for confirmation before destroying multiple snapshots,
+// showing how much space would be reclaimed according to a dry-run.
+func NewDestroySnapshotsDialog(
+	application *tview.Application,
+	snapshots []*data.SnapshotBrowserEntry,
+	recursive bool,
+	estimate *zfs.DestroyEstimate,
+	estimateErr error,
+	asyncWork func(d *SelectionDialog, action DialogActionId) error,
+	onComplete func(d *SelectionDialog, option *DialogOption, err error),
+) *SelectionDialog {
+	return NewSelectionDialog(
+		application,
+		string(DestroySnapshotsDialogPage),
+		" 💥 Destroy Snapshots ",
+		describeDestroyEstimate(len(snapshots), recursive, estimate, estimateErr),
+		buildConfirmDialogOptions(DestroySnapshotsDialogDestroyActionId, "Destroy", true, DialogSeverityDanger),
+		asyncWork,
+		onComplete,
+	)
+}
+
+func describeDestroyEstimate(count int, recursive bool, estimate *zfs.DestroyEstimate, estimateErr error) string {
+	var sb strings.Builder
+	if recursive {
+		sb.WriteString(fmt.Sprintf("Destroy %d snapshots, including those of child datasets and dependent clones?\n", count))
+	} else {
+		sb.WriteString(fmt.Sprintf("Destroy %d snapshots?\n", count))
+	}
+
+	sb.WriteString("\n")
+	if estimateErr != nil || estimate == nil {
+		reason := "unknown error"
+		if estimateErr != nil {
+			reason = estimateErr.Error()
+		}
+		sb.WriteString(fmt.Sprintf("Reclaimable space could not be estimated: %s\n", reason))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Reclaimable space: %s\n", humanize.IBytes(estimate.Reclaimable)))
+
+	if len(estimate.Skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("\nSnapshots that will be skipped (%d):\n", len(estimate.Skipped)))
+		var entries []string
+		for _, result := range estimate.Skipped {
+			entries = append(entries, fmt.Sprintf("%s: %s", result.Snapshot.Name, result.Reason))
+		}
+		writeLimitedList(&sb, entries)
+	}
+	return sb.String()
+}
+
+// NewDestroySnapshotsReportDialog lists the outcome of destroying each snapshot of a batch
+func NewDestroySnapshotsReportDialog(application *tview.Application, results []*zfs.DestroyResult) *SelectionDialog {
+	title := " ✅ Snapshots Destroyed "
+	for _, result := range results {
+		if result.Status != zfs.DestroyStatusDestroyed {
+			title = " ⚠️ Snapshots Partially Destroyed "
+			break
+		}
+	}
+
+	return NewSelectionDialog(
+		application,
+		string(DestroySnapshotsReportDialogPage),
+		title,
+		describeDestroyResults(results),
+		[]*DialogOption{
+			{
+				Id:   DialogCloseActionId,
+				Name: "OK",
+			},
+		},
+		nil,
+		nil,
+	)
+}
+
+func describeDestroyResults(results []*zfs.DestroyResult) string {
+	var destroyed, skipped, failed int
+	var sb strings.Builder
+	for _, result := range results {
+		switch {
+		case result.Status == zfs.DestroyStatusDestroyed:
+			destroyed++
+			sb.WriteString(fmt.Sprintf("✔ %s: destroyed\n", result.Snapshot.Name))
+		case result.IsSkipped():
+			skipped++
+			sb.WriteString(fmt.Sprintf("⏭ %s: skipped, %s\n", result.Snapshot.Name, result.Reason))
+		default:
+			failed++
+			sb.WriteString(fmt.Sprintf("✖ %s: failed, %s\n", result.Snapshot.Name, result.Reason))
+		}
+	}
+	return fmt.Sprintf("%d destroyed, %d skipped, %d failed.\n\n%s", destroyed, skipped, failed, sb.String())
+}
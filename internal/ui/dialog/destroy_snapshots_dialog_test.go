@@ -0,0 +1,44 @@
+package dialog
+
+import (
+	"errors"
+	"testing"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeDestroyEstimate(t *testing.T) {
+	estimate := &zfs.DestroyEstimate{
+		Reclaimable: 3 * 1024 * 1024,
+		Skipped: []*zfs.DestroyResult{
+			{Snapshot: &zfs.Snapshot{Name: "held"}, Status: zfs.DestroyStatusSkippedHold, Reason: "held by keep"},
+		},
+	}
+
+	text := describeDestroyEstimate(3, false, estimate, nil)
+	assert.Contains(t, text, "Destroy 3 snapshots?")
+	assert.Contains(t, text, "Reclaimable space: 3.0 MiB")
+	assert.Contains(t, text, "Snapshots that will be skipped (1):\n  held: held by keep\n")
+
+	recursive := describeDestroyEstimate(2, true, &zfs.DestroyEstimate{}, nil)
+	assert.Contains(t, recursive, "child datasets and dependent clones")
+	assert.NotContains(t, recursive, "skipped")
+
+	failed := describeDestroyEstimate(2, false, nil, errors.New("permission denied"))
+	assert.Contains(t, failed, "could not be estimated: permission denied")
+}
+
+func TestDescribeDestroyResults(t *testing.T) {
+	results := []*zfs.DestroyResult{
+		{Snapshot: &zfs.Snapshot{Name: "a"}, Status: zfs.DestroyStatusDestroyed},
+		{Snapshot: &zfs.Snapshot{Name: "b"}, Status: zfs.DestroyStatusSkippedClones, Reason: "has 1 dependent clone(s)"},
+		{Snapshot: &zfs.Snapshot{Name: "c"}, Status: zfs.DestroyStatusFailed, Reason: "dataset is busy"},
+	}
+
+	text := describeDestroyResults(results)
+	assert.Contains(t, text, "1 destroyed, 1 skipped, 1 failed.")
+	assert.Contains(t, text, "✔ a: destroyed\n")
+	assert.Contains(t, text, "⏭ b: skipped, has 1 dependent clone(s)\n")
+	assert.Contains(t, text, "✖ c: failed, dataset is busy\n")
+}
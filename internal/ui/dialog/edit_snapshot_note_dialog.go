@@ -0,0 +1,67 @@
+package dialog
+
+import (
+	"fmt"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+)
+
+const EditSnapshotNoteDialogPage util.Page = "EditSnapshotNoteDialog"
+
+// NewEditSnapshotNoteDialog shows an input field for each of the given user properties of a snapshot.
+// The values of the returned InputDialogValues are keyed by property name, an empty value clears the property.
+func NewEditSnapshotNoteDialog(
+	application *tview.Application,
+	snapshot *data.SnapshotBrowserEntry,
+	properties []string,
+	asyncWork func(d *InputDialog, values InputDialogValues) error,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	var fields []*InputDialogField
+	for _, property := range properties {
+		value, _ := snapshot.Snapshot.GetUserProperty(property)
+		label := property
+		if snapshot.Snapshot.IsUserPropertyInherited(property) {
+			// setting the field overrides the inherited value for this snapshot only
+			label = fmt.Sprintf("%s (%s)", property, snapshot.Snapshot.GetUserPropertySource(property))
+		}
+		fields = append(fields, &InputDialogField{
+			Id:    property,
+			Label: label,
+			Value: value,
+		})
+	}
+
+	return NewInputDialog(
+		application,
+		string(EditSnapshotNoteDialogPage),
+		" 📝 Edit Note ",
+		fmt.Sprintf("Edit the user properties of '%s'. Leave a field empty to remove the property.", snapshot.Snapshot.Name),
+		fields,
+		asyncWork,
+		onComplete,
+	).SetValidator(func(values InputDialogValues) error {
+		for _, property := range properties {
+			if err := zfs.ValidateUserPropertyValue(property, values[property]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetChangedUserProperties returns the user properties whose value in values differs from the
+// current value of the snapshot. Removed properties are contained with an empty value.
+func GetChangedUserProperties(snapshot *zfs.Snapshot, values InputDialogValues) map[string]string {
+	result := map[string]string{}
+	for property, value := range values {
+		current, _ := snapshot.GetUserProperty(property)
+		if value != current {
+			result[property] = value
+		}
+	}
+	return result
+}
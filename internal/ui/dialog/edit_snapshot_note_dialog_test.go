@@ -0,0 +1,46 @@
+package dialog
+
+import (
+	"testing"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditSnapshotNoteDialog_PrefillsValues(t *testing.T) {
+	entry := &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{
+			Name:       "snap1",
+			Properties: zfs.SnapshotProperties{User: map[string]string{"zfh:note": "pre-deploy"}},
+		},
+	}
+
+	d := NewEditSnapshotNoteDialog(tview.NewApplication(), entry, []string{"zfh:note", "com.sun:auto-snapshot"}, nil, nil)
+
+	assert.Equal(t, InputDialogValues{"zfh:note": "pre-deploy", "com.sun:auto-snapshot": ""}, d.GetValues())
+}
+
+func TestGetChangedUserProperties(t *testing.T) {
+	snapshot := &zfs.Snapshot{
+		Properties: zfs.SnapshotProperties{User: map[string]string{
+			"zfh:note":              "pre-deploy",
+			"com.sun:auto-snapshot": "false",
+			"zfh:ticket":            "OPS-1",
+		}},
+	}
+
+	changed := GetChangedUserProperties(snapshot, InputDialogValues{
+		"zfh:note":              "post-deploy",
+		"com.sun:auto-snapshot": "false",
+		"zfh:ticket":            "",
+		"zfh:owner":             "markus",
+	})
+
+	assert.Equal(t, map[string]string{
+		"zfh:note":   "post-deploy",
+		"zfh:ticket": "",
+		"zfh:owner":  "markus",
+	}, changed)
+}
@@ -1,6 +1,8 @@
 package dialog
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"slices"
@@ -17,6 +19,7 @@ import (
 	uiutil "zfs-file-history/internal/ui/util"
 	"zfs-file-history/internal/zfs"
 
+	"github.com/dustin/go-humanize"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"golang.org/x/term"
@@ -27,6 +30,9 @@ const (
 	HistoryMainPage        uiutil.Page = "history-main"
 	HistoryLoadingPage     uiutil.Page = "history-loading"
 	DevNull                            = "/dev/null"
+
+	// historyProgressInterval limits how often the progress of the history scan is shown
+	historyProgressInterval = 100 * time.Millisecond
 )
 
 type diffMode int
@@ -60,6 +66,13 @@ type FileHistoryOverlay struct {
 	copyShortcutLabel    string
 	rightLayoutContainer *uiutil.LoadingContainer
 	loadingView          *uiutil.LoadingView
+
+	// cancelScan stops the history scan, e.g. when the overlay is closed
+	cancelScan       context.CancelFunc
+	historyComplete  bool
+	scanDone         int
+	scanTotal        int
+	skippedSnapshots int
 }
 
 var (
@@ -154,6 +167,7 @@ func (o *FileHistoryOverlay) GetActionChannel() <-chan DialogActionId {
 }
 
 func (o *FileHistoryOverlay) Close() {
+	o.cancelScan()
 	o.diffLoader.Cancel()
 	o.actionChannel <- DialogCloseActionId
 }
@@ -164,8 +178,8 @@ func (o *FileHistoryOverlay) createHistoryTable() *table.RowSelectionTable[data.
 		o.createTableCells,
 		func(entries []*data.SnapshotBrowserEntry, columnToSortBy *table.Column, inverted bool) []*data.SnapshotBrowserEntry {
 			sort.SliceStable(entries, func(i, j int) bool {
-				a := entries[i].Snapshot.GetCreationDate()
-				b := entries[j].Snapshot.GetCreationDate()
+				a := entries[i].Snapshot.Properties.CreationDate
+				b := entries[j].Snapshot.Properties.CreationDate
 				if inverted {
 					return a.Before(b)
 				}
@@ -211,7 +225,7 @@ func (o *FileHistoryOverlay) createTableCells(row int, columns []*table.Column,
 					color = theme.Colors.FileBrowser.Table.State.Equal
 				}
 			} else {
-				isOldest := len(o.historyEntries) > 0 && o.historyEntries[len(o.historyEntries)-1] == entry
+				isOldest := o.historyComplete && len(o.historyEntries) > 0 && o.historyEntries[len(o.historyEntries)-1] == entry
 				switch entry.DiffState {
 				case diff_state.Added:
 					if isOldest {
@@ -464,18 +478,69 @@ func (o *FileHistoryOverlay) renderDiffTextSync(text string) {
 
 func (o *FileHistoryOverlay) scanHistoryAsync() {
 	filePath := o.file.GetRealPath()
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancelScan = cancel
+
+	// queueUpdate applies a result of the scan, unless the overlay has been closed in the meantime
+	queueUpdate := func(f func()) {
+		o.application.QueueUpdate(func() {
+			if ctx.Err() == nil {
+				f()
+			}
+		})
+	}
 
 	go func() {
+		defer cancel()
+
 		scanner := newHistoryScanner(filePath, o.cachedEntries)
-		history, err := scanner.scan(func(msg string) {
-			o.application.QueueUpdate(func() {
-				o.loadingView.SetMessage(msg)
+		var lastProgressUpdate time.Time
+
+		// entries are added to the table in batches, since every update of its data sorts all of its entries
+		var pendingEntries []*data.SnapshotBrowserEntry
+		var lastEntriesUpdate time.Time
+		flushEntries := func(force bool) {
+			if len(pendingEntries) == 0 || (!force && time.Since(lastEntriesUpdate) < historyProgressInterval) {
+				return
+			}
+			entries := pendingEntries
+			pendingEntries = nil
+			lastEntriesUpdate = time.Now()
+			queueUpdate(func() {
+				o.addHistoryEntries(entries)
 			})
+		}
+
+		err := scanner.scan(ctx, historyScanListener{
+			onStatus: func(msg string) {
+				queueUpdate(func() {
+					o.loadingView.SetMessage(msg)
+				})
+			},
+			onProgress: func(done int, total int) {
+				flushEntries(false)
+				// avoid flooding the UI with updates when the file is looked up in thousands of snapshots
+				if done < total && time.Since(lastProgressUpdate) < historyProgressInterval {
+					return
+				}
+				lastProgressUpdate = time.Now()
+				queueUpdate(func() {
+					o.scanDone, o.scanTotal = done, total
+					o.updateScanProgress()
+				})
+			},
+			onEntry: func(entry *data.SnapshotBrowserEntry) {
+				pendingEntries = append(pendingEntries, entry)
+				flushEntries(false)
+			},
 		})
+		flushEntries(true)
 
-		if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		} else if err != nil {
 			logging.Error("Failed to scan history: %s", err.Error())
-			o.application.QueueUpdate(func() {
+			queueUpdate(func() {
 				o.loadingView.Stop()
 				o.pages.HidePage(string(HistoryLoadingPage))
 				o.pages.ShowPage(string(HistoryMainPage))
@@ -485,13 +550,17 @@ func (o *FileHistoryOverlay) scanHistoryAsync() {
 			return
 		}
 
-		o.application.QueueUpdate(func() {
-			o.historyEntries = history
-			o.tableContainer.SetData(history)
-			if len(history) > 0 {
-				o.tableContainer.SelectFirstIfExists()
-				o.currentSelection = history[0]
-				o.updateDiff()
+		queueUpdate(func() {
+			o.skippedSnapshots = len(scanner.skippedSnapshots)
+			o.historyComplete = true
+			o.updateScanProgress()
+			// the oldest entry is only known now, it is shown as the initial version
+			o.tableContainer.SetData(o.historyEntries)
+			if len(o.historyEntries) > 0 {
+				if o.currentDiffMode == diffModePredecessor {
+					// the predecessor of the oldest entry found so far may have been unknown
+					o.updateDiff()
+				}
 			} else {
 				o.loadingView.Stop()
 				o.pages.HidePage(string(HistoryLoadingPage))
@@ -504,6 +573,40 @@ func (o *FileHistoryOverlay) scanHistoryAsync() {
 	}()
 }
 
+// addHistoryEntries appends entries found by the history scan, which reports them newest first,
+// so the selection is not moved by the entries added below it
+func (o *FileHistoryOverlay) addHistoryEntries(entries []*data.SnapshotBrowserEntry) {
+	isFirst := len(o.historyEntries) == 0
+	o.historyEntries = append(o.historyEntries, entries...)
+	o.tableContainer.SetData(o.historyEntries)
+	if isFirst && len(o.historyEntries) > 0 {
+		o.tableContainer.SelectFirstIfExists()
+		o.currentSelection = o.historyEntries[0]
+		o.updateDiff()
+	}
+}
+
+// updateScanProgress shows the progress of the history scan in the loading view and the table title
+func (o *FileHistoryOverlay) updateScanProgress() {
+	progress := fmt.Sprintf("%s/%s snapshots", humanize.Comma(int64(o.scanDone)), humanize.Comma(int64(o.scanTotal)))
+	if !o.historyComplete {
+		o.loadingView.SetMessage(fmt.Sprintf("Scanning snapshot history for changes... %s", progress))
+	}
+
+	var details []string
+	if !o.historyComplete {
+		details = append(details, progress)
+	}
+	if o.skippedSnapshots > 0 {
+		details = append(details, fmt.Sprintf("⚠ %d skipped, not recursive", o.skippedSnapshots))
+	}
+	if len(details) > 0 {
+		o.tableContainer.SetTitle(fmt.Sprintf(" Snapshots (%s) ", strings.Join(details, ", ")))
+	} else {
+		o.tableContainer.SetTitle(" Snapshots ")
+	}
+}
+
 func presenceStr(exists bool) string {
 	if exists {
 		return "Exists"
@@ -1,13 +1,15 @@
 package dialog
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"slices"
-	"sync"
+	"strings"
 	"time"
 	"zfs-file-history/internal/data"
 	"zfs-file-history/internal/data/diff_state"
+	"zfs-file-history/internal/index"
 	"zfs-file-history/internal/logging"
 	"zfs-file-history/internal/zfs"
 )
@@ -21,8 +23,9 @@ type fileMeta struct {
 }
 
 type prefetchResult struct {
-	snapPath string
-	meta     fileMeta
+	// index of the snapshot
+	index int
+	meta  fileMeta
 }
 
 type historyScanner struct {
@@ -31,6 +34,9 @@ type historyScanner struct {
 	metaCache         map[string]fileMeta
 	workingCopyExists bool
 	workingCopyStat   os.FileInfo
+	// skippedSnapshots contains the names of cached snapshots which have no counterpart
+	// on the child dataset containing the file
+	skippedSnapshots []string
 }
 
 func newHistoryScanner(filePath string, cachedEntries []*data.SnapshotBrowserEntry) *historyScanner {
@@ -41,130 +47,189 @@ func newHistoryScanner(filePath string, cachedEntries []*data.SnapshotBrowserEnt
 	}
 }
 
-func (s *historyScanner) scan(loadingMsgFunc func(string)) ([]*data.SnapshotBrowserEntry, error) {
+// historyScanListener receives the results of a history scan while it is running, all functions may be nil
+type historyScanListener struct {
+	// onStatus is called with a message describing the current phase of the scan
+	onStatus func(msg string)
+	// onProgress is called whenever the file has been looked up within another snapshot
+	onProgress func(done int, total int)
+	// onEntry is called for every snapshot in which the file has changed, newest first
+	onEntry func(entry *data.SnapshotBrowserEntry)
+}
+
+// scan determines the snapshots in which the file has changed compared to their predecessor and reports them
+// to the listener as soon as they are known, newest first. It returns ctx.Err() if the scan has been cancelled.
+func (s *historyScanner) scan(ctx context.Context, listener historyScanListener) error {
 	ds, err := zfs.FindHostDataset(s.filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find host dataset: %w", err)
+		return fmt.Errorf("failed to find host dataset: %w", err)
 	}
 
 	var snapshots []*zfs.Snapshot
-	if len(s.cachedEntries) > 0 && s.cachedEntries[0].Snapshot != nil && s.cachedEntries[0].Snapshot.ParentDataset != nil && s.cachedEntries[0].Snapshot.ParentDataset.Path == ds.Path {
+	var cachedDatasetPath string
+	if len(s.cachedEntries) > 0 && s.cachedEntries[0].Snapshot != nil && s.cachedEntries[0].Snapshot.ParentDataset != nil {
+		cachedDatasetPath = s.cachedEntries[0].Snapshot.ParentDataset.Path
+	}
+
+	if cachedDatasetPath == ds.Path {
 		for _, entry := range s.cachedEntries {
 			if entry != nil && entry.Snapshot != nil {
 				snapshots = append(snapshots, entry.Snapshot)
 			}
 		}
+	} else if cachedDatasetPath != "" && strings.HasPrefix(ds.Path, cachedDatasetPath+"/") {
+		// The file lives in a child dataset of the cached snapshots,
+		// so use the same-named snapshots of the child dataset.
+		for _, entry := range s.cachedEntries {
+			if entry == nil || entry.Snapshot == nil {
+				continue
+			}
+			childSnapshot, err := entry.Snapshot.ForDataset(ds)
+			if zfs.IsMissingChildSnapshot(err) {
+				s.skippedSnapshots = append(s.skippedSnapshots, entry.Snapshot.Name)
+				continue
+			} else if err != nil {
+				return err
+			}
+			snapshots = append(snapshots, childSnapshot)
+		}
+		if len(s.skippedSnapshots) > 0 {
+			logging.Warning("%d snapshot(s) have no counterpart on child dataset %s", len(s.skippedSnapshots), ds.Path)
+		}
 	} else {
-		var err error
-		snapshots, err = ds.GetSnapshots()
+		var warnings []error
+		snapshots, warnings, err = ds.GetSnapshotsWithReplicas()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get snapshots for dataset %s: %w", ds.Path, err)
+			return fmt.Errorf("failed to get snapshots for dataset %s: %w", ds.Path, err)
+		}
+		for _, warning := range warnings {
+			logging.Warning("Skipped replica snapshots: %s", warning.Error())
 		}
 	}
 
-	if loadingMsgFunc != nil {
-		loadingMsgFunc("Scanning snapshot history for changes...")
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if listener.onStatus != nil {
+		listener.onStatus("Scanning snapshot history for changes...")
 	}
 
 	slices.SortFunc(snapshots, func(a, b *zfs.Snapshot) int {
-		return a.GetCreationDate().Compare(b.GetCreationDate())
+		return a.Properties.CreationDate.Compare(b.Properties.CreationDate)
 	})
 
 	workingCopyStat, workingCopyErr := os.Lstat(s.filePath)
 	s.workingCopyExists = workingCopyErr == nil
 	s.workingCopyStat = workingCopyStat
 
-	s.prefetchStats(snapshots)
-
-	var history []*data.SnapshotBrowserEntry
-	var prev *zfs.Snapshot = nil
+	return s.scanSnapshots(ctx, snapshots, listener)
+}
 
-	for _, snap := range snapshots {
-		state, err := s.determineDiffStateBetween(snap, prev)
-		if err != nil {
-			logging.Error("Failed to determine diff state between snapshots: %s", err.Error())
-			state = diff_state.Unknown
+// scanSnapshots stats the file within the given snapshots (sorted oldest first) concurrently, newest first,
+// and reports each snapshot to the listener as soon as the file is known within it and its predecessor
+func (s *historyScanner) scanSnapshots(ctx context.Context, snapshots []*zfs.Snapshot, listener historyScanListener) error {
+	defer func() {
+		if err := zfs.FlushMetadataCacheThrottled(); err != nil {
+			logging.Warning("Could not write metadata cache: %s", err.Error())
 		}
+	}()
 
-		workingCopyState, err := s.determineDiffStateAgainstWorkingCopy(snap)
-		if err != nil {
-			logging.Error("Failed to determine diff state against working copy: %s", err.Error())
-			workingCopyState = diff_state.Unknown
+	total := len(snapshots)
+	if listener.onProgress != nil {
+		listener.onProgress(0, total)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	results := s.prefetchStats(ctx, snapshots)
+
+	// next is the index of the newest snapshot which has not been reported yet,
+	// it can be reported as soon as the file is known within its predecessor
+	next := total - 1
+	known := make([]bool, total)
+	for done := 0; done < total; done++ {
+		var res prefetchResult
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res = <-results:
+		}
+		s.metaCache[snapshots[res.index].GetSnapshotPath(s.filePath)] = res.meta
+		known[res.index] = true
+		if listener.onProgress != nil {
+			listener.onProgress(done+1, total)
 		}
 
-		if state != diff_state.Equal && state != diff_state.Unknown {
-			history = append(history, &data.SnapshotBrowserEntry{
-				Snapshot:             snap,
-				DiffState:            state,
-				WorkingCopyDiffState: workingCopyState,
-				IsLoading:            false,
-			})
-			prev = snap
-		} else if state == diff_state.Equal {
-			prev = snap
+		for next >= 0 && known[next] && (next == 0 || known[next-1]) {
+			var prev *zfs.Snapshot
+			if next > 0 {
+				prev = snapshots[next-1]
+			}
+			if entry := s.createHistoryEntry(snapshots[next], prev); entry != nil && listener.onEntry != nil {
+				listener.onEntry(entry)
+			}
+			next--
 		}
 	}
-
-	slices.Reverse(history)
-	return history, nil
+	return nil
 }
 
-func (s *historyScanner) prefetchStats(snapshots []*zfs.Snapshot) {
-	var pathsToStat []string
-	for _, snap := range snapshots {
-		snapPath := snap.GetSnapshotPath(s.filePath)
-		pathsToStat = append(pathsToStat, snapPath)
+// createHistoryEntry returns the entry of the snapshot, or nil if the file has not changed compared to prev
+func (s *historyScanner) createHistoryEntry(snap, prev *zfs.Snapshot) *data.SnapshotBrowserEntry {
+	state, err := s.determineDiffStateBetween(snap, prev)
+	if err != nil {
+		logging.Error("Failed to determine diff state between snapshots: %s", err.Error())
+		return nil
+	}
+	if state == diff_state.Equal || state == diff_state.Unknown {
+		return nil
+	}
+
+	workingCopyState, err := s.determineDiffStateAgainstWorkingCopy(snap)
+	if err != nil {
+		logging.Error("Failed to determine diff state against working copy: %s", err.Error())
+		workingCopyState = diff_state.Unknown
 	}
 
-	if len(pathsToStat) == 0 {
-		return
+	return &data.SnapshotBrowserEntry{
+		Snapshot:             snap,
+		DiffState:            state,
+		WorkingCopyDiffState: workingCopyState,
+		IsLoading:            false,
 	}
+}
 
-	resultsChan := make(chan prefetchResult, len(pathsToStat))
-	pathsChan := make(chan string, len(pathsToStat))
-	for _, p := range pathsToStat {
-		pathsChan <- p
+// prefetchStats stats the file within all snapshots concurrently, newest first, consulting the index and
+// the persistent metadata cache first. The workers stop as soon as the context is cancelled.
+func (s *historyScanner) prefetchStats(ctx context.Context, snapshots []*zfs.Snapshot) <-chan prefetchResult {
+	resultsChan := make(chan prefetchResult, len(snapshots))
+	indexChan := make(chan int, len(snapshots))
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		indexChan <- i
 	}
-	close(pathsChan)
+	close(indexChan)
 
 	numWorkers := 64
-	if len(pathsToStat) < numWorkers {
-		numWorkers = len(pathsToStat)
+	if len(snapshots) < numWorkers {
+		numWorkers = len(snapshots)
 	}
 
-	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
 		go func() {
-			defer wg.Done()
-			for path := range pathsChan {
-				stat, err := os.Lstat(path)
-				if os.IsNotExist(err) {
-					resultsChan <- prefetchResult{snapPath: path, meta: fileMeta{exists: false}}
-				} else if err == nil {
-					resultsChan <- prefetchResult{
-						snapPath: path,
-						meta: fileMeta{
-							exists:  true,
-							isDir:   stat.IsDir(),
-							size:    stat.Size(),
-							mode:    stat.Mode(),
-							modTime: stat.ModTime(),
-						},
-					}
-				} else {
-					resultsChan <- prefetchResult{snapPath: path, meta: fileMeta{exists: false}}
+			for position := range indexChan {
+				if ctx.Err() != nil {
+					return
 				}
+				meta, err := s.statSnapshotFile(snapshots[position])
+				if err != nil {
+					meta = zfs.FileMeta{}
+				}
+				resultsChan <- prefetchResult{index: position, meta: toFileMeta(meta)}
 			}
 		}()
 	}
-
-	wg.Wait()
-	close(resultsChan)
-
-	for res := range resultsChan {
-		s.metaCache[res.snapPath] = res.meta
-	}
+	return resultsChan
 }
 
 func (s *historyScanner) getSnapshotMeta(snap *zfs.Snapshot) (fileMeta, error) {
@@ -173,24 +238,32 @@ func (s *historyScanner) getSnapshotMeta(snap *zfs.Snapshot) (fileMeta, error) {
 		return meta, nil
 	}
 
-	stat, err := os.Lstat(snapPath)
-	if os.IsNotExist(err) {
-		meta := fileMeta{exists: false}
-		s.metaCache[snapPath] = meta
-		return meta, nil
-	} else if err != nil {
+	meta, err := s.statSnapshotFile(snap)
+	if err != nil {
 		return fileMeta{}, err
 	}
+	s.metaCache[snapPath] = toFileMeta(meta)
+	return s.metaCache[snapPath], nil
+}
+
+// statSnapshotFile returns the state of the file within the snapshot, from the index if the snapshot has been indexed
+func (s *historyScanner) statSnapshotFile(snap *zfs.Snapshot) (zfs.FileMeta, error) {
+	if idx := index.Get(); idx != nil {
+		if meta, indexed := idx.Stat(snap, s.filePath); indexed {
+			return meta, nil
+		}
+	}
+	return snap.StatFile(s.filePath)
+}
 
-	meta := fileMeta{
-		exists:  true,
-		isDir:   stat.IsDir(),
-		size:    stat.Size(),
-		mode:    stat.Mode(),
-		modTime: stat.ModTime(),
+func toFileMeta(meta zfs.FileMeta) fileMeta {
+	return fileMeta{
+		exists:  meta.Exists,
+		isDir:   meta.IsDir,
+		size:    meta.Size,
+		mode:    meta.Mode,
+		modTime: meta.ModTime,
 	}
-	s.metaCache[snapPath] = meta
-	return meta, nil
 }
 
 func (s *historyScanner) determineDiffStateBetween(snap, prev *zfs.Snapshot) (diff_state.DiffState, error) {
@@ -215,7 +288,7 @@ func (s *historyScanner) determineDiffStateBetween(snap, prev *zfs.Snapshot) (di
 		if sMeta.isDir != prevMeta.isDir ||
 			sMeta.size != prevMeta.size ||
 			sMeta.mode != prevMeta.mode ||
-			sMeta.modTime != prevMeta.modTime {
+			!sMeta.modTime.Equal(prevMeta.modTime) {
 			return diff_state.Modified, nil
 		}
 		return diff_state.Equal, nil
@@ -238,7 +311,7 @@ func (s *historyScanner) determineDiffStateAgainstWorkingCopy(snap *zfs.Snapshot
 		if sMeta.isDir != s.workingCopyStat.IsDir() ||
 			sMeta.size != s.workingCopyStat.Size() ||
 			sMeta.mode != s.workingCopyStat.Mode() ||
-			sMeta.modTime != s.workingCopyStat.ModTime() {
+			!sMeta.modTime.Equal(s.workingCopyStat.ModTime()) {
 			return diff_state.Modified, nil
 		}
 		return diff_state.Equal, nil
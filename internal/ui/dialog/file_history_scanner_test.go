@@ -1,10 +1,13 @@
 package dialog
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
+	"zfs-file-history/internal/data"
 	"zfs-file-history/internal/data/diff_state"
 	"zfs-file-history/internal/zfs"
 
@@ -276,3 +279,72 @@ func TestComputeHistoryDiffText(t *testing.T) {
 	assert.Contains(t, res, "+Hello")
 	assert.Contains(t, res, "+World")
 }
+
+func createScannerTestSnapshots(t *testing.T, contents ...string) (string, []*zfs.Snapshot) {
+	datasetPath := t.TempDir()
+	dataset := &zfs.Dataset{
+		Path:          datasetPath,
+		HiddenZfsPath: filepath.Join(datasetPath, ".zfs"),
+	}
+	filePath := filepath.Join(datasetPath, "file.txt")
+
+	var snapshots []*zfs.Snapshot
+	creation := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, content := range contents {
+		snap := &zfs.Snapshot{
+			Name:          fmt.Sprintf("snap-%d", i),
+			ParentDataset: dataset,
+			Properties:    zfs.SnapshotProperties{CreationDate: creation.Add(time.Duration(i) * time.Hour)},
+		}
+		snapshotFile := snap.GetSnapshotPath(filePath)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(snapshotFile), 0755))
+		if content != "" {
+			assert.NoError(t, os.WriteFile(snapshotFile, []byte(content), 0644))
+			assert.NoError(t, os.Chtimes(snapshotFile, creation, creation))
+		}
+		snapshots = append(snapshots, snap)
+	}
+	return filePath, snapshots
+}
+
+func TestHistoryScanner_ScanSnapshots_StreamsNewestFirst(t *testing.T) {
+	// "" means the file does not exist within the snapshot
+	filePath, snapshots := createScannerTestSnapshots(t, "", "a", "a", "ab", "", "abc")
+	s := newHistoryScanner(filePath, nil)
+
+	var names []string
+	var states []diff_state.DiffState
+	var progress []int
+	err := s.scanSnapshots(context.Background(), snapshots, historyScanListener{
+		onProgress: func(done int, total int) {
+			assert.Equal(t, len(snapshots), total)
+			progress = append(progress, done)
+		},
+		onEntry: func(entry *data.SnapshotBrowserEntry) {
+			names = append(names, entry.Snapshot.Name)
+			states = append(states, entry.DiffState)
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"snap-5", "snap-4", "snap-3", "snap-1"}, names)
+	assert.Equal(t, []diff_state.DiffState{diff_state.Added, diff_state.Deleted, diff_state.Modified, diff_state.Added}, states)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, progress)
+}
+
+func TestHistoryScanner_ScanSnapshots_Cancelled(t *testing.T) {
+	filePath, snapshots := createScannerTestSnapshots(t, "a", "ab", "abc")
+	s := newHistoryScanner(filePath, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	entries := 0
+	err := s.scanSnapshots(ctx, snapshots, historyScanListener{
+		onEntry: func(entry *data.SnapshotBrowserEntry) {
+			entries++
+		},
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, entries)
+}
@@ -41,6 +41,7 @@ func (p *HelpPage) createLayout() {
 		{Key: "→", Value: "Enters selected directory"},
 		{Key: "space", Value: "Toggle Multi-Selection"},
 		{Key: "⭾, shift+⭾", Value: "Cycles window focus"},
+		{Key: "F3", Value: "Opens the dataset tree"},
 		emptyEntry,
 		{Key: "esc", Value: "Closes any currently open dialog"},
 		{Key: "ctrl+q", Value: "Quits zfs-file-history"},
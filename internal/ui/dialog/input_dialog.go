@@ -0,0 +1,310 @@
+package dialog
+
+import (
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+	"zfs-file-history/internal/ui/shortcut_helper"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const inputDialogFieldWidth = 40
+
+type InputDialogFieldType int
+
+const (
+	InputDialogFieldTypeText InputDialogFieldType = iota
+	InputDialogFieldTypeCheckbox
+)
+
+// InputDialogField describes a single input of an InputDialog
+type InputDialogField struct {
+	Id          string
+	Type        InputDialogFieldType
+	Label       string
+	Value       string
+	Placeholder string
+	// Checked is the initial state of a InputDialogFieldTypeCheckbox field
+	Checked bool
+	// Masked hides the text of a InputDialogFieldTypeText field, e.g. for passphrases
+	Masked bool
+}
+
+// InputDialogValues holds the submitted values of an InputDialog, keyed by InputDialogField.Id.
+// Checkbox fields are represented as "true" or "false".
+type InputDialogValues map[string]string
+
+// GetBool returns the state of a checkbox field
+func (values InputDialogValues) GetBool(id string) bool {
+	return values[id] == "true"
+}
+
+type InputDialog struct {
+	application   *tview.Application
+	name          string
+	title         string
+	description   string
+	fields        []*InputDialogField
+	layout        *tview.Flex
+	actionChannel chan DialogActionId
+
+	form       *tview.Form
+	formItems  []tview.FormItem
+	statusView *tview.TextView
+	isRunning  bool
+
+	validate func(values InputDialogValues) error
+	changed  func(d *InputDialog, id string, value string)
+
+	// Handlers for exclusive async execution
+	handler    func(d *InputDialog, values InputDialogValues) error
+	onComplete func(d *InputDialog, values InputDialogValues, err error)
+}
+
+// NewInputDialog
+// handler - The background execution logic to be executed when the user confirms the input.
+// onComplete - The callback to be executed on the UI thread after the background execution completes.
+func NewInputDialog(
+	application *tview.Application,
+	name string,
+	title string,
+	description string,
+	fields []*InputDialogField,
+	handler func(d *InputDialog, values InputDialogValues) error,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	d := &InputDialog{
+		application:   application,
+		name:          name,
+		title:         title,
+		description:   description,
+		fields:        fields,
+		actionChannel: make(chan DialogActionId),
+		handler:       handler,
+		onComplete:    onComplete,
+	}
+	d.createLayout()
+	return d
+}
+
+// SetValidator sets a function which is called on the UI thread before the handler is executed.
+// If it returns an error, the error is shown within the dialog and the handler is not executed.
+func (d *InputDialog) SetValidator(validate func(values InputDialogValues) error) *InputDialog {
+	d.validate = validate
+	return d
+}
+
+// SetChangedFunc sets a function which is called on the UI thread whenever the value of a field changes.
+func (d *InputDialog) SetChangedFunc(changed func(d *InputDialog, id string, value string)) *InputDialog {
+	d.changed = changed
+	return d
+}
+
+func (d *InputDialog) onFieldChanged(id string, value string) {
+	if d.changed != nil {
+		d.changed(d, id, value)
+	}
+}
+
+func (d *InputDialog) createLayout() {
+	maxLabelWidth := 0
+	for _, field := range d.fields {
+		if l := utf8.RuneCountInString(field.Label); l > maxLabelWidth {
+			maxLabelWidth = l
+		}
+	}
+
+	formHeight := 2*len(d.fields) - 1
+	staticHeight := 1 + formHeight + 1 + 1 + 1
+
+	constraints := DialogSizeConstraints{
+		Title:             d.title,
+		Description:       d.description,
+		ExtraContentWidth: maxLabelWidth + 1 + inputDialogFieldWidth,
+		StaticHeight:      staticHeight,
+	}
+
+	dialogWidth, _ := CalculateDialogSize(constraints)
+	textLineWidth := dialogWidth - 6
+	if textLineWidth < 5 {
+		textLineWidth = 5
+	}
+	descHeight := calculateWrappedHeight(d.description, textLineWidth)
+
+	textDescriptionView := tview.NewTextView().
+		SetText(d.description).
+		SetWrap(true).
+		SetWordWrap(true)
+
+	d.form = tview.NewForm()
+	d.form.SetBorderPadding(0, 0, 0, 0)
+	d.form.SetItemPadding(1)
+	for _, field := range d.fields {
+		id := field.Id
+		var item tview.FormItem
+		switch field.Type {
+		case InputDialogFieldTypeCheckbox:
+			item = tview.NewCheckbox().
+				SetLabel(field.Label).
+				SetChecked(field.Checked).
+				SetChangedFunc(func(checked bool) {
+					d.onFieldChanged(id, strconv.FormatBool(checked))
+				})
+		default:
+			inputField := tview.NewInputField().
+				SetLabel(field.Label).
+				SetText(field.Value).
+				SetPlaceholder(field.Placeholder).
+				SetChangedFunc(func(text string) {
+					d.onFieldChanged(id, text)
+				})
+			if field.Masked {
+				inputField.SetMaskCharacter('*')
+			}
+			item = inputField
+		}
+		d.formItems = append(d.formItems, item)
+		d.form.AddFormItem(item)
+	}
+
+	d.statusView = tview.NewTextView().SetDynamicColors(false)
+	d.statusView.SetTextColor(tcell.ColorRed)
+
+	shortcutMap := shortcut_helper.NewShortcutMap(d.application)
+	shortcutEntries := []shortcut_helper.ShortcutEntry{
+		{KeyCombo: []string{"Enter"}, Name: "Confirm"},
+		{KeyCombo: []string{"⭾", "shift+⭾"}, Name: "Next/Previous Field"},
+		{KeyCombo: []string{"Esc"}, Name: "Cancel"},
+	}
+	if slices.ContainsFunc(d.fields, func(field *InputDialogField) bool {
+		return field.Type == InputDialogFieldTypeCheckbox
+	}) {
+		shortcutEntries = append(shortcutEntries, shortcut_helper.ShortcutEntry{KeyCombo: []string{"Space"}, Name: "Toggle"})
+	}
+	shortcutMap.SetEntries(shortcutEntries)
+
+	dialogContent := tview.NewFlex().SetDirection(tview.FlexRow)
+	dialogContent.AddItem(textDescriptionView, descHeight, 0, false)
+	dialogContent.AddItem(tview.NewBox(), 1, 0, false)
+	dialogContent.AddItem(d.form, formHeight, 0, true)
+	dialogContent.AddItem(d.statusView, 1, 0, false)
+	dialogContent.AddItem(tview.NewBox(), 1, 0, false)
+	dialogContent.AddItem(shortcutMap.GetLayout(), 1, 0, false)
+
+	dialog := createModal(d.title, dialogContent, constraints)
+	dialog.SetInputCapture(d.captureInput)
+	d.layout = dialog
+}
+
+func (d *InputDialog) captureInput(event *tcell.EventKey) *tcell.EventKey {
+	if d.isRunning {
+		return nil
+	}
+	switch event.Key() {
+	case tcell.KeyEscape:
+		d.Close()
+		return nil
+	case tcell.KeyEnter:
+		d.submit()
+		return nil
+	default:
+	}
+	return event
+}
+
+func (d *InputDialog) GetName() string {
+	return d.name
+}
+
+func (d *InputDialog) GetLayout() *tview.Flex {
+	return d.layout
+}
+
+func (d *InputDialog) GetActionChannel() <-chan DialogActionId {
+	return d.actionChannel
+}
+
+// GetValues returns the current values of all input fields
+func (d *InputDialog) GetValues() InputDialogValues {
+	values := InputDialogValues{}
+	for i, field := range d.fields {
+		switch item := d.formItems[i].(type) {
+		case *tview.Checkbox:
+			values[field.Id] = strconv.FormatBool(item.IsChecked())
+		case *tview.InputField:
+			values[field.Id] = item.GetText()
+		}
+	}
+	return values
+}
+
+// SetValue sets the text of the input field with the given id
+func (d *InputDialog) SetValue(id string, value string) {
+	for i, field := range d.fields {
+		if field.Id != id {
+			continue
+		}
+		if inputField, ok := d.formItems[i].(*tview.InputField); ok {
+			inputField.SetText(value)
+		}
+	}
+}
+
+func (d *InputDialog) Close() {
+	select {
+	case d.actionChannel <- DialogCloseActionId:
+	default:
+		go func() { d.actionChannel <- DialogCloseActionId }()
+	}
+}
+
+// Chain closes this dialog and mounts the next one, see SelectionDialog.Chain
+func (d *InputDialog) Chain(mountNext func()) {
+	d.Close()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		d.application.QueueUpdateDraw(mountNext)
+	}()
+}
+
+// ShowError displays the given error within the dialog, keeping it open.
+func (d *InputDialog) ShowError(err error) {
+	if err == nil {
+		d.statusView.SetText("")
+		return
+	}
+	d.statusView.SetTextColor(tcell.ColorRed)
+	d.statusView.SetText(err.Error())
+}
+
+func (d *InputDialog) submit() {
+	values := d.GetValues()
+	if d.validate != nil {
+		if err := d.validate(values); err != nil {
+			d.ShowError(err)
+			return
+		}
+	}
+
+	d.isRunning = true
+	d.statusView.SetTextColor(tcell.ColorGray)
+	d.statusView.SetText("Please wait...")
+
+	go func() {
+		var err error
+		if d.handler != nil {
+			err = d.handler(d, values)
+		}
+
+		d.application.QueueUpdateDraw(func() {
+			d.isRunning = false
+			d.statusView.SetText("")
+			if d.onComplete != nil {
+				d.onComplete(d, values, err)
+			}
+		})
+	}()
+}
@@ -0,0 +1,66 @@
+package dialog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInputDialog(t *testing.T) {
+	app := tview.NewApplication()
+	fields := []*InputDialogField{
+		{Id: "name", Label: "Name", Value: "initial"},
+		{Id: "other", Label: "Other"},
+	}
+	d := NewInputDialog(app, "test-input-dialog", "Title", "Description", fields, nil, nil)
+
+	assert.Equal(t, "test-input-dialog", d.GetName())
+	assert.NotNil(t, d.GetLayout())
+	assert.NotNil(t, d.GetActionChannel())
+	assert.Equal(t, InputDialogValues{"name": "initial", "other": ""}, d.GetValues())
+}
+
+func TestInputDialog_ValidatorPreventsSubmit(t *testing.T) {
+	app := tview.NewApplication()
+	fields := []*InputDialogField{
+		{Id: "name", Label: "Name"},
+	}
+	handlerCalled := false
+	d := NewInputDialog(app, "test-input-dialog", "Title", "", fields, func(d *InputDialog, values InputDialogValues) error {
+		handlerCalled = true
+		return nil
+	}, nil).SetValidator(func(values InputDialogValues) error {
+		return errors.New("name is required")
+	})
+
+	res := d.captureInput(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	assert.Nil(t, res)
+	assert.False(t, d.isRunning)
+	assert.False(t, handlerCalled)
+	assert.Equal(t, "name is required", d.statusView.GetText(true))
+}
+
+func TestInputDialog_CheckboxAndChangedFunc(t *testing.T) {
+	app := tview.NewApplication()
+	fields := []*InputDialogField{
+		{Id: "name", Label: "Name"},
+		{Id: "flag", Type: InputDialogFieldTypeCheckbox, Label: "Flag", Checked: true},
+	}
+	var changedIds []string
+	d := NewInputDialog(app, "test-input-dialog", "Title", "", fields, nil, nil).
+		SetChangedFunc(func(d *InputDialog, id string, value string) {
+			changedIds = append(changedIds, id)
+		})
+
+	d.SetValue("name", "value")
+	d.SetValue("flag", "ignored")
+
+	values := d.GetValues()
+	assert.Equal(t, "value", values["name"])
+	assert.True(t, values.GetBool("flag"))
+	assert.False(t, values.GetBool("name"))
+	assert.Equal(t, []string{"name"}, changedIds)
+}
@@ -0,0 +1,62 @@
+package dialog
+
+import (
+	"errors"
+	"fmt"
+	"zfs-file-history/internal/ui/util"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	LoadKeyDialogPage util.Page = "LoadKeyDialog"
+
+	LoadKeyDialogPassphraseFieldId = "passphrase"
+)
+
+// NewLoadKeyDialog prompts for the passphrase of an encrypted dataset.
+// requiresPrompt - whether the key can only be entered by the user, otherwise an empty passphrase
+// loads the key from keyLocation
+func NewLoadKeyDialog(
+	application *tview.Application,
+	datasetName string,
+	keyLocation string,
+	requiresPrompt bool,
+	asyncWork func(d *InputDialog, values InputDialogValues) error,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	description := fmt.Sprintf("Enter the passphrase to load the key of '%s'.", datasetName)
+	placeholder := ""
+	if !requiresPrompt {
+		description = fmt.Sprintf("Enter the passphrase to load the key of '%s', or leave it empty to load the key from '%s'.", datasetName, keyLocation)
+		placeholder = "use keylocation"
+	}
+
+	fields := []*InputDialogField{
+		{
+			Id:          LoadKeyDialogPassphraseFieldId,
+			Label:       "Passphrase",
+			Placeholder: placeholder,
+			Masked:      true,
+		},
+	}
+
+	return NewInputDialog(
+		application,
+		string(LoadKeyDialogPage),
+		" 🔑 Load Key ",
+		description,
+		fields,
+		asyncWork,
+		onComplete,
+	).SetValidator(func(values InputDialogValues) error {
+		return validateLoadKeyInput(values[LoadKeyDialogPassphraseFieldId], requiresPrompt)
+	})
+}
+
+func validateLoadKeyInput(passphrase string, requiresPrompt bool) error {
+	if requiresPrompt && passphrase == "" {
+		return errors.New("passphrase must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLoadKeyInput(t *testing.T) {
+	assert.NoError(t, validateLoadKeyInput("secret", true))
+	assert.NoError(t, validateLoadKeyInput("secret", false))
+	assert.NoError(t, validateLoadKeyInput("", false))
+	assert.ErrorContains(t, validateLoadKeyInput("", true), "must not be empty")
+}
+
+func TestNewLoadKeyDialog_MasksPassphrase(t *testing.T) {
+	app := tview.NewApplication()
+	d := NewLoadKeyDialog(app, "tank/secure", "prompt", true, nil, nil)
+
+	assert.Equal(t, string(LoadKeyDialogPage), d.GetName())
+	d.SetValue(LoadKeyDialogPassphraseFieldId, "secret")
+	assert.Equal(t, "secret", d.GetValues()[LoadKeyDialogPassphraseFieldId])
+	assert.True(t, d.fields[0].Masked)
+}
@@ -0,0 +1,62 @@
+package dialog
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	RenameSnapshotDialogPage util.Page = "RenameSnapshotDialog"
+
+	RenameSnapshotDialogNameFieldId = "name"
+)
+
+// NewRenameSnapshotDialog prompts for a new name of the given snapshot.
+// existingNames - names of all snapshots of the same dataset, used to detect conflicts
+func NewRenameSnapshotDialog(
+	application *tview.Application,
+	snapshot *data.SnapshotBrowserEntry,
+	existingNames []string,
+	asyncWork func(d *InputDialog, values InputDialogValues) error,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	fields := []*InputDialogField{
+		{
+			Id:    RenameSnapshotDialogNameFieldId,
+			Label: "Name",
+			Value: snapshot.Snapshot.Name,
+		},
+	}
+
+	return NewInputDialog(
+		application,
+		string(RenameSnapshotDialogPage),
+		" ✏️ Rename Snapshot ",
+		fmt.Sprintf("Enter a new name for '%s'.", snapshot.Snapshot.FullName),
+		fields,
+		asyncWork,
+		onComplete,
+	).SetValidator(func(values InputDialogValues) error {
+		return validateSnapshotRename(snapshot.Snapshot.Name, strings.TrimSpace(values[RenameSnapshotDialogNameFieldId]), existingNames)
+	})
+}
+
+func validateSnapshotRename(currentName string, newName string, existingNames []string) error {
+	if err := zfs.ValidateSnapshotName(newName); err != nil {
+		return err
+	}
+	if newName == currentName {
+		return errors.New("new name must differ from the current name")
+	}
+	if slices.Contains(existingNames, newName) {
+		return fmt.Errorf("snapshot '%s' already exists", newName)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSnapshotRename(t *testing.T) {
+	existing := []string{"zfh-2024-01-02-150405", "before-upgrade-pg16"}
+
+	assert.NoError(t, validateSnapshotRename("zfh-2024-01-02-150405", "after-upgrade-pg16", existing))
+	assert.ErrorContains(t, validateSnapshotRename("zfh-2024-01-02-150405", "zfh-2024-01-02-150405", existing), "must differ")
+	assert.ErrorContains(t, validateSnapshotRename("zfh-2024-01-02-150405", "before-upgrade-pg16", existing), "already exists")
+	assert.ErrorContains(t, validateSnapshotRename("zfh-2024-01-02-150405", "pool/ds@snap", existing), "invalid character")
+	assert.Error(t, validateSnapshotRename("zfh-2024-01-02-150405", "", existing))
+}
@@ -9,7 +9,9 @@ import (
 	"zfs-file-history/internal/logging"
 	"zfs-file-history/internal/ui/theme"
 	uiutil "zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
 
+	"github.com/dustin/go-humanize"
 	"github.com/gdamore/tcell/v2"
 	"github.com/navidys/tvxwidgets"
 	"github.com/rivo/tview"
@@ -19,13 +21,20 @@ const (
 	RestoreFileProgress uiutil.Page = "RestoreFileProgressDialog"
 )
 
+// restoreBandwidthSteps are the bandwidth limits in MB/s which can be selected while a restore is running
+var restoreBandwidthSteps = []int64{1, 5, 10, 25, 50, 100, 250, 500, 1000}
+
 type RestoreFileProgressDialog struct {
 	application   *tview.Application
 	fileSelection *data.FileBrowserEntry
 	actionChannel chan DialogActionId
 
+	// engine restores the files, its settings can be adjusted while it is running
+	engine *zfs.RestoreEngine
+
 	layout              *tview.Flex
 	descriptionTextView *tview.TextView
+	settingsTextView    *tview.TextView
 	actionsHelpTextView *tview.TextView
 	actionPages         *tview.Pages
 	closeTable          *tview.Table
@@ -41,6 +50,7 @@ func NewRestoreFileProgressDialog(application *tview.Application, fileSelection
 		application:   application,
 		fileSelection: fileSelection,
 		actionChannel: make(chan DialogActionId),
+		engine:        zfs.NewRestoreEngine(zfs.GetRestoreSettings()),
 	}
 
 	dialog.createLayout()
@@ -81,6 +91,10 @@ func (d *RestoreFileProgressDialog) createLayout() {
 		AddItem(spinner, 2, 0, false).
 		AddItem(descriptionTextView, 0, 1, false)
 
+	settingsTextView := tview.NewTextView().SetDynamicColors(true)
+	d.settingsTextView = settingsTextView
+	d.updateSettingsText()
+
 	abortTextView := uiutil.CreateAttentionTextView("Press 'q' to abort")
 	d.actionsHelpTextView = abortTextView
 
@@ -108,6 +122,7 @@ func (d *RestoreFileProgressDialog) createLayout() {
 
 	progressLayout := tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(descriptionLayout, 0, 1, false).
+		AddItem(settingsTextView, 1, 0, false).
 		AddItem(progress, 3, 0, false).
 		AddItem(actionPages, 1, 0, false)
 	progressLayout.SetBorderPadding(0, 0, 1, 1)
@@ -115,7 +130,7 @@ func (d *RestoreFileProgressDialog) createLayout() {
 	dialog := createModal(dialogTitle, progressLayout, DialogSizeConstraints{
 		Title:        dialogTitle,
 		Description:  text,
-		StaticHeight: 4, // 3 for progress bar, 1 for actionPages
+		StaticHeight: 5, // 1 for settings, 3 for progress bar, 1 for actionPages
 	})
 	dialog.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEscape {
@@ -126,11 +141,79 @@ func (d *RestoreFileProgressDialog) createLayout() {
 			d.Close()
 			return nil
 		}
+		if d.isRunning && d.adjustSettings(event.Rune()) {
+			return nil
+		}
 		return event
 	})
 	d.layout = dialog
 }
 
+// adjustSettings changes the settings of the running restore according to the given key,
+// returns false if the key is not bound to a setting
+func (d *RestoreFileProgressDialog) adjustSettings(key rune) bool {
+	settings := d.engine.Settings()
+	switch key {
+	case '+':
+		settings.Workers++
+	case '-':
+		settings.Workers--
+	case '>':
+		settings.BandwidthLimit = nextBandwidthLimit(settings.BandwidthLimit, true)
+	case '<':
+		settings.BandwidthLimit = nextBandwidthLimit(settings.BandwidthLimit, false)
+	case 'i':
+		settings.IdleIoPriority = !settings.IdleIoPriority
+	default:
+		return false
+	}
+	d.engine.SetSettings(settings)
+	d.updateSettingsText()
+	return true
+}
+
+func (d *RestoreFileProgressDialog) updateSettingsText() {
+	d.settingsTextView.SetText(formatRestoreSettings(d.engine.Settings()))
+}
+
+// formatRestoreSettings describes the settings along with the keys to adjust them
+func formatRestoreSettings(settings zfs.RestoreSettings) string {
+	bandwidth := "unlimited"
+	if settings.BandwidthLimit > 0 {
+		bandwidth = humanize.Bytes(uint64(settings.BandwidthLimit)) + "/s"
+	}
+	idle := "off"
+	if settings.IdleIoPriority {
+		idle = "on"
+	}
+	return fmt.Sprintf("Workers: %d [gray](+/-)[-]  Bandwidth: %s [gray](</>)[-]  Idle I/O: %s [gray](i)[-]",
+		settings.Workers, bandwidth, idle)
+}
+
+// nextBandwidthLimit returns the next higher or lower step of restoreBandwidthSteps in bytes per second.
+// Unlimited (0) is above the highest step.
+func nextBandwidthLimit(current int64, higher bool) int64 {
+	if higher {
+		if current <= 0 {
+			return 0
+		}
+		for _, step := range restoreBandwidthSteps {
+			if step*humanize.MByte > current {
+				return step * humanize.MByte
+			}
+		}
+		return 0
+	}
+
+	for i := len(restoreBandwidthSteps) - 1; i >= 0; i-- {
+		step := restoreBandwidthSteps[i] * humanize.MByte
+		if current <= 0 || step < current {
+			return step
+		}
+	}
+	return restoreBandwidthSteps[0] * humanize.MByte
+}
+
 func (d *RestoreFileProgressDialog) GetName() string {
 	return string(RestoreFileProgress)
 }
@@ -158,6 +241,7 @@ func (d *RestoreFileProgressDialog) runAction(recursive bool) {
 		srcFilePath := snapshotFile.Path
 		dstFilePath := snapshotFile.OriginalPath
 
+		var report zfs.RestoreReport
 		if srcFilePath == "" {
 			// The file is absent in the snapshot.
 			// Restoring it means deleting the working copy!
@@ -167,24 +251,26 @@ func (d *RestoreFileProgressDialog) runAction(recursive bool) {
 				return
 			}
 		} else if recursive {
-			// TODO: this loops two times currently to ensure folder modtime properties are correct.
-			//  See implementation for what we need to do to fix this
-			for i := 0; i < 2; i++ {
-				err := snapshot.RestoreRecursive(srcFilePath)
-				d.handleError(err)
-				if err != nil {
-					return
-				}
+			var err error
+			report, err = d.engine.RestoreRecursive(snapshot, srcFilePath)
+			d.handleError(err)
+			if err != nil {
+				return
+			}
+			if len(report.Warnings) > 0 {
+				d.handleDoneWithWarnings(report)
+				return
 			}
 		} else {
-			err := snapshot.Restore(srcFilePath)
+			var err error
+			report, err = d.engine.Restore(snapshot, srcFilePath)
 			d.handleError(err)
 			if err != nil {
 				return
 			}
 		}
 
-		d.handleDone()
+		d.handleDone(report)
 	}()
 
 	d.progressValue = 0
@@ -224,9 +310,28 @@ func (d *RestoreFileProgressDialog) handleError(err error) {
 	}
 }
 
-func (d *RestoreFileProgressDialog) handleDone() {
+// handleDoneWithWarnings finishes the restore, listing the child datasets which have been skipped
+func (d *RestoreFileProgressDialog) handleDoneWithWarnings(report zfs.RestoreReport) {
+	d.isRunning = false
+	text := fmt.Sprintf("%s\nRestored with %d warning(s):", report.Summary(), len(report.Warnings))
+	for _, warning := range report.Warnings {
+		text += "\n⚠ " + warning.Error()
+	}
+	d.application.QueueUpdateDraw(func() {
+		d.descriptionTextView.SetText(text).SetTextColor(tcell.ColorYellow)
+		d.progress.SetValue(d.progress.GetMaxValue())
+		d.progress.SetTitle(theme.CreateTitleText("Done (with warnings)"))
+		d.progress.SetTitleColor(tcell.ColorYellow)
+		d.actionPages.ShowPage("finished")
+		d.application.SetFocus(d.closeTable)
+	})
+}
+
+// handleDone finishes the restore, showing how the files have been copied
+func (d *RestoreFileProgressDialog) handleDone(report zfs.RestoreReport) {
 	d.isRunning = false
 	d.application.QueueUpdateDraw(func() {
+		d.descriptionTextView.SetText(report.Summary())
 		finishedValue := d.progress.GetMaxValue()
 		d.progress.SetValue(finishedValue)
 		d.progress.SetTitle(theme.CreateTitleText("Done!"))
@@ -8,6 +8,7 @@ import (
 	"zfs-file-history/internal/data"
 	"zfs-file-history/internal/zfs"
 
+	"github.com/dustin/go-humanize"
 	"github.com/rivo/tview"
 	"github.com/stretchr/testify/assert"
 )
@@ -75,3 +76,42 @@ func TestRestoreFileProgressDialog_AbsentFile(t *testing.T) {
 	// Assert the working copy was deleted
 	assert.NoFileExists(t, tempFile)
 }
+
+func TestNextBandwidthLimit(t *testing.T) {
+	mb := int64(humanize.MByte)
+
+	assert.Equal(t, 10*mb, nextBandwidthLimit(5*mb, true))
+	assert.Equal(t, 10*mb, nextBandwidthLimit(7*mb, true))
+	assert.Equal(t, int64(0), nextBandwidthLimit(1000*mb, true))
+	assert.Equal(t, int64(0), nextBandwidthLimit(0, true))
+
+	assert.Equal(t, 1000*mb, nextBandwidthLimit(0, false))
+	assert.Equal(t, 5*mb, nextBandwidthLimit(7*mb, false))
+	assert.Equal(t, 1*mb, nextBandwidthLimit(1*mb, false))
+}
+
+func TestFormatRestoreSettings(t *testing.T) {
+	assert.Equal(t,
+		"Workers: 4 [gray](+/-)[-]  Bandwidth: unlimited [gray](</>)[-]  Idle I/O: off [gray](i)[-]",
+		formatRestoreSettings(zfs.RestoreSettings{Workers: 4}),
+	)
+	assert.Equal(t,
+		"Workers: 1 [gray](+/-)[-]  Bandwidth: 50 MB/s [gray](</>)[-]  Idle I/O: on [gray](i)[-]",
+		formatRestoreSettings(zfs.RestoreSettings{Workers: 1, BandwidthLimit: 50 * humanize.MByte, IdleIoPriority: true}),
+	)
+}
+
+func TestRestoreFileProgressDialog_AdjustSettings(t *testing.T) {
+	d := &RestoreFileProgressDialog{
+		engine:           zfs.NewRestoreEngine(zfs.RestoreSettings{Workers: 4}),
+		settingsTextView: tview.NewTextView(),
+	}
+
+	assert.True(t, d.adjustSettings('+'))
+	assert.True(t, d.adjustSettings('<'))
+	assert.True(t, d.adjustSettings('i'))
+	assert.False(t, d.adjustSettings('x'))
+
+	assert.Equal(t, zfs.RestoreSettings{Workers: 5, BandwidthLimit: 1000 * humanize.MByte, IdleIoPriority: true}, d.engine.Settings())
+	assert.Contains(t, d.settingsTextView.GetText(false), "Workers: 5")
+}
@@ -0,0 +1,118 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/data/diff_state"
+	"zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	RollbackDatasetDialogPage util.Page = "RollbackDatasetDialog"
+
+	RollbackDatasetDialogConfirmFieldId = "confirm"
+
+	// rollbackDialogMaxListedEntries limits the amount of entries listed per category
+	rollbackDialogMaxListedEntries = 5
+)
+
+// NewRollbackDatasetDialog shows the impact of rolling back a dataset to the given snapshot
+// and requires the user to type the name of the dataset to confirm.
+func NewRollbackDatasetDialog(
+	application *tview.Application,
+	snapshot *data.SnapshotBrowserEntry,
+	datasetName string,
+	impact *zfs.RollbackImpact,
+	changes *zfs.WorkingCopyChanges,
+	asyncWork func(d *InputDialog, values InputDialogValues) error,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	fields := []*InputDialogField{
+		{
+			Id:          RollbackDatasetDialogConfirmFieldId,
+			Label:       "Confirm",
+			Placeholder: datasetName,
+		},
+	}
+
+	return NewInputDialog(
+		application,
+		string(RollbackDatasetDialogPage),
+		" ⏪ Rollback Dataset ",
+		describeRollbackImpact(datasetName, snapshot.Snapshot.Name, impact, changes),
+		fields,
+		asyncWork,
+		onComplete,
+	).SetValidator(func(values InputDialogValues) error {
+		if strings.TrimSpace(values[RollbackDatasetDialogConfirmFieldId]) != datasetName {
+			return fmt.Errorf("type '%s' to confirm", datasetName)
+		}
+		return nil
+	})
+}
+
+func describeRollbackImpact(datasetName string, snapshotName string, impact *zfs.RollbackImpact, changes *zfs.WorkingCopyChanges) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Rollback '%s' to '%s'.\n", datasetName, snapshotName))
+
+	if len(impact.NewerSnapshots) > 0 {
+		sb.WriteString(fmt.Sprintf("\nNewer snapshots that will be destroyed (%d):\n", len(impact.NewerSnapshots)))
+		writeLimitedList(&sb, impact.NewerSnapshots)
+	}
+	if len(impact.Clones) > 0 {
+		sb.WriteString(fmt.Sprintf("\nClones that will be destroyed (%d):\n", len(impact.Clones)))
+		writeLimitedList(&sb, impact.Clones)
+	}
+
+	sb.WriteString("\n")
+	switch {
+	case changes == nil:
+		sb.WriteString("Changes in the working copy could not be determined.\n")
+	case changes.Total() == 0 && !changes.Incomplete:
+		sb.WriteString("The working copy has no changes since this snapshot.\n")
+	default:
+		qualifier := ""
+		if changes.Incomplete {
+			qualifier = "at least "
+		}
+		sb.WriteString(fmt.Sprintf(
+			"Working copy changes that will be lost: %s%d added, %d modified, %d deleted\n",
+			qualifier, changes.Added, changes.Modified, changes.Deleted,
+		))
+		var examples []string
+		for _, change := range changes.Examples {
+			examples = append(examples, fmt.Sprintf("%s %s", diffStateSymbol(change.State), change.Path))
+		}
+		writeLimitedList(&sb, examples)
+	}
+
+	sb.WriteString(fmt.Sprintf("\nThis cannot be undone. Type '%s' to confirm.", datasetName))
+	return sb.String()
+}
+
+func writeLimitedList(sb *strings.Builder, entries []string) {
+	for i, entry := range entries {
+		if i >= rollbackDialogMaxListedEntries {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(entries)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  %s\n", entry))
+	}
+}
+
+func diffStateSymbol(state diff_state.DiffState) string {
+	switch state {
+	case diff_state.Added:
+		return "+"
+	case diff_state.Deleted:
+		return "-"
+	case diff_state.Modified:
+		return "≠"
+	default:
+		return "?"
+	}
+}
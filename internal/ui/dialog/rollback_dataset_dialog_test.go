@@ -0,0 +1,65 @@
+package dialog
+
+import (
+	"testing"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/data/diff_state"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeRollbackImpact(t *testing.T) {
+	impact := &zfs.RollbackImpact{
+		NewerSnapshots: []string{"s1", "s2", "s3", "s4", "s5", "s6", "s7"},
+		Clones:         []string{"pool/clone"},
+	}
+	changes := &zfs.WorkingCopyChanges{
+		Added:    1,
+		Modified: 2,
+		Examples: []zfs.WorkingCopyChange{
+			{Path: "/pool/ds/new.txt", State: diff_state.Added},
+			{Path: "/pool/ds/changed.txt", State: diff_state.Modified},
+		},
+	}
+
+	text := describeRollbackImpact("pool/ds", "snap", impact, changes)
+
+	assert.Contains(t, text, "Newer snapshots that will be destroyed (7):")
+	assert.Contains(t, text, "  s5\n")
+	assert.NotContains(t, text, "  s6\n")
+	assert.Contains(t, text, "  ... and 2 more\n")
+	assert.Contains(t, text, "Clones that will be destroyed (1):\n  pool/clone\n")
+	assert.Contains(t, text, "1 added, 2 modified, 0 deleted")
+	assert.Contains(t, text, "+ /pool/ds/new.txt")
+	assert.Contains(t, text, "≠ /pool/ds/changed.txt")
+}
+
+func TestDescribeRollbackImpact_NoChanges(t *testing.T) {
+	text := describeRollbackImpact("pool/ds", "snap", &zfs.RollbackImpact{}, &zfs.WorkingCopyChanges{})
+
+	assert.NotContains(t, text, "Newer snapshots")
+	assert.NotContains(t, text, "Clones")
+	assert.Contains(t, text, "The working copy has no changes since this snapshot.")
+
+	incomplete := describeRollbackImpact("pool/ds", "snap", &zfs.RollbackImpact{}, &zfs.WorkingCopyChanges{Deleted: 3, Incomplete: true})
+	assert.Contains(t, incomplete, "at least 0 added, 0 modified, 3 deleted")
+
+	unknown := describeRollbackImpact("pool/ds", "snap", &zfs.RollbackImpact{}, nil)
+	assert.Contains(t, unknown, "could not be determined")
+}
+
+func TestRollbackDatasetDialog_RequiresDatasetName(t *testing.T) {
+	app := tview.NewApplication()
+	entry := &data.SnapshotBrowserEntry{Snapshot: &zfs.Snapshot{Name: "snap"}}
+	d := NewRollbackDatasetDialog(app, entry, "pool/ds", &zfs.RollbackImpact{}, &zfs.WorkingCopyChanges{}, nil, nil)
+
+	assert.Error(t, d.validate(InputDialogValues{RollbackDatasetDialogConfirmFieldId: "pool/other"}))
+	assert.NoError(t, d.validate(InputDialogValues{RollbackDatasetDialogConfirmFieldId: "pool/ds"}))
+
+	d.SetValue(RollbackDatasetDialogConfirmFieldId, "wrong")
+	d.captureInput(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	assert.False(t, d.isRunning)
+}
@@ -162,6 +162,25 @@ func TestNewMultiSnapshotActionDialog(t *testing.T) {
 	assert.Equal(t, "MultiSnapshotActionDialog", d.GetName())
 }
 
+func TestBuildMultiSnapshotDialogOptions(t *testing.T) {
+	assert.Equal(t,
+		[]DialogActionId{
+			MultiSnapshotDialogDestroySnapshotActionId,
+			MultiSnapshotDialogDestroySnapshotRecursivelyActionId,
+			MultiSnapshotDialogClearSelectionActionId,
+			DialogCloseActionId,
+		},
+		optionIds(buildMultiSnapshotDialogOptions(false)),
+	)
+	assert.Equal(t,
+		[]DialogActionId{
+			MultiSnapshotDialogClearSelectionActionId,
+			DialogCloseActionId,
+		},
+		optionIds(buildMultiSnapshotDialogOptions(true)),
+	)
+}
+
 func TestNewRestoreFileDialog(t *testing.T) {
 	app := tview.NewApplication()
 	file := &data.FileBrowserEntry{
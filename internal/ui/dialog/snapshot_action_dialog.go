@@ -15,6 +15,12 @@ const (
 	SnapshotDialogCreateSnapshotActionId DialogActionId = iota
 	SnapshotDialogDestroySnapshotActionId
 	SnapshotDialogDestroySnapshotRecursivelyActionId
+	SnapshotDialogCloneSnapshotActionId
+	SnapshotDialogShowClonesActionId
+	SnapshotDialogRollbackDatasetActionId
+	SnapshotDialogRenameSnapshotActionId
+	SnapshotDialogEditNoteActionId
+	SnapshotDialogCreateBookmarkActionId
 )
 
 func NewSnapshotActionDialog(
@@ -23,34 +29,84 @@ func NewSnapshotActionDialog(
 	asyncWork func(d *SelectionDialog, action DialogActionId) error,
 	onComplete func(d *SelectionDialog, option *DialogOption, err error),
 ) *SelectionDialog {
-	dialogOptions := []*DialogOption{
-		{
-			Id:   SnapshotDialogCreateSnapshotActionId,
-			Name: "📸 Create Snapshot",
-		},
-		{
-			Id:       SnapshotDialogDestroySnapshotActionId,
-			Name:     fmt.Sprintf("💥 Destroy '%s'", snapshot.Snapshot.Name),
-			Severity: DialogSeverityDanger,
-		},
-		{
-			Id:       SnapshotDialogDestroySnapshotRecursivelyActionId,
-			Name:     fmt.Sprintf("💥 Destroy (recursive) '%s'", snapshot.Snapshot.Name),
-			Severity: DialogSeverityDanger,
-		},
-		{
-			Id:   DialogCloseActionId,
-			Name: localization.LocalizationCommonClose,
-		},
+	fromReplica := snapshot.Snapshot.IsFromReplica()
+	description := fmt.Sprintf("What do you want to do with '%s'?", snapshot.Snapshot.Name)
+	if fromReplica {
+		description += fmt.Sprintf("\nIt belongs to the replica '%s', which cannot be modified from here.", snapshot.Snapshot.ParentDataset.GetName())
 	}
-
 	return NewSelectionDialog(
 		application,
 		string(SnapshotActionDialogPage),
 		localization.LocalizationSelectActionDialogTitle,
-		fmt.Sprintf("What do you want to do with '%s'?", snapshot.Snapshot.Name),
-		dialogOptions,
+		description,
+		buildSnapshotDialogOptions(snapshot, fromReplica),
 		asyncWork,
 		onComplete,
 	)
 }
+
+// buildSnapshotDialogOptions returns the actions available for the given snapshot.
+// Snapshots of a replica only offer actions which don't modify the replica, since it is usually a backup pool
+// with a long retention, and the snapshot browser does not make it obvious that an action would target it.
+func buildSnapshotDialogOptions(snapshot *data.SnapshotBrowserEntry, fromReplica bool) []*DialogOption {
+	dialogOptions := []*DialogOption{
+		{
+			Id:   SnapshotDialogCreateSnapshotActionId,
+			Name: "📸 Create Snapshot",
+		},
+	}
+	if !fromReplica {
+		dialogOptions = append(dialogOptions,
+			&DialogOption{
+				Id:   SnapshotDialogRenameSnapshotActionId,
+				Name: fmt.Sprintf("✏️  Rename '%s'", snapshot.Snapshot.Name),
+			},
+			&DialogOption{
+				Id:   SnapshotDialogEditNoteActionId,
+				Name: "📝 Edit note",
+			},
+			&DialogOption{
+				Id:   SnapshotDialogCloneSnapshotActionId,
+				Name: fmt.Sprintf("🐑 Clone '%s'", snapshot.Snapshot.Name),
+			},
+		)
+	}
+
+	if snapshot.Snapshot.Properties.Clones > 0 {
+		dialogOptions = append(dialogOptions, &DialogOption{
+			Id:   SnapshotDialogShowClonesActionId,
+			Name: fmt.Sprintf("🐑 Show Clones (%d)", snapshot.Snapshot.Properties.Clones),
+		})
+	}
+
+	if !fromReplica {
+		dialogOptions = append(dialogOptions,
+			&DialogOption{
+				Id:   SnapshotDialogCreateBookmarkActionId,
+				Name: fmt.Sprintf("🔖 Bookmark '%s'", snapshot.Snapshot.Name),
+			},
+			&DialogOption{
+				Id:       SnapshotDialogRollbackDatasetActionId,
+				Name:     fmt.Sprintf("⏪ Rollback dataset to '%s'", snapshot.Snapshot.Name),
+				Severity: DialogSeverityDanger,
+			},
+			&DialogOption{
+				Id:       SnapshotDialogDestroySnapshotActionId,
+				Name:     fmt.Sprintf("💥 Destroy '%s'", snapshot.Snapshot.Name),
+				Severity: DialogSeverityDanger,
+			},
+			&DialogOption{
+				Id:       SnapshotDialogDestroySnapshotRecursivelyActionId,
+				Name:     fmt.Sprintf("💥 Destroy (recursive) '%s'", snapshot.Snapshot.Name),
+				Severity: DialogSeverityDanger,
+			},
+		)
+	}
+
+	dialogOptions = append(dialogOptions, &DialogOption{
+		Id:   DialogCloseActionId,
+		Name: localization.LocalizationCommonClose,
+	})
+
+	return dialogOptions
+}
@@ -0,0 +1,80 @@
+package dialog
+
+import (
+	"testing"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSnapshotDialogOptions_WithoutClones(t *testing.T) {
+	entry := &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{Name: "snap1"},
+	}
+
+	options := buildSnapshotDialogOptions(entry, false)
+
+	assert.Equal(t,
+		[]DialogActionId{
+			SnapshotDialogCreateSnapshotActionId,
+			SnapshotDialogRenameSnapshotActionId,
+			SnapshotDialogEditNoteActionId,
+			SnapshotDialogCloneSnapshotActionId,
+			SnapshotDialogCreateBookmarkActionId,
+			SnapshotDialogRollbackDatasetActionId,
+			SnapshotDialogDestroySnapshotActionId,
+			SnapshotDialogDestroySnapshotRecursivelyActionId,
+			DialogCloseActionId,
+		},
+		optionIds(options),
+	)
+}
+
+func TestBuildSnapshotDialogOptions_WithClones(t *testing.T) {
+	entry := &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{
+			Name:       "snap1",
+			Properties: zfs.SnapshotProperties{Clones: 2},
+		},
+	}
+
+	options := buildSnapshotDialogOptions(entry, false)
+
+	assert.Equal(t,
+		[]DialogActionId{
+			SnapshotDialogCreateSnapshotActionId,
+			SnapshotDialogRenameSnapshotActionId,
+			SnapshotDialogEditNoteActionId,
+			SnapshotDialogCloneSnapshotActionId,
+			SnapshotDialogShowClonesActionId,
+			SnapshotDialogCreateBookmarkActionId,
+			SnapshotDialogRollbackDatasetActionId,
+			SnapshotDialogDestroySnapshotActionId,
+			SnapshotDialogDestroySnapshotRecursivelyActionId,
+			DialogCloseActionId,
+		},
+		optionIds(options),
+	)
+	assert.Equal(t, "🐑 Show Clones (2)", options[4].Name)
+}
+
+func TestBuildSnapshotDialogOptions_FromReplica(t *testing.T) {
+	entry := &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{
+			Name:       "snap1",
+			Properties: zfs.SnapshotProperties{Clones: 1},
+		},
+	}
+
+	options := buildSnapshotDialogOptions(entry, true)
+
+	assert.Equal(t,
+		[]DialogActionId{
+			SnapshotDialogCreateSnapshotActionId,
+			SnapshotDialogShowClonesActionId,
+			DialogCloseActionId,
+		},
+		optionIds(options),
+	)
+}
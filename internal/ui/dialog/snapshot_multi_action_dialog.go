@@ -24,38 +24,54 @@ func NewMultiSnapshotActionDialog(
 	onComplete func(d *SelectionDialog, option *DialogOption, err error),
 ) *SelectionDialog {
 	snapshotNames := make([]string, 0)
+	containsReplicaSnapshots := false
 	for _, snapshot := range snapshots {
 		snapshotNames = append(snapshotNames, snapshot.Snapshot.Name)
+		containsReplicaSnapshots = containsReplicaSnapshots || snapshot.Snapshot.IsFromReplica()
 	}
 
-	dialogOptions := []*DialogOption{
-		{
-			Id:       MultiSnapshotDialogDestroySnapshotActionId,
-			Name:     "💥 Destroy all",
-			Severity: DialogSeverityDanger,
-		},
-		{
-			Id:       MultiSnapshotDialogDestroySnapshotRecursivelyActionId,
-			Name:     "💥 Destroy all (recursive)",
-			Severity: DialogSeverityDanger,
-		},
-		{
-			Id:   MultiSnapshotDialogClearSelectionActionId,
-			Name: "Clear Selection",
-		},
-		{
-			Id:   DialogCloseActionId,
-			Name: localization.LocalizationCommonClose,
-		},
+	description := fmt.Sprintf("What do you want to do with '%v'?", snapshotNames)
+	if containsReplicaSnapshots {
+		description += "\nThe selection contains snapshots of a replica, which cannot be destroyed from here."
 	}
 
 	return NewSelectionDialog(
 		application,
 		string(MultiSnapshotActionDialogPage),
 		localization.LocalizationSelectActionDialogTitle,
-		fmt.Sprintf("What do you want to do with '%v'?", snapshotNames),
-		dialogOptions,
+		description,
+		buildMultiSnapshotDialogOptions(containsReplicaSnapshots),
 		asyncWork,
 		onComplete,
 	)
 }
+
+// buildMultiSnapshotDialogOptions returns the actions available for multiple snapshots,
+// snapshots of replicas are never destroyed, see buildSnapshotDialogOptions
+func buildMultiSnapshotDialogOptions(containsReplicaSnapshots bool) []*DialogOption {
+	var dialogOptions []*DialogOption
+	if !containsReplicaSnapshots {
+		dialogOptions = append(dialogOptions,
+			&DialogOption{
+				Id:       MultiSnapshotDialogDestroySnapshotActionId,
+				Name:     "💥 Destroy all",
+				Severity: DialogSeverityDanger,
+			},
+			&DialogOption{
+				Id:       MultiSnapshotDialogDestroySnapshotRecursivelyActionId,
+				Name:     "💥 Destroy all (recursive)",
+				Severity: DialogSeverityDanger,
+			},
+		)
+	}
+	return append(dialogOptions,
+		&DialogOption{
+			Id:   MultiSnapshotDialogClearSelectionActionId,
+			Name: "Clear Selection",
+		},
+		&DialogOption{
+			Id:   DialogCloseActionId,
+			Name: localization.LocalizationCommonClose,
+		},
+	)
+}
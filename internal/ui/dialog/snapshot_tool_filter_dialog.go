@@ -0,0 +1,38 @@
+package dialog
+
+import (
+	"zfs-file-history/internal/ui/util"
+
+	"github.com/rivo/tview"
+)
+
+const SnapshotToolFilterDialogPage util.Page = "SnapshotToolFilterDialog"
+
+// NewSnapshotToolFilterDialog shows a checkbox for each of the given snapshot tools.
+// The values of the returned InputDialogValues are keyed by tool name and are "true" for visible tools.
+func NewSnapshotToolFilterDialog(
+	application *tview.Application,
+	tools []string,
+	hiddenTools map[string]bool,
+	onComplete func(d *InputDialog, values InputDialogValues, err error),
+) *InputDialog {
+	var fields []*InputDialogField
+	for _, tool := range tools {
+		fields = append(fields, &InputDialogField{
+			Id:      tool,
+			Type:    InputDialogFieldTypeCheckbox,
+			Label:   tool,
+			Checked: !hiddenTools[tool],
+		})
+	}
+
+	return NewInputDialog(
+		application,
+		string(SnapshotToolFilterDialogPage),
+		" 🧰 Snapshot Tools ",
+		"Show snapshots created by:",
+		fields,
+		nil,
+		onComplete,
+	)
+}
@@ -0,0 +1,197 @@
+package file_browser
+
+import (
+	"context"
+	"os"
+	path2 "path"
+	"slices"
+	"sync"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/zfs"
+)
+
+// statWorkers is the maximum number of files which are stat'ed concurrently while listing a directory
+const statWorkers = 32
+
+// listedName is a name found in the real directory, the snapshot directory, or both
+type listedName struct {
+	name       string
+	inReal     bool
+	inSnapshot bool
+}
+
+// listDirectory returns an entry for every file of the directory at path, and, if snapshot is not nil,
+// for every file which only exists within the same directory of the snapshot.
+// Files are stat'ed concurrently, files which disappear while listing are skipped.
+func listDirectory(ctx context.Context, path string, snapshot *zfs.Snapshot) ([]*data.FileBrowserEntry, error) {
+	realNames, err := readDirNames(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshotDir string
+	var snapshotNames []string
+	if snapshot != nil {
+		snapshotDir = snapshot.GetSnapshotPath(path)
+		snapshotNames, _ = readDirNames(snapshotDir)
+	}
+
+	names := mergeDirectoryListings(realNames, snapshotNames)
+	entries := make([]*data.FileBrowserEntry, len(names))
+	err = forEachParallel(ctx, len(names), statWorkers, func(i int) {
+		if names[i].inReal {
+			entries[i] = createRealFileEntry(path2.Join(path, names[i].name), names[i].inSnapshot, snapshot)
+		} else {
+			entries[i] = createSnapshotOnlyEntry(path2.Join(snapshotDir, names[i].name), snapshot)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(entries, func(entry *data.FileBrowserEntry) bool {
+		return entry == nil
+	}), nil
+}
+
+// readDirNames returns the sorted names of the files within the directory, a missing directory is empty.
+// Unlike os.ReadDir, no os.DirEntry is allocated for each file.
+func readDirNames(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	names, err := file.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// mergeDirectoryListings merges the names of a real directory and its snapshot counterpart,
+// real names first, in the order they were listed
+func mergeDirectoryListings(realNames []string, snapshotNames []string) []listedName {
+	result := make([]listedName, 0, len(realNames)+len(snapshotNames))
+	indexOfName := make(map[string]int, len(realNames))
+	for _, name := range realNames {
+		indexOfName[name] = len(result)
+		result = append(result, listedName{name: name, inReal: true})
+	}
+	for _, name := range snapshotNames {
+		if i, exists := indexOfName[name]; exists {
+			result[i].inSnapshot = true
+		} else {
+			result = append(result, listedName{name: name, inSnapshot: true})
+		}
+	}
+	return result
+}
+
+// forEachParallel calls action for every index in [0, n) using at most the given number of goroutines,
+// it stops early and returns ctx.Err() if the context is cancelled
+func forEachParallel(ctx context.Context, n int, workers int, action func(i int)) error {
+	if n < workers {
+		workers = n
+	}
+	indices := make(chan int, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				action(i)
+			}
+		}()
+	}
+
+	var err error
+	for i := 0; i < n; i++ {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return err
+}
+
+// createRealFileEntry returns the entry of a file present on the "real" location, and within the snapshot if inSnapshot is true
+func createRealFileEntry(realFilePath string, inSnapshot bool, snapshot *zfs.Snapshot) *data.FileBrowserEntry {
+	realFileStat, err := os.Lstat(realFilePath)
+	if err != nil {
+		return nil
+	}
+	realFileName := path2.Base(realFilePath)
+
+	entryType := entryTypeOf(realFileStat)
+	isDatasetBoundary := entryType == data.Directory && zfs.IsDatasetRoot(realFilePath)
+	var snapshotFiles []*data.SnapshotFile
+	if snapshot != nil && (inSnapshot || isDatasetBoundary) {
+		snapshotPathOfRealFile := snapshot.GetSnapshotPath(realFilePath)
+		if isDatasetBoundary {
+			// the snapshot of the parent only contains an empty mountpoint,
+			// so compare against the same-named snapshot of the child dataset instead
+			childSnapshot, err := snapshot.ForChildDataset(realFilePath)
+			if err == nil {
+				snapshot = childSnapshot
+				snapshotPathOfRealFile = childSnapshot.GetSnapshotPath(realFilePath)
+			} else {
+				logging.Debug("%s", err.Error())
+			}
+		}
+
+		if statSnap, err := os.Lstat(snapshotPathOfRealFile); err == nil {
+			snapshotFiles = append(snapshotFiles, &data.SnapshotFile{
+				Path:         snapshotPathOfRealFile,
+				OriginalPath: realFilePath,
+				Stat:         statSnap,
+				Snapshot:     snapshot,
+			})
+		} else if !os.IsNotExist(err) {
+			logging.Error("Cannot stat snapshot file: %v", err.Error())
+		}
+	}
+
+	realFile := &data.RealFile{
+		Name: realFileName,
+		Path: realFilePath,
+		Stat: realFileStat,
+	}
+
+	entry := data.NewFileBrowserEntry(realFileName, realFile, snapshotFiles, entryType)
+	entry.IsDatasetBoundary = isDatasetBoundary
+	return entry
+}
+
+// createSnapshotOnlyEntry returns the entry of a file which is only present within the snapshot
+func createSnapshotOnlyEntry(snapshotFilePath string, snapshot *zfs.Snapshot) *data.FileBrowserEntry {
+	statSnap, err := os.Lstat(snapshotFilePath)
+	if err != nil {
+		return nil
+	}
+
+	snapshotFile := &data.SnapshotFile{
+		Path:         snapshotFilePath,
+		OriginalPath: snapshot.GetRealPath(snapshotFilePath),
+		Stat:         statSnap,
+		Snapshot:     snapshot,
+	}
+	return data.NewFileBrowserEntry(path2.Base(snapshotFilePath), nil, []*data.SnapshotFile{snapshotFile}, entryTypeOf(statSnap))
+}
+
+// entryTypeOf determines whether the given file is a file, directory or symlink.
+func entryTypeOf(stat os.FileInfo) data.FileBrowserEntryType {
+	if stat.Mode().Type() == os.ModeSymlink {
+		return data.Link
+	} else if stat.IsDir() {
+		return data.Directory
+	}
+	return data.File
+}
@@ -0,0 +1,173 @@
+package file_browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/data/diff_state"
+	"zfs-file-history/internal/zfs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createListingTestDataset creates a dataset with a single snapshot, the given files are created
+// in the working copy and the snapshot respectively
+func createListingTestDataset(t testing.TB, realFiles []string, snapshotFiles []string) (string, *zfs.Snapshot) {
+	datasetPath := t.TempDir()
+	snapshot := &zfs.Snapshot{
+		Name: "snap-1",
+		ParentDataset: &zfs.Dataset{
+			Path:          datasetPath,
+			HiddenZfsPath: filepath.Join(datasetPath, ".zfs"),
+		},
+	}
+	snapshot.Path = snapshot.GetSnapshotPath(datasetPath)
+
+	assert.NoError(t, os.MkdirAll(snapshot.Path, 0755))
+	for _, name := range realFiles {
+		assert.NoError(t, os.WriteFile(filepath.Join(datasetPath, name), []byte(name), 0644))
+	}
+	for _, name := range snapshotFiles {
+		assert.NoError(t, os.WriteFile(filepath.Join(snapshot.Path, name), []byte(name), 0644))
+	}
+	return datasetPath, snapshot
+}
+
+func TestMergeDirectoryListings(t *testing.T) {
+	result := mergeDirectoryListings([]string{"a", "b", "c"}, []string{"b", "d"})
+
+	assert.Equal(t, []listedName{
+		{name: "a", inReal: true},
+		{name: "b", inReal: true, inSnapshot: true},
+		{name: "c", inReal: true},
+		{name: "d", inSnapshot: true},
+	}, result)
+}
+
+func TestListDirectory(t *testing.T) {
+	datasetPath, snapshot := createListingTestDataset(t, []string{"both", "real-only"}, []string{"both", "snapshot-only"})
+	assert.NoError(t, os.Mkdir(filepath.Join(datasetPath, "dir"), 0755))
+
+	entries, err := listDirectory(context.Background(), datasetPath, snapshot)
+	assert.NoError(t, err)
+
+	byName := map[string]*data.FileBrowserEntry{}
+	for _, entry := range entries {
+		byName[entry.Name] = entry
+	}
+	// the ".zfs" directory of the test dataset is a regular directory, so it is listed as well
+	assert.Len(t, entries, 5)
+
+	assert.True(t, byName["both"].HasReal())
+	assert.True(t, byName["both"].HasSnapshot())
+	assert.Equal(t, filepath.Join(snapshot.Path, "both"), byName["both"].SnapshotFiles[0].Path)
+
+	assert.True(t, byName["real-only"].HasReal())
+	assert.False(t, byName["real-only"].HasSnapshot())
+
+	assert.False(t, byName["snapshot-only"].HasReal())
+	assert.Equal(t, filepath.Join(datasetPath, "snapshot-only"), byName["snapshot-only"].GetRealPath())
+
+	assert.Equal(t, data.Directory, byName["dir"].Type)
+	assert.Equal(t, data.File, byName["both"].Type)
+}
+
+func TestListDirectory_WithoutSnapshot(t *testing.T) {
+	datasetPath, _ := createListingTestDataset(t, []string{"a", "b"}, []string{"c"})
+
+	entries, err := listDirectory(context.Background(), datasetPath, nil)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+		assert.False(t, entry.HasSnapshot())
+	}
+	assert.Equal(t, []string{".zfs", "a", "b"}, names)
+}
+
+func TestListDirectory_Missing(t *testing.T) {
+	entries, err := listDirectory(context.Background(), filepath.Join(t.TempDir(), "missing"), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestForEachParallel(t *testing.T) {
+	var sum atomic.Int64
+	err := forEachParallel(context.Background(), 1000, 8, func(i int) {
+		sum.Add(int64(i))
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(999*1000/2), sum.Load())
+}
+
+func TestForEachParallel_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int64
+	err := forEachParallel(ctx, 1000, 8, func(i int) {
+		calls.Add(1)
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(0), calls.Load())
+}
+
+func createListingBenchmarkNames(count int) []string {
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("file-%07d", i)
+	}
+	return names
+}
+
+func BenchmarkMergeDirectoryListings(b *testing.B) {
+	// a huge directory, e.g. a maildir, where a tenth of the files has been replaced since the snapshot
+	realNames := createListingBenchmarkNames(200_000)
+	snapshotNames := append(createListingBenchmarkNames(180_000), createListingBenchmarkNames(220_000)[200_000:]...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mergeDirectoryListings(realNames, snapshotNames)
+	}
+}
+
+func BenchmarkListDirectory(b *testing.B) {
+	names := createListingBenchmarkNames(10_000)
+	datasetPath, snapshot := createListingTestDataset(b, names, names[:9_000])
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entries, err := listDirectory(context.Background(), datasetPath, snapshot)
+		// +1 for the ".zfs" directory of the test dataset
+		if err != nil || len(entries) != len(names)+1 {
+			b.Fatalf("unexpected listing: %d entries, %v", len(entries), err)
+		}
+	}
+}
+
+func BenchmarkDetermineDiffState(b *testing.B) {
+	names := createListingBenchmarkNames(1_000)
+	datasetPath, snapshot := createListingTestDataset(b, names, names)
+	entries, err := listDirectory(context.Background(), datasetPath, snapshot)
+	if err != nil {
+		b.Fatal(err)
+	}
+	fileBrowser := &FileBrowserComponent{}
+	snapshotEntry := &data.SnapshotBrowserEntry{Snapshot: snapshot}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, entry := range entries {
+			if fileBrowser.determineDiffState(entry, snapshotEntry) == diff_state.Unknown {
+				b.Fatal("unexpected unknown diff state")
+			}
+		}
+	}
+}
@@ -17,11 +17,10 @@ type PathChangedEvent struct {
 
 func (pathChangedEvent PathChangedEvent) isFileBrowserEvent() {}
 
-type CreateSnapshotEvent struct {
-	SnapshotName string
-}
+// RequestCreateSnapshotEvent asks for a new snapshot of the current dataset to be created
+type RequestCreateSnapshotEvent struct{}
 
-func (CreateSnapshotEvent) isFileBrowserEvent() {}
+func (RequestCreateSnapshotEvent) isFileBrowserEvent() {}
 
 type RequestFocusEvent struct {
 	Layout tview.Primitive
@@ -103,6 +103,10 @@ type FileBrowserComponent struct {
 
 	diffLoader    *uiutil.DebouncedLoader
 	refreshLoader *uiutil.DebouncedLoader
+
+	// lockedMountpoints contains the mountpoints of datasets whose encryption key is not loaded,
+	// which are only empty directories of their parent dataset
+	lockedMountpoints map[string]*zfs.LockedDataset
 }
 
 func NewFileBrowser(application *tview.Application) *FileBrowserComponent {
@@ -115,7 +119,7 @@ func NewFileBrowser(application *tview.Application) *FileBrowserComponent {
 	}
 
 	fileBrowser.diffLoader = uiutil.NewDebouncedLoader(application, func() {
-		for _, entry := range fileBrowser.tableContainer.GetEntries() {
+		for _, entry := range fileBrowser.tableContainer.GetVisibleEntries() {
 			if entry != nil && entry.IsLoading {
 				fileBrowser.tableContainer.UpdateEntry(entry)
 			}
@@ -201,6 +205,16 @@ func (fileBrowser *FileBrowserComponent) setupTable() {
 		fileBrowser.rememberSelectionInfoForCurrentPath()
 		fileBrowser.Events.Emit(SelectedTableEntryChangedEvent{selectedEntry})
 	})
+	fileBrowser.tableContainer.SetVisibleRowsChangedCallback(func() {
+		if len(fileBrowser.getEntriesWithoutDiff()) > 0 {
+			fileBrowser.startAsyncDiffCalculation()
+		}
+	})
+	fileBrowser.tableContainer.SetSortChangedCallback(func() {
+		if fileBrowser.isSortedByDiff() && len(fileBrowser.getEntriesWithoutDiff()) > 0 {
+			fileBrowser.startAsyncDiffCalculation()
+		}
+	})
 }
 
 func (fileBrowser *FileBrowserComponent) emit(event Event) {
@@ -233,88 +247,13 @@ func (fileBrowser *FileBrowserComponent) computeTableEntries(ctx context.Context
 		return nil, ctx.Err()
 	}
 
-	// list files in current path
-	realFiles, err := util.ListFilesIn(path)
-	if err != nil {
-		return nil, err
-	}
-
-	// list snapshot files in currently path with currently selected snapshot
-	var snapshotFilePaths []string
+	var snapshot *zfs.Snapshot
 	if snapshotEntry != nil {
-		snapshotPath := snapshotEntry.Snapshot.GetSnapshotPath(path)
-		snapshotFilePaths, _ = util.ListFilesIn(snapshotPath)
-	}
-
-	fileEntries := []*data.FileBrowserEntry{}
-
-	// add entries for files which are present on the "real" location (and possibly within a snapshot as well)
-	for _, realFilePath := range realFiles {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-		_, realFileName := path2.Split(realFilePath)
-		realFileStat, err := os.Lstat(realFilePath)
-		if err != nil {
-			continue
-		}
-
-		entryType := fileBrowser.determineEntryType(realFilePath)
-		var snapshotFile *data.SnapshotFile = nil
-		if snapshotEntry != nil {
-			snapshot := snapshotEntry.Snapshot
-			snapshotPathOfRealFile := snapshot.GetSnapshotPath(realFilePath)
-			snapshotFile = fileBrowser.computeSnapshotEntryForRealPathIfExists(
-				realFilePath,
-				snapshotPathOfRealFile,
-				snapshot,
-			)
-			// remove from snapshotFilePaths so we don't add it again later
-			snapshotFilePaths = slices.DeleteFunc(snapshotFilePaths, func(s string) bool {
-				return s == snapshotPathOfRealFile
-			})
-		}
-
-		var snapshotFiles []*data.SnapshotFile
-		if snapshotFile != nil {
-			snapshotFiles = append(snapshotFiles, snapshotFile)
-		}
-
-		realFile := &data.RealFile{
-			Name: realFileName,
-			Path: realFilePath,
-			Stat: realFileStat,
-		}
-
-		fileBrowserEntry := data.NewFileBrowserEntry(realFileName, realFile, snapshotFiles, entryType)
-		fileEntries = append(fileEntries, fileBrowserEntry)
+		snapshot = snapshotEntry.Snapshot
 	}
-
-	if snapshotEntry != nil {
-		// add remaining entries for files which are only present in the snapshot
-		for _, snapshotFilePath := range snapshotFilePaths {
-			if ctx.Err() != nil {
-				return nil, ctx.Err()
-			}
-			_, snapshotFileName := path2.Split(snapshotFilePath)
-
-			statSnap, err := os.Lstat(snapshotFilePath)
-			if err != nil {
-				continue
-			}
-
-			entryType := fileBrowser.determineEntryType(snapshotFilePath)
-
-			snapshotFile := &data.SnapshotFile{
-				Path:         snapshotFilePath,
-				OriginalPath: snapshotEntry.Snapshot.GetRealPath(snapshotFilePath),
-				Stat:         statSnap,
-				Snapshot:     snapshotEntry.Snapshot,
-			}
-
-			snapshotFiles := []*data.SnapshotFile{snapshotFile}
-			fileEntries = append(fileEntries, data.NewFileBrowserEntry(snapshotFileName, nil, snapshotFiles, entryType))
-		}
+	fileEntries, err := listDirectory(ctx, path, snapshot)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, entry := range fileEntries {
@@ -329,42 +268,6 @@ func (fileBrowser *FileBrowserComponent) computeTableEntries(ctx context.Context
 	return fileEntries, nil
 }
 
-func (fileBrowser *FileBrowserComponent) computeSnapshotEntryForRealPathIfExists(
-	realFilePath string,
-	snapshotPathOfRealFile string,
-	snapshot *zfs.Snapshot,
-) *data.SnapshotFile {
-	var snapshotFile *data.SnapshotFile = nil
-	snapshotFilePath := snapshotPathOfRealFile
-	statSnap, err := os.Lstat(snapshotFilePath)
-	if err != nil {
-		logging.Error("Cannot stat snapshot file: %v", err.Error())
-		return nil
-	}
-
-	snapshotFile = &data.SnapshotFile{
-		Path:         snapshotFilePath,
-		OriginalPath: realFilePath,
-		Stat:         statSnap,
-		Snapshot:     snapshot,
-	}
-	return snapshotFile
-}
-
-// determineEntryType determines whether the given path is a file, directory or symlink.
-func (fileBrowser *FileBrowserComponent) determineEntryType(path string) data.FileBrowserEntryType {
-	var entryType data.FileBrowserEntryType
-	lstat, err := os.Lstat(path)
-	if err == nil && lstat.Mode().Type() == os.ModeSymlink {
-		entryType = data.Link
-	} else if lstat != nil && lstat.IsDir() {
-		entryType = data.Directory
-	} else {
-		entryType = data.File
-	}
-	return entryType
-}
-
 func (fileBrowser *FileBrowserComponent) determineDiffState(
 	entry *data.FileBrowserEntry,
 	snapshotEntry *data.SnapshotBrowserEntry,
@@ -446,8 +349,6 @@ func (fileBrowser *FileBrowserComponent) openActionDialog(selection *data.FileBr
 		switch action {
 		case dialog.FileDialogShowDiffActionId:
 			return fileBrowser.showDiff(selection, fileBrowser.currentSnapshot)
-		case dialog.FileDialogCreateSnapshotDialogActionId:
-			return fileBrowser.createSnapshot(selection)
 		case dialog.FileDialogRestoreRecursiveDialogActionId:
 			return fileBrowser.runRestoreFileAction(selection, true)
 		case dialog.FileDialogRestoreFileActionId:
@@ -461,6 +362,11 @@ func (fileBrowser *FileBrowserComponent) openActionDialog(selection *data.FileBr
 	// 2. Define the UI updates after the work finishes
 	onComplete := func(d *dialog.SelectionDialog, option *dialog.DialogOption, err error) {
 		fileBrowser.Refresh(false)
+
+		if err == nil && option.Id == dialog.FileDialogCreateSnapshotDialogActionId {
+			d.Chain(func() { fileBrowser.emit(RequestCreateSnapshotEvent{}) })
+			return
+		}
 		d.Close()
 
 		if err != nil {
@@ -545,13 +451,36 @@ func (fileBrowser *FileBrowserComponent) SetSelectedSnapshot(snapshot *data.Snap
 	fileBrowser.Refresh(true)
 }
 
+// getEntriesWithoutDiff returns the visible entries whose diff state has not been computed yet.
+// Diff states are computed lazily, as rows are scrolled into view, since huge directories
+// would otherwise require to stat every file within the snapshot.
+// When sorted by diff state, the order depends on all entries, so none of them are skipped.
+func (fileBrowser *FileBrowserComponent) getEntriesWithoutDiff() []*data.FileBrowserEntry {
+	entries := fileBrowser.tableContainer.GetVisibleEntries()
+	if fileBrowser.isSortedByDiff() {
+		entries = fileBrowser.tableContainer.GetEntries()
+	}
+	var result []*data.FileBrowserEntry
+	for _, entry := range entries {
+		if entry != nil && entry.IsLoading {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+func (fileBrowser *FileBrowserComponent) isSortedByDiff() bool {
+	return fileBrowser.tableContainer.GetSortColumn() == columnDiff
+}
+
 func (fileBrowser *FileBrowserComponent) startAsyncDiffCalculation() {
 	if fileBrowser.diffLoader != nil {
 		fileBrowser.diffLoader.Cancel()
 	}
 
 	snapshotEntry := fileBrowser.currentSnapshot
-	entriesToProcess := slices.Clone(fileBrowser.tableContainer.GetEntries())
+	entriesToProcess := fileBrowser.getEntriesWithoutDiff()
+	isSortedByDiff := fileBrowser.isSortedByDiff()
 
 	if len(entriesToProcess) == 0 {
 		return
@@ -633,6 +562,19 @@ func (fileBrowser *FileBrowserComponent) startAsyncDiffCalculation() {
 				pushBatch(false)
 			}
 		}
+
+		if isSortedByDiff {
+			// UpdateEntry keeps the order of the rows, which is only known once all diff states have been computed
+			fileBrowser.application.QueueUpdateDraw(func() {
+				if fileBrowser.diffLoader.IsCurrentSequence(seq) && fileBrowser.isSortedByDiff() {
+					fileBrowser.tableContainer.Resort()
+				}
+			})
+		}
+
+		if err := zfs.FlushMetadataCacheThrottled(); err != nil {
+			logging.Warning("Could not write metadata cache: %s", err.Error())
+		}
 	}()
 }
 
@@ -826,9 +768,14 @@ func (fileBrowser *FileBrowserComponent) updateFileWatcher() {
 		fileBrowser.fileWatcher = nil
 	}
 	fileBrowser.fileWatcher = util.NewFileWatcher(path)
-	action := func(s string) {
-		fileBrowser.Refresh(false)
-		fileBrowser.application.Draw()
+	action := func(events []util.FileEvent) {
+		fileBrowser.application.QueueUpdate(func() {
+			// ignore events which were already queued when the path changed
+			if fileBrowser.path != path {
+				return
+			}
+			fileBrowser.Refresh(false)
+		})
 	}
 	err := fileBrowser.fileWatcher.Watch(action)
 	if err != nil {
@@ -941,13 +888,6 @@ func (fileBrowser *FileBrowserComponent) delete(entry *data.FileBrowserEntry) er
 	return nil
 }
 
-func (fileBrowser *FileBrowserComponent) createSnapshot(entry *data.FileBrowserEntry) error {
-	snapshotName := fmt.Sprintf("zfh-%s", time.Now().Format(zfs.SnapshotTimeFormat))
-	// TODO: return error from this event chain, and probably not use an event here at all
-	fileBrowser.emit(CreateSnapshotEvent{snapshotName})
-	return nil
-}
-
 func (fileBrowser *FileBrowserComponent) showMessage(message *status_message.StatusMessage) {
 	logging.Info("%s", message.Message)
 	fileBrowser.emit(FileBrowserStatusEvent{message})
@@ -971,6 +911,29 @@ func (fileBrowser *FileBrowserComponent) SelectFirstEntryIfExists() {
 	fileBrowser.tableContainer.SelectFirstIfExists()
 }
 
+// SetLockedDatasets marks the mountpoints of the given datasets, whose encryption key is not loaded
+func (fileBrowser *FileBrowserComponent) SetLockedDatasets(datasets []*zfs.LockedDataset) {
+	previous := fileBrowser.lockedMountpoints
+	fileBrowser.lockedMountpoints = make(map[string]*zfs.LockedDataset, len(datasets))
+	for _, dataset := range datasets {
+		fileBrowser.lockedMountpoints[dataset.Mountpoint] = dataset
+	}
+	for _, entry := range fileBrowser.tableContainer.GetEntries() {
+		path := entry.GetRealPath()
+		if previous[path] != nil || fileBrowser.lockedMountpoints[path] != nil {
+			fileBrowser.tableContainer.UpdateEntry(entry)
+		}
+	}
+}
+
+// getLockedDataset returns the locked dataset mounted at the given entry, or nil if there is none
+func (fileBrowser *FileBrowserComponent) getLockedDataset(entry *data.FileBrowserEntry) *zfs.LockedDataset {
+	if entry.RealFile == nil {
+		return nil
+	}
+	return fileBrowser.lockedMountpoints[entry.GetRealPath()]
+}
+
 func (fileBrowser *FileBrowserComponent) GetEntries() []*data.FileBrowserEntry {
 	return fileBrowser.tableContainer.GetEntries()
 }
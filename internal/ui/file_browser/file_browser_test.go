@@ -0,0 +1,72 @@
+package file_browser
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"zfs-file-history/internal/data"
+	"zfs-file-history/internal/data/diff_state"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortByDiff_ComputesAllEntries(t *testing.T) {
+	var snapshotFiles []string
+	for i := 0; i < 29; i++ {
+		snapshotFiles = append(snapshotFiles, fmt.Sprintf("file-%02d", i))
+	}
+	// the last file only exists in the working copy, and is far below the visible rows when sorted by name
+	realFiles := append([]string{"file-29"}, snapshotFiles...)
+	datasetPath, snapshot := createListingTestDataset(t, realFiles, snapshotFiles)
+
+	screen := tcell.NewSimulationScreen("")
+	assert.NoError(t, screen.Init())
+	screen.SetSize(80, 10)
+	application := tview.NewApplication().SetScreen(screen)
+	fileBrowser := NewFileBrowser(application)
+	fileBrowser.path = datasetPath
+	fileBrowser.currentSnapshot = &data.SnapshotBrowserEntry{Snapshot: snapshot}
+	application.SetRoot(fileBrowser.GetLayout(), true)
+	go func() { _ = application.Run() }()
+	defer application.Stop()
+
+	entries, err := fileBrowser.computeTableEntries(context.Background(), nil)
+	assert.NoError(t, err)
+
+	onUiThread := func(action func()) {
+		done := make(chan struct{})
+		application.QueueUpdateDraw(func() {
+			action()
+			close(done)
+		})
+		<-done
+	}
+
+	onUiThread(func() {
+		fileBrowser.tableContainer.SetData(entries)
+	})
+	onUiThread(func() {
+		assert.Less(t, len(fileBrowser.tableContainer.GetVisibleEntries()), len(entries))
+		assert.Less(t, len(fileBrowser.getEntriesWithoutDiff()), len(entries))
+
+		fileBrowser.tableContainer.SortBy(columnDiff, true)
+		assert.Len(t, fileBrowser.getEntriesWithoutDiff(), len(entries))
+		fileBrowser.startAsyncDiffCalculation()
+	})
+
+	assert.Eventually(t, func() bool {
+		var first *data.FileBrowserEntry
+		onUiThread(func() {
+			first = fileBrowser.tableContainer.GetEntries()[0]
+		})
+		return first.Name == "file-29"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	onUiThread(func() {
+		assert.Empty(t, fileBrowser.getEntriesWithoutDiff())
+		assert.Equal(t, diff_state.Added, fileBrowser.tableContainer.GetEntries()[0].DiffState)
+	})
+}
@@ -1,9 +1,9 @@
 package file_browser
 
 import (
+	"cmp"
 	"fmt"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
 	"zfs-file-history/internal/configuration"
@@ -32,6 +32,12 @@ func (fileBrowser *FileBrowserComponent) fileBrowserEntryTableCellsFunction(row
 	statusCellColor := determineStatusColor(entry)
 	typeCellText := determineTypeCellText(entry)
 	typeCellColor := determineTypeCellColor(entry)
+	isLocked := fileBrowser.getLockedDataset(entry) != nil
+	if isLocked {
+		// the mountpoint of a dataset without its key is an empty directory of the parent dataset
+		typeCellText = "Z"
+		typeCellColor = tcell.ColorRed
+	}
 
 	for _, column := range columns {
 		var cellColor = tcell.ColorWhite
@@ -48,6 +54,9 @@ func (fileBrowser *FileBrowserComponent) fileBrowserEntryTableCellsFunction(row
 			} else if entry.Type == data.Directory {
 				cellText = fmt.Sprintf("/%s", cellText)
 			}
+			if isLocked {
+				cellText = fmt.Sprintf("%s 🔒", cellText)
+			}
 			cellColor = statusCellColor
 		case columnType:
 			cellText = typeCellText
@@ -134,6 +143,9 @@ func (fileBrowser *FileBrowserComponent) fileBrowserEntryTableCellsFunction(row
 }
 
 func determineTypeCellColor(entry *data.FileBrowserEntry) tcell.Color {
+	if entry.IsDatasetBoundary {
+		return tcell.ColorAqua
+	}
 	switch entry.Type {
 	case data.Directory:
 		return theme.Colors.Layout.Table.Header
@@ -147,6 +159,9 @@ func determineTypeCellColor(entry *data.FileBrowserEntry) tcell.Color {
 }
 
 func determineTypeCellText(entry *data.FileBrowserEntry) string {
+	if entry.IsDatasetBoundary {
+		return "Z"
+	}
 	switch entry.Type {
 	case data.File:
 		return "F"
@@ -256,91 +271,72 @@ func formatIdentity(id uint32, lookupName func(uint32) (string, error)) string {
 	}
 }
 
-func fileBrowserEntrySortFunction(entries []*data.FileBrowserEntry, columnToSortBy *table.Column, inverted bool) []*data.FileBrowserEntry {
-	sort.SliceStable(entries, func(i, j int) bool {
-		a := entries[i]
-		b := entries[j]
-
-		result := 0
-		switch columnToSortBy {
-		case columnName:
-			result = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
-		case columnDateTime:
-			result = compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
-				return statA.ModTime().Compare(statB.ModTime())
-			})
-		case columnType:
-			result = int(b.Type - a.Type)
-		case columnSize:
-			result = compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
-				return int(statA.Size() - statB.Size())
-			})
-		case columnDiff:
-			result = int(b.DiffState - a.DiffState)
-		case columnPermissions:
-			result = compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
-				permA := util.UnixPermissions(statA.Mode())
-				permB := util.UnixPermissions(statB.Mode())
-				switch {
-				case permA < permB:
-					return -1
-				case permA > permB:
-					return 1
-				default:
-					return 0
-				}
-			})
-		case columnUID:
-			result = compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
-				uidA, _, okA := util.UnixOwnerIDs(statA)
-				uidB, _, okB := util.UnixOwnerIDs(statB)
-				return compareUint32WithMissing(uidA, okA, uidB, okB)
-			})
-		case columnGID:
-			result = compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
-				_, gidA, okA := util.UnixOwnerIDs(statA)
-				_, gidB, okB := util.UnixOwnerIDs(statB)
-				return compareUint32WithMissing(gidA, okA, gidB, okB)
-			})
-		}
-
-		if inverted {
-			result *= -1
-		}
+// fileBrowserSortKey contains the values of an entry which are compared while sorting, see table.SortByKeys
+type fileBrowserSortKey struct {
+	entry *data.FileBrowserEntry
+	// lowerName is computed once per entry, as every comparison falls back to the name
+	lowerName string
+}
 
-		if result != 0 {
-			if result <= 0 {
-				return true
-			} else {
-				return false
+func fileBrowserEntrySortFunction(entries []*data.FileBrowserEntry, columnToSortBy *table.Column, inverted bool) []*data.FileBrowserEntry {
+	return table.SortByKeys(entries,
+		func(entry *data.FileBrowserEntry) fileBrowserSortKey {
+			return fileBrowserSortKey{entry: entry, lowerName: strings.ToLower(entry.Name)}
+		},
+		func(keyA, keyB fileBrowserSortKey) int {
+			result := compareFileBrowserEntries(keyA, keyB, columnToSortBy)
+			if inverted {
+				result *= -1
 			}
-		}
-
-		result = int(b.Type - a.Type)
-		if result != 0 {
-			if result <= 0 {
-				return true
-			} else {
-				return false
+			if result != 0 {
+				return result
 			}
-		}
 
-		result = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
-		if result != 0 {
-			if result <= 0 {
-				return true
-			} else {
-				return false
+			result = int(keyB.entry.Type - keyA.entry.Type)
+			if result != 0 {
+				return result
 			}
-		}
+			return strings.Compare(keyA.lowerName, keyB.lowerName)
+		},
+	)
+}
 
-		if result <= 0 {
-			return true
-		} else {
-			return false
-		}
-	})
-	return entries
+func compareFileBrowserEntries(keyA, keyB fileBrowserSortKey, columnToSortBy *table.Column) int {
+	a := keyA.entry
+	b := keyB.entry
+	switch columnToSortBy {
+	case columnName:
+		return strings.Compare(keyA.lowerName, keyB.lowerName)
+	case columnDateTime:
+		return compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
+			return statA.ModTime().Compare(statB.ModTime())
+		})
+	case columnType:
+		return int(b.Type - a.Type)
+	case columnSize:
+		return compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
+			return cmp.Compare(statA.Size(), statB.Size())
+		})
+	case columnDiff:
+		return int(b.DiffState - a.DiffState)
+	case columnPermissions:
+		return compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
+			return cmp.Compare(util.UnixPermissions(statA.Mode()), util.UnixPermissions(statB.Mode()))
+		})
+	case columnUID:
+		return compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
+			uidA, _, okA := util.UnixOwnerIDs(statA)
+			uidB, _, okB := util.UnixOwnerIDs(statB)
+			return compareUint32WithMissing(uidA, okA, uidB, okB)
+		})
+	case columnGID:
+		return compareWithMissingStats(a, b, func(statA, statB os.FileInfo) int {
+			_, gidA, okA := util.UnixOwnerIDs(statA)
+			_, gidB, okB := util.UnixOwnerIDs(statB)
+			return compareUint32WithMissing(gidA, okA, gidB, okB)
+		})
+	}
+	return 0
 }
 
 func compareWithMissingStats(a, b *data.FileBrowserEntry, compareFunc func(statA, statB os.FileInfo) int) int {
@@ -73,6 +73,10 @@ func NewMainPage(application *tview.Application, path string) *MainPage {
 		switch event := event.(type) {
 		case snapshot_browser.StatusMessageEvent:
 			mainPage.showStatusMessage(event.Message)
+		case snapshot_browser.RequestOpenPathEvent:
+			fileBrowser.SetPath(event.Path, true)
+			fileBrowser.Focus()
+			mainPage.updateShortcutMap(fileBrowser)
 		}
 	})
 
@@ -96,6 +100,20 @@ func NewMainPage(application *tview.Application, path string) *MainPage {
 		}
 	})
 
+	datasetInfo.Events.Subscribe(func(event dataset_info.Event) {
+		switch e := event.(type) {
+		case dataset_info.LockedDatasetsChangedEvent:
+			fileBrowser.SetLockedDatasets(e.Datasets)
+		case dataset_info.KeyUnavailableEvent:
+			mainPage.showStatusMessage(status_message.NewWarningStatusMessage(fmt.Sprintf("Encryption key of '%s' is not loaded, its files and snapshots are inaccessible.", e.Datasets[0].Name)))
+			if datasetInfo.HasFocus() {
+				mainPage.updateShortcutMap(datasetInfo)
+			}
+		case dataset_info.RequestLoadKeyEvent:
+			mainPage.openLoadKeyDialog(e.Dataset)
+		}
+	})
+
 	snapshotBrowser.Events.Subscribe(func(event snapshot_browser.Event) {
 		switch e := event.(type) {
 		case snapshot_browser.SelectedSnapshotChanged:
@@ -129,6 +147,9 @@ func NewMainPage(application *tview.Application, path string) *MainPage {
 			mainPage.CycleFocus(false)
 		case tcell.KeyBacktab:
 			mainPage.CycleFocus(true)
+		case tcell.KeyF3:
+			mainPage.openDatasetTreeDialog()
+			return nil
 		case tcell.KeyF5:
 			zfs.RefreshZfsData()
 			fileBrowser.Refresh(false)
@@ -141,17 +162,8 @@ func NewMainPage(application *tview.Application, path string) *MainPage {
 		switch e := event.(type) {
 		case file_browser.RequestFocusEvent:
 			application.SetFocus(e.Layout)
-		case file_browser.CreateSnapshotEvent:
-			name := e.SnapshotName
-			err := datasetInfo.CreateSnapshot(name)
-			if err != nil {
-				logging.Error("Failed to create snapshot: %s", err)
-				mainPage.showStatusMessage(status_message.NewErrorStatusMessage(fmt.Sprintf("Failed to create snapshot: %s", err)))
-			} else {
-				snapshotBrowser.Refresh(true)
-				snapshotBrowser.SelectLatest()
-				mainPage.showStatusMessage(status_message.NewSuccessStatusMessage(fmt.Sprintf("Snapshot '%s' created.", name)))
-			}
+		case file_browser.RequestCreateSnapshotEvent:
+			snapshotBrowser.OpenCreateSnapshotDialog()
 		}
 	})
 
@@ -378,6 +390,62 @@ func (mainPage *MainPage) CycleFocus(reversed bool) {
 	mainPage.updateShortcutMap(nextFocusedComponent)
 }
 
+// openDatasetTreeDialog lists all datasets and opens the mountpoint of the selected one in the file browser
+func (mainPage *MainPage) openDatasetTreeDialog() {
+	go func() {
+		datasets, err := zfs.ListDatasets()
+		mainPage.application.QueueUpdateDraw(func() {
+			if err != nil {
+				logging.Error("Failed to list datasets: %s", err.Error())
+				errDialog := dialog.NewErrorDialog(mainPage.application, "Cannot List Datasets", err)
+				dialog.ShowDialogOnPages(mainPage.application, mainPage.pages, errDialog, nil)
+				return
+			}
+
+			d := dialog.NewDatasetTreeDialog(mainPage.application, datasets, mainPage.fileBrowser.GetPath(), func(dataset *zfs.DatasetSummary) {
+				mainPage.fileBrowser.SetPath(dataset.Mountpoint, true)
+				mainPage.fileBrowser.Focus()
+				mainPage.updateShortcutMap(mainPage.fileBrowser)
+			})
+			dialog.ShowDialogOnPages(mainPage.application, mainPage.pages, d, nil)
+		})
+	}()
+}
+
+// openLoadKeyDialog asks for the passphrase of the given dataset, loads its key and refreshes all components
+func (mainPage *MainPage) openLoadKeyDialog(dataset *zfs.LockedDataset) {
+	datasetName := dataset.Name
+
+	asyncWork := func(d *dialog.InputDialog, values dialog.InputDialogValues) error {
+		return dataset.LoadKey(values[dialog.LoadKeyDialogPassphraseFieldId])
+	}
+
+	onComplete := func(d *dialog.InputDialog, values dialog.InputDialogValues, err error) {
+		if err != nil {
+			// keep the dialog open to allow retrying a mistyped passphrase
+			logging.Error("Failed to load key: %s", err.Error())
+			d.ShowError(err)
+			return
+		}
+		d.Close()
+
+		mainPage.showStatusMessage(status_message.NewSuccessStatusMessage(fmt.Sprintf("Key of '%s' loaded.", datasetName)))
+		zfs.RefreshZfsData()
+		mainPage.datasetInfo.Refresh()
+		mainPage.fileBrowser.Refresh(false)
+	}
+
+	d := dialog.NewLoadKeyDialog(
+		mainPage.application,
+		datasetName,
+		dataset.KeyLocation,
+		dataset.RequiresKeyPrompt(),
+		asyncWork,
+		onComplete,
+	)
+	dialog.ShowDialogOnPages(mainPage.application, mainPage.pages, d, nil)
+}
+
 func (mainPage *MainPage) showStatusMessage(status *status_message.StatusMessage) {
 	mainPage.header.SetStatus(status)
 }
@@ -396,6 +464,7 @@ func (mainPage *MainPage) updateShortcutMap(component FocusableUiComponent) {
 
 		globalShortcutMapEntries := []shortcut_helper.ShortcutEntry{
 			{KeyCombo: []string{"⭾", "shift+⭾"}, Name: "Cycle focus"},
+			{KeyCombo: []string{"F3"}, Name: "Datasets"},
 			{KeyCombo: []string{"F5"}, Name: "Refresh"},
 			{KeyCombo: []string{"ctrl+q"}, Name: "Quit"},
 		}
@@ -20,3 +20,9 @@ type StatusMessageEvent struct {
 }
 
 func (e StatusMessageEvent) isSnapshotBrowserEvent() {}
+
+type RequestOpenPathEvent struct {
+	Path string
+}
+
+func (e RequestOpenPathEvent) isSnapshotBrowserEvent() {}
@@ -2,14 +2,20 @@ package snapshot_browser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
 	"strings"
 	"time"
+	"zfs-file-history/internal/autosnap"
+	"zfs-file-history/internal/configuration"
 	"zfs-file-history/internal/data"
 	"zfs-file-history/internal/data/diff_state"
+	"zfs-file-history/internal/index"
 	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/retention"
 	"zfs-file-history/internal/ui/dialog"
 	"zfs-file-history/internal/ui/shortcut_helper"
 	"zfs-file-history/internal/ui/status_message"
@@ -44,8 +50,33 @@ type SnapshotBrowserComponent struct {
 	selectLatestOnNextLoad bool
 
 	diffLoader *uiutil.DebouncedLoader
+
+	// snapshotMonitor watches the snapshot directories of the current dataset and its replicas,
+	// to pick up snapshots taken or destroyed by other tools
+	snapshotMonitor *snapshotMonitor
+
+	// columns contains all available table columns, including the configured user property columns
+	columns []*table.Column
+
+	// retentionPolicy is nil if no retention policy is configured
+	retentionPolicy    *retention.Policy
+	retentionDecisions map[string]*retention.Decision
+
+	// sanoidConfig is nil if no sanoid configuration is available
+	sanoidConfig    *autosnap.SanoidConfig
+	snapshotOrigins map[string]*autosnap.Origin
+	// hiddenTools contains the snapshot tools whose snapshots are not shown, see otherSnapshotTool
+	hiddenTools map[string]bool
 }
 
+const (
+	rollbackPreviewTimeout     = 10 * time.Second
+	rollbackPreviewMaxExamples = 5
+
+	// otherSnapshotTool is used to hide snapshots which were not created by a known snapshot tool
+	otherSnapshotTool = "other"
+)
+
 type snapshotLoadResult struct {
 	dataset   *zfs.Dataset
 	snapshots []*zfs.Snapshot
@@ -87,9 +118,50 @@ var (
 		Title:     "Clones",
 		Alignment: tview.AlignCenter,
 	}
+	columnWritten = &table.Column{
+		Id:        7,
+		Title:     "Written",
+		Alignment: tview.AlignCenter,
+	}
+	columnAge = &table.Column{
+		Id:        8,
+		Title:     "Age",
+		Alignment: tview.AlignRight,
+	}
+	columnRetention = &table.Column{
+		Id:        9,
+		Title:     "Retention",
+		Alignment: tview.AlignLeft,
+	}
+	columnTool = &table.Column{
+		Id:        10,
+		Title:     "Tool",
+		Alignment: tview.AlignLeft,
+	}
+	columnInterval = &table.Column{
+		Id:        11,
+		Title:     "Interval",
+		Alignment: tview.AlignLeft,
+	}
+	columnExpires = &table.Column{
+		Id:        12,
+		Title:     "Expires",
+		Alignment: tview.AlignLeft,
+	}
+	columnCreationSource = &table.Column{
+		Id:        13,
+		Title:     "Date Source",
+		Alignment: tview.AlignLeft,
+	}
+	columnSource = &table.Column{
+		Id:        14,
+		Title:     "Source",
+		Alignment: tview.AlignLeft,
+	}
 
 	tableColumns = []*table.Column{
-		columnName, columnDate, columnDiff, columnUsed, columnRefer, columnRatio, columnClones,
+		columnName, columnDate, columnAge, columnDiff, columnUsed, columnRefer, columnWritten, columnRatio, columnClones, columnRetention,
+		columnTool, columnInterval, columnExpires, columnCreationSource, columnSource,
 	}
 
 	initialActiveTableColumns = []*table.Column{
@@ -98,6 +170,9 @@ var (
 		columnDate,
 		columnUsed,
 	}
+
+	snapshotBrowserShortcutBookmarks = shortcut_helper.ShortcutEntry{KeyCombo: []string{"b"}, Name: "Bookmarks"}
+	snapshotBrowserShortcutTools     = shortcut_helper.ShortcutEntry{KeyCombo: []string{"t"}, Name: "Tools"}
 )
 
 func NewSnapshotBrowser(application *tview.Application) *SnapshotBrowserComponent {
@@ -106,6 +181,27 @@ func NewSnapshotBrowser(application *tview.Application) *SnapshotBrowserComponen
 		application:            application,
 		currentSnapshots:       []*zfs.Snapshot{},
 		selectedSnapshotMemory: uiutil.NewSelectionMemory[data.SnapshotBrowserEntry](),
+		retentionDecisions:     map[string]*retention.Decision{},
+		snapshotOrigins:        map[string]*autosnap.Origin{},
+		hiddenTools:            map[string]bool{},
+	}
+
+	if path := configuration.CurrentConfig.Snapshot.SanoidConfig; path != "" {
+		sanoidConfig, err := autosnap.LoadSanoidConfig(path)
+		if err != nil {
+			logging.Debug("Not using sanoid configuration: %s", err.Error())
+		} else {
+			snapshotBrowser.sanoidConfig = sanoidConfig
+		}
+	}
+
+	if retentionConfig := configuration.CurrentConfig.Retention; retentionConfig.IsEnabled() {
+		policy, err := retention.NewPolicy(retentionConfig)
+		if err != nil {
+			logging.Error("Failed to create retention policy: %s", err.Error())
+		} else {
+			snapshotBrowser.retentionPolicy = policy
+		}
 	}
 
 	snapshotBrowser.diffLoader = uiutil.NewDebouncedLoader(application, func() {
@@ -139,6 +235,10 @@ func NewSnapshotBrowser(application *tview.Application) *SnapshotBrowserComponen
 
 			snapshotBrowser.hostDataset = result.dataset
 			snapshotBrowser.currentSnapshots = result.snapshots
+			snapshotBrowser.updateSnapshotMonitor()
+			snapshotBrowser.indexSnapshotsInBackground()
+			snapshotBrowser.updateRetentionDecisions()
+			snapshotBrowser.updateSnapshotOrigins()
 			snapshotBrowser.updateCurrentSnapshotEntries(true)
 
 			if snapshotBrowser.selectLatestOnNextLoad {
@@ -169,6 +269,18 @@ func (snapshotBrowser *SnapshotBrowserComponent) setupTable() {
 			snapshotBrowser.openColumnSelectionDialog()
 			return nil
 		}
+		if (key == tcell.KeyEnter || event.Rune() == 'b' || event.Rune() == 'd') && !snapshotBrowser.supportsZfsActions() {
+			snapshotBrowser.showReadOnlyMessage()
+			return nil
+		}
+		if event.Rune() == 'b' {
+			snapshotBrowser.openBookmarksDialog()
+			return nil
+		}
+		if event.Rune() == 't' {
+			snapshotBrowser.openToolFilterDialog()
+			return nil
+		}
 		if snapshotBrowser.GetSelection() != nil {
 			if key == tcell.KeyEnter {
 				if snapshotBrowser.HasMultiSelection() {
@@ -193,8 +305,17 @@ func (snapshotBrowser *SnapshotBrowserComponent) setupTable() {
 		return event
 	})
 
-	snapshotBrowser.tableContainer.SetColumnSpec(tableColumns, columnDate, true)
-	snapshotBrowser.tableContainer.SetActiveColumns(initialActiveTableColumns)
+	userPropertyColumns := newUserPropertyColumns(configuration.CurrentConfig.Snapshot.UserProperties)
+	snapshotBrowser.columns = append(slices.Clone(tableColumns), userPropertyColumns...)
+	snapshotBrowser.tableContainer.SetColumnSpec(snapshotBrowser.columns, columnDate, true)
+	activeColumns := slices.Clone(initialActiveTableColumns)
+	if snapshotBrowser.retentionPolicy != nil {
+		activeColumns = append(activeColumns, columnRetention)
+	}
+	if len(configuration.CurrentConfig.Replicas) > 0 {
+		activeColumns = append(activeColumns, columnSource)
+	}
+	snapshotBrowser.tableContainer.SetActiveColumns(append(activeColumns, userPropertyColumns...))
 	snapshotBrowser.tableContainer.SetSelectionChangedCallback(func(entry *data.SnapshotBrowserEntry) {
 		if snapshotBrowser.isRestoringSelection {
 			return
@@ -279,12 +400,7 @@ func (snapshotBrowser *SnapshotBrowserComponent) reloadSnapshotEntries(force boo
 			return snapshotLoadResult{dataset: ds, snapshots: capturedSnapshots}, nil
 		}
 
-		snapshots, err := ds.GetSnapshots()
-		if err != nil {
-			return snapshotLoadResult{dataset: ds}, err
-		}
-
-		return snapshotLoadResult{dataset: ds, snapshots: snapshots}, nil
+		return loadSnapshots(ds, nil)
 	}
 
 	if !force && isSubpath {
@@ -293,6 +409,158 @@ func (snapshotBrowser *SnapshotBrowserComponent) reloadSnapshotEntries(force boo
 		snapshotBrowser.loader.Load(loadFunc)
 	}
 }
+
+// loadSnapshots lists the snapshots of the given dataset, including those of its replicas.
+// previous - snapshots of an earlier load, which are reused if they still exist
+func loadSnapshots(dataset *zfs.Dataset, previous []*zfs.Snapshot) (snapshotLoadResult, error) {
+	snapshots, warnings, err := dataset.RefreshSnapshotsWithReplicas(previous)
+	if err != nil {
+		return snapshotLoadResult{dataset: dataset}, err
+	}
+	for _, warning := range warnings {
+		logging.Warning("Skipped replica snapshots: %s", warning.Error())
+	}
+
+	err = zfs.LoadUserProperties(snapshots, configuration.CurrentConfig.Snapshot.GetEditableUserProperties())
+	if err != nil {
+		logging.Error("Failed to load snapshot user properties: %s", err.Error())
+	}
+
+	return snapshotLoadResult{dataset: dataset, snapshots: snapshots}, nil
+}
+
+// updateSnapshotMonitor watches the snapshot directories of the current dataset and its replicas, if they have changed
+func (snapshotBrowser *SnapshotBrowserComponent) updateSnapshotMonitor() {
+	snapshotsDirs := snapshotBrowser.getSnapshotsDirs()
+	if snapshotBrowser.snapshotMonitor != nil && slices.Equal(snapshotBrowser.snapshotMonitor.dirs, snapshotsDirs) {
+		return
+	}
+	if snapshotBrowser.snapshotMonitor != nil {
+		snapshotBrowser.snapshotMonitor.stop()
+		snapshotBrowser.snapshotMonitor = nil
+	}
+	if len(snapshotsDirs) == 0 {
+		return
+	}
+
+	var monitor *snapshotMonitor
+	monitor = startSnapshotMonitor(snapshotsDirs, func() {
+		snapshotBrowser.application.QueueUpdate(func() {
+			// ignore changes which were already queued when the dataset changed
+			if snapshotBrowser.snapshotMonitor != monitor {
+				return
+			}
+			snapshotBrowser.reloadChangedSnapshots()
+		})
+	})
+	snapshotBrowser.snapshotMonitor = monitor
+}
+
+// getSnapshotsDirs returns the snapshot directories of the current dataset and its replicas
+func (snapshotBrowser *SnapshotBrowserComponent) getSnapshotsDirs() []string {
+	if snapshotBrowser.hostDataset == nil {
+		return nil
+	}
+	var dirs []string
+	if dir := snapshotBrowser.hostDataset.GetSnapshotsDir(); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	// inaccessible replicas are already reported when their snapshots are listed
+	replicas, _ := snapshotBrowser.hostDataset.GetReplicas()
+	for _, replica := range replicas {
+		if dir := replica.GetSnapshotsDir(); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// indexSnapshotsInBackground adds the current snapshots to the index, if background indexing is enabled
+func (snapshotBrowser *SnapshotBrowserComponent) indexSnapshotsInBackground() {
+	indexConfig := configuration.CurrentConfig.Index
+	idx := index.Get()
+	if !indexConfig.Background || idx == nil || len(snapshotBrowser.currentSnapshots) == 0 {
+		return
+	}
+	idx.UpdateInBackground(snapshotBrowser.currentSnapshots, index.Options{Hash: indexConfig.Hash})
+}
+
+// reloadChangedSnapshots updates the snapshots of the current dataset after snapshots have been created or destroyed,
+// without resetting the selection
+func (snapshotBrowser *SnapshotBrowserComponent) reloadChangedSnapshots() {
+	dataset := snapshotBrowser.hostDataset
+	if dataset == nil {
+		return
+	}
+	previous := snapshotBrowser.currentSnapshots
+	snapshotBrowser.loader.LoadQuietly(func(ctx context.Context) (snapshotLoadResult, error) {
+		return loadSnapshots(dataset, previous)
+	})
+}
+
+// updateRetentionDecisions evaluates the retention policy for the snapshots of the current dataset
+func (snapshotBrowser *SnapshotBrowserComponent) updateRetentionDecisions() {
+	snapshotBrowser.retentionDecisions = map[string]*retention.Decision{}
+	if snapshotBrowser.retentionPolicy == nil {
+		return
+	}
+	for _, decision := range snapshotBrowser.retentionPolicy.Evaluate(snapshotBrowser.currentSnapshots) {
+		snapshotBrowser.retentionDecisions[decision.Snapshot.Name] = decision
+	}
+}
+
+// updateSnapshotOrigins determines which snapshot tool created each snapshot of the current dataset
+func (snapshotBrowser *SnapshotBrowserComponent) updateSnapshotOrigins() {
+	snapshotBrowser.snapshotOrigins = map[string]*autosnap.Origin{}
+	if snapshotBrowser.hostDataset == nil {
+		return
+	}
+	datasetName := snapshotBrowser.hostDataset.GetName()
+	for _, snapshot := range snapshotBrowser.currentSnapshots {
+		if origin, ok := autosnap.Resolve(datasetName, snapshot.Name, snapshotBrowser.sanoidConfig); ok {
+			snapshotBrowser.snapshotOrigins[snapshot.Name] = origin
+		}
+	}
+}
+
+// getSnapshotTool returns the tool which created the given snapshot, or otherSnapshotTool
+func (snapshotBrowser *SnapshotBrowserComponent) getSnapshotTool(snapshot *zfs.Snapshot) string {
+	if origin, ok := snapshotBrowser.snapshotOrigins[snapshot.Name]; ok {
+		return origin.Tool
+	}
+	return otherSnapshotTool
+}
+
+// getVisibleSnapshots returns the snapshots of the current dataset which are not hidden by the tool filter
+func (snapshotBrowser *SnapshotBrowserComponent) getVisibleSnapshots() []*zfs.Snapshot {
+	if len(snapshotBrowser.hiddenTools) == 0 {
+		return snapshotBrowser.currentSnapshots
+	}
+	return slices.DeleteFunc(slices.Clone(snapshotBrowser.currentSnapshots), func(snapshot *zfs.Snapshot) bool {
+		return snapshotBrowser.hiddenTools[snapshotBrowser.getSnapshotTool(snapshot)]
+	})
+}
+
+// openToolFilterDialog allows to hide the snapshots of individual snapshot tools
+func (snapshotBrowser *SnapshotBrowserComponent) openToolFilterDialog() {
+	tools := []string{autosnap.ToolSanoid, autosnap.ToolZfsAutoSnapshot, otherSnapshotTool}
+
+	onComplete := func(d *dialog.InputDialog, values dialog.InputDialogValues, err error) {
+		d.Close()
+		hiddenTools := map[string]bool{}
+		for _, tool := range tools {
+			if !values.GetBool(tool) {
+				hiddenTools[tool] = true
+			}
+		}
+		snapshotBrowser.hiddenTools = hiddenTools
+		snapshotBrowser.updateCurrentSnapshotEntries(true)
+	}
+
+	filterDialog := dialog.NewSnapshotToolFilterDialog(snapshotBrowser.application, tools, snapshotBrowser.hiddenTools, onComplete)
+	snapshotBrowser.showDialog(filterDialog, nil)
+}
+
 func (snapshotBrowser *SnapshotBrowserComponent) updateCurrentSnapshotEntries(quiet bool) {
 	snapshotBrowser.updateTableEntries()
 	snapshotBrowser.restoreSelectionForDataset(quiet)
@@ -309,7 +577,7 @@ func (snapshotBrowser *SnapshotBrowserComponent) cancelDiffCalculation() {
 }
 
 func (snapshotBrowser *SnapshotBrowserComponent) startAsyncDiffCalculation() {
-	snapshots := snapshotBrowser.currentSnapshots
+	snapshots := snapshotBrowser.getVisibleSnapshots()
 	fileEntry := snapshotBrowser.currentFileEntry
 
 	if len(snapshots) == 0 {
@@ -331,7 +599,12 @@ func (snapshotBrowser *SnapshotBrowserComponent) startAsyncDiffCalculation() {
 		}
 	}
 
-	if !sameSnapshots {
+	if sameSnapshots {
+		for _, entry := range currentEntries {
+			entry.Retention = snapshotBrowser.retentionDecisions[entry.Snapshot.Name]
+			entry.Origin = snapshotBrowser.snapshotOrigins[entry.Snapshot.Name]
+		}
+	} else {
 		previousDiffs := make(map[string]diff_state.DiffState)
 		for _, entry := range currentEntries {
 			if entry != nil {
@@ -349,6 +622,8 @@ func (snapshotBrowser *SnapshotBrowserComponent) startAsyncDiffCalculation() {
 				Snapshot:  snap,
 				DiffState: diffState,
 				IsLoading: true,
+				Retention: snapshotBrowser.retentionDecisions[snap.Name],
+				Origin:    snapshotBrowser.snapshotOrigins[snap.Name],
 			}
 		}
 		snapshotBrowser.tableContainer.SetData(initialEntries)
@@ -567,13 +842,24 @@ func (snapshotBrowser *SnapshotBrowserComponent) openActionDialog(selection *dat
 		return
 	}
 
-	var createdName string
+	var clones []*zfs.SnapshotClone
+	var bookmarks []*zfs.Bookmark
+	var rollbackImpact *zfs.RollbackImpact
+	var rollbackChanges *zfs.WorkingCopyChanges
 
 	asyncWork := func(d *dialog.SelectionDialog, action dialog.DialogActionId) error {
 		switch action {
-		case dialog.SnapshotDialogCreateSnapshotActionId:
-			name, err := snapshotBrowser.createSnapshot(selection)
-			createdName = name
+		case dialog.SnapshotDialogShowClonesActionId:
+			result, err := selection.Snapshot.ListClones()
+			clones = result
+			return err
+		case dialog.SnapshotDialogCreateBookmarkActionId:
+			result, err := selection.Snapshot.ParentDataset.GetBookmarks()
+			bookmarks = result
+			return err
+		case dialog.SnapshotDialogRollbackDatasetActionId:
+			var err error
+			rollbackImpact, rollbackChanges, err = snapshotBrowser.computeRollbackPreview(selection)
 			return err
 		case dialog.SnapshotDialogDestroySnapshotActionId:
 			return snapshotBrowser.destroySnapshot(selection, false, false)
@@ -584,6 +870,33 @@ func (snapshotBrowser *SnapshotBrowserComponent) openActionDialog(selection *dat
 	}
 
 	onComplete := func(d *dialog.SelectionDialog, option *dialog.DialogOption, err error) {
+		if err == nil {
+			// Actions which lead to a follow-up dialog
+			switch option.Id {
+			case dialog.SnapshotDialogCreateSnapshotActionId:
+				d.Chain(snapshotBrowser.OpenCreateSnapshotDialog)
+				return
+			case dialog.SnapshotDialogRenameSnapshotActionId:
+				d.Chain(func() { snapshotBrowser.openRenameDialog(selection) })
+				return
+			case dialog.SnapshotDialogEditNoteActionId:
+				d.Chain(func() { snapshotBrowser.openEditNoteDialog(selection) })
+				return
+			case dialog.SnapshotDialogCloneSnapshotActionId:
+				d.Chain(func() { snapshotBrowser.openCloneDialog(selection) })
+				return
+			case dialog.SnapshotDialogShowClonesActionId:
+				d.Chain(func() { snapshotBrowser.openClonesDialog(selection, clones) })
+				return
+			case dialog.SnapshotDialogCreateBookmarkActionId:
+				d.Chain(func() { snapshotBrowser.openCreateBookmarkDialog(selection, bookmarks) })
+				return
+			case dialog.SnapshotDialogRollbackDatasetActionId:
+				d.Chain(func() { snapshotBrowser.openRollbackDialog(selection, rollbackImpact, rollbackChanges) })
+				return
+			}
+		}
+
 		d.Close() // Dismiss selection menu
 
 		if err != nil {
@@ -595,12 +908,6 @@ func (snapshotBrowser *SnapshotBrowserComponent) openActionDialog(selection *dat
 
 		// Handle downstream states depending on what succeeded
 		switch option.Id {
-		case dialog.SnapshotDialogCreateSnapshotActionId:
-			snapshotBrowser.selectLatestOnNextLoad = true
-
-			successDialog := dialog.NewSuccessDialog(snapshotBrowser.application, "Snapshot Created", fmt.Sprintf("Snapshot '%s' created successfully.", createdName))
-			snapshotBrowser.showDialog(successDialog, nil)
-
 		case dialog.SnapshotDialogDestroySnapshotActionId, dialog.SnapshotDialogDestroySnapshotRecursivelyActionId:
 			successDialog := dialog.NewSuccessDialog(snapshotBrowser.application, "Snapshot Destroyed", fmt.Sprintf("Snapshot '%s' destroyed.", selection.Snapshot.Name))
 			snapshotBrowser.showDialog(successDialog, nil)
@@ -613,59 +920,415 @@ func (snapshotBrowser *SnapshotBrowserComponent) openActionDialog(selection *dat
 	snapshotBrowser.showDialog(actionDialog, nil)
 }
 
+// OpenCreateSnapshotDialog asks for the details of a new snapshot of the current dataset and creates it
+func (snapshotBrowser *SnapshotBrowserComponent) OpenCreateSnapshotDialog() {
+	dataset := snapshotBrowser.hostDataset
+	if dataset == nil {
+		snapshotBrowser.showStatusMessage(status_message.NewErrorStatusMessage("No dataset selected"))
+		return
+	}
+	if !dataset.IsZfs() {
+		snapshotBrowser.showReadOnlyMessage()
+		return
+	}
+
+	var name string
+
+	asyncWork := func(d *dialog.InputDialog, values dialog.InputDialogValues) error {
+		name = strings.TrimSpace(values[dialog.CreateSnapshotDialogNameFieldId])
+		properties, err := dialog.ParseSnapshotProperties(values[dialog.CreateSnapshotDialogPropertiesFieldId])
+		if err != nil {
+			return err
+		}
+		return dataset.CreateSnapshot(name, values.GetBool(dialog.CreateSnapshotDialogRecursiveFieldId), properties)
+	}
+
+	onComplete := func(d *dialog.InputDialog, values dialog.InputDialogValues, err error) {
+		if err != nil {
+			logging.Error("Failed to create snapshot: %s", err.Error())
+			d.Chain(func() {
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Snapshot Creation Failed", err)
+				snapshotBrowser.showDialog(errDialog, nil)
+			})
+			return
+		}
+		d.Close()
+
+		snapshotBrowser.selectLatestOnNextLoad = true
+		snapshotBrowser.showStatusMessage(status_message.NewSuccessStatusMessage(fmt.Sprintf("Snapshot '%s' created.", name)))
+		snapshotBrowser.Refresh(true)
+	}
+
+	createDialog := dialog.NewCreateSnapshotDialog(
+		snapshotBrowser.application,
+		dataset.GetName(),
+		configuration.CurrentConfig.Snapshot.GetNameTemplate(),
+		asyncWork,
+		onComplete,
+	)
+	snapshotBrowser.showDialog(createDialog, nil)
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) openRenameDialog(selection *data.SnapshotBrowserEntry) {
+	var existingNames []string
+	for _, snapshot := range snapshotBrowser.currentSnapshots {
+		existingNames = append(existingNames, snapshot.Name)
+	}
+	oldName := selection.Snapshot.Name
+
+	asyncWork := func(d *dialog.InputDialog, values dialog.InputDialogValues) error {
+		return selection.Snapshot.Rename(strings.TrimSpace(values[dialog.RenameSnapshotDialogNameFieldId]))
+	}
+
+	onComplete := func(d *dialog.InputDialog, values dialog.InputDialogValues, err error) {
+		if err != nil {
+			logging.Error("Failed to rename snapshot: %s", err.Error())
+			d.Chain(func() {
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Rename Failed", err)
+				snapshotBrowser.showDialog(errDialog, nil)
+			})
+			return
+		}
+		d.Close()
+
+		// The entry has been renamed in place, so remembering it makes the
+		// reload below select the snapshot by its new name.
+		snapshotBrowser.tableContainer.UpdateEntry(selection)
+		snapshotBrowser.rememberSelectionForDataset(selection)
+		snapshotBrowser.updateTableTitle()
+		snapshotBrowser.showStatusMessage(status_message.NewSuccessStatusMessage(fmt.Sprintf("Snapshot '%s' renamed to '%s'.", oldName, selection.Snapshot.Name)))
+		snapshotBrowser.Refresh(true)
+	}
+
+	renameDialog := dialog.NewRenameSnapshotDialog(snapshotBrowser.application, selection, existingNames, asyncWork, onComplete)
+	snapshotBrowser.showDialog(renameDialog, nil)
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) openEditNoteDialog(selection *data.SnapshotBrowserEntry) {
+	asyncWork := func(d *dialog.InputDialog, values dialog.InputDialogValues) error {
+		changed := dialog.GetChangedUserProperties(selection.Snapshot, values)
+		for _, property := range slices.Sorted(maps.Keys(changed)) {
+			if err := selection.Snapshot.SetUserProperty(property, changed[property]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	onComplete := func(d *dialog.InputDialog, values dialog.InputDialogValues, err error) {
+		if err != nil {
+			logging.Error("Failed to edit snapshot note: %s", err.Error())
+			d.Chain(func() {
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Edit Note Failed", err)
+				snapshotBrowser.showDialog(errDialog, nil)
+			})
+			return
+		}
+		d.Close()
+
+		snapshotBrowser.tableContainer.UpdateEntry(selection)
+		snapshotBrowser.showStatusMessage(status_message.NewSuccessStatusMessage(fmt.Sprintf("Note of '%s' updated.", selection.Snapshot.Name)))
+	}
+
+	noteDialog := dialog.NewEditSnapshotNoteDialog(
+		snapshotBrowser.application,
+		selection,
+		configuration.CurrentConfig.Snapshot.GetEditableUserProperties(),
+		asyncWork,
+		onComplete,
+	)
+	snapshotBrowser.showDialog(noteDialog, nil)
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) openCloneDialog(selection *data.SnapshotBrowserEntry) {
+	var clone *zfs.SnapshotClone
+
+	asyncWork := func(d *dialog.InputDialog, values dialog.InputDialogValues) (err error) {
+		clone, err = selection.Snapshot.Clone(
+			strings.TrimSpace(values[dialog.CloneSnapshotDialogNameFieldId]),
+			strings.TrimSpace(values[dialog.CloneSnapshotDialogMountpointFieldId]),
+		)
+		return err
+	}
+
+	onComplete := func(d *dialog.InputDialog, values dialog.InputDialogValues, err error) {
+		if err != nil {
+			logging.Error("Failed to clone snapshot: %s", err.Error())
+			d.Chain(func() {
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Clone Failed", err)
+				snapshotBrowser.showDialog(errDialog, nil)
+			})
+			return
+		}
+
+		d.Chain(func() { snapshotBrowser.openCloneCreatedDialog(clone) })
+		snapshotBrowser.Refresh(true)
+	}
+
+	cloneDialog := dialog.NewCloneSnapshotDialog(snapshotBrowser.application, selection, asyncWork, onComplete)
+	snapshotBrowser.showDialog(cloneDialog, nil)
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) openCloneCreatedDialog(clone *zfs.SnapshotClone) {
+	onComplete := func(d *dialog.SelectionDialog, option *dialog.DialogOption, err error) {
+		d.Close()
+		if option.Id == dialog.CloneDialogOpenMountpointActionId {
+			snapshotBrowser.emit(RequestOpenPathEvent{Path: clone.Mountpoint})
+		}
+	}
+
+	createdDialog := dialog.NewCloneCreatedDialog(snapshotBrowser.application, clone, onComplete)
+	snapshotBrowser.showDialog(createdDialog, nil)
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) openClonesDialog(selection *data.SnapshotBrowserEntry, clones []*zfs.SnapshotClone) {
+	onComplete := func(d *dialog.SelectionDialog, option *dialog.DialogOption, err error) {
+		index := int(option.Id) - 1
+		if index < 0 || index >= len(clones) {
+			d.Close()
+			return
+		}
+
+		clone := clones[index]
+		if !clone.IsBrowsable() {
+			d.Chain(func() {
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Cannot Open Clone", fmt.Errorf("clone '%s' is not mounted", clone.Name))
+				snapshotBrowser.showDialog(errDialog, nil)
+			})
+			return
+		}
+
+		d.Close()
+		snapshotBrowser.emit(RequestOpenPathEvent{Path: clone.Mountpoint})
+	}
+
+	clonesDialog := dialog.NewSnapshotClonesDialog(snapshotBrowser.application, selection, clones, onComplete)
+	snapshotBrowser.showDialog(clonesDialog, nil)
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) openCreateBookmarkDialog(selection *data.SnapshotBrowserEntry, bookmarks []*zfs.Bookmark) {
+	var existingNames []string
+	for _, bookmark := range bookmarks {
+		existingNames = append(existingNames, bookmark.Name)
+	}
+
+	var bookmark *zfs.Bookmark
+	asyncWork := func(d *dialog.InputDialog, values dialog.InputDialogValues) error {
+		var err error
+		bookmark, err = selection.Snapshot.CreateBookmark(values[dialog.CreateBookmarkDialogNameFieldId])
+		return err
+	}
+
+	onComplete := func(d *dialog.InputDialog, values dialog.InputDialogValues, err error) {
+		if err != nil {
+			logging.Error("Failed to create bookmark: %s", err.Error())
+			d.Chain(func() {
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Bookmark Creation Failed", err)
+				snapshotBrowser.showDialog(errDialog, nil)
+			})
+			return
+		}
+		d.Close()
+		snapshotBrowser.showStatusMessage(status_message.NewSuccessStatusMessage(fmt.Sprintf("Bookmark '%s' created.", bookmark.FullName)))
+	}
+
+	createDialog := dialog.NewCreateBookmarkDialog(snapshotBrowser.application, selection, existingNames, asyncWork, onComplete)
+	snapshotBrowser.showDialog(createDialog, nil)
+}
+
+// openBookmarksDialog lists the bookmarks of the current dataset
+func (snapshotBrowser *SnapshotBrowserComponent) openBookmarksDialog() {
+	dataset := snapshotBrowser.hostDataset
+	if dataset == nil {
+		snapshotBrowser.showStatusMessage(status_message.NewErrorStatusMessage("No dataset selected"))
+		return
+	}
+
+	go func() {
+		bookmarks, err := dataset.GetBookmarks()
+		snapshotBrowser.application.QueueUpdateDraw(func() {
+			if err != nil {
+				logging.Error("Failed to list bookmarks: %s", err.Error())
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Cannot List Bookmarks", err)
+				snapshotBrowser.showDialog(errDialog, nil)
+				return
+			}
+
+			onComplete := func(d *dialog.SelectionDialog, option *dialog.DialogOption, err error) {
+				index := int(option.Id) - 1
+				if index < 0 || index >= len(bookmarks) {
+					d.Close()
+					return
+				}
+				d.Chain(func() { snapshotBrowser.openDestroyBookmarkDialog(bookmarks[index]) })
+			}
+
+			bookmarksDialog := dialog.NewBookmarksDialog(snapshotBrowser.application, dataset.GetName(), bookmarks, onComplete)
+			snapshotBrowser.showDialog(bookmarksDialog, nil)
+		})
+	}()
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) openDestroyBookmarkDialog(bookmark *zfs.Bookmark) {
+	asyncWork := func(d *dialog.SelectionDialog, action dialog.DialogActionId) error {
+		if action == dialog.DestroyBookmarkDialogDestroyActionId {
+			return bookmark.Destroy()
+		}
+		return nil
+	}
+
+	onComplete := func(d *dialog.SelectionDialog, option *dialog.DialogOption, err error) {
+		if err != nil {
+			logging.Error("Failed to destroy bookmark: %s", err.Error())
+			d.Chain(func() {
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Operation Failed", err)
+				snapshotBrowser.showDialog(errDialog, nil)
+			})
+			return
+		}
+		d.Close()
+		if option.Id == dialog.DestroyBookmarkDialogDestroyActionId {
+			snapshotBrowser.showStatusMessage(status_message.NewSuccessStatusMessage(fmt.Sprintf("Bookmark '%s' destroyed.", bookmark.FullName)))
+		}
+	}
+
+	destroyDialog := dialog.NewDestroyBookmarkDialog(snapshotBrowser.application, bookmark, asyncWork, onComplete)
+	snapshotBrowser.showDialog(destroyDialog, nil)
+}
+
+// computeRollbackPreview determines what would be lost when rolling back to the given snapshot.
+// The working copy comparison is limited in time, since it has to visit every file of the dataset.
+func (snapshotBrowser *SnapshotBrowserComponent) computeRollbackPreview(selection *data.SnapshotBrowserEntry) (*zfs.RollbackImpact, *zfs.WorkingCopyChanges, error) {
+	impact, err := selection.Snapshot.GetRollbackImpact()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackPreviewTimeout)
+	defer cancel()
+	changes, err := selection.Snapshot.DiffWorkingCopy(ctx, rollbackPreviewMaxExamples)
+	if err != nil {
+		logging.Error("Failed to compare working copy with snapshot: %s", err.Error())
+		changes = nil
+	}
+
+	return impact, changes, nil
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) openRollbackDialog(selection *data.SnapshotBrowserEntry, impact *zfs.RollbackImpact, changes *zfs.WorkingCopyChanges) {
+	datasetName := selection.Snapshot.ParentDataset.GetName()
+
+	asyncWork := func(d *dialog.InputDialog, values dialog.InputDialogValues) error {
+		return selection.Snapshot.RollbackConfirmed(impact)
+	}
+
+	onComplete := func(d *dialog.InputDialog, values dialog.InputDialogValues, err error) {
+		d.Chain(func() {
+			if err != nil {
+				logging.Error("Failed to rollback dataset: %s", err.Error())
+				errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Rollback Failed", err)
+				snapshotBrowser.showDialog(errDialog, nil)
+				return
+			}
+			successDialog := dialog.NewSuccessDialog(snapshotBrowser.application, "Dataset Rolled Back", fmt.Sprintf("'%s' has been rolled back to '%s'.", datasetName, selection.Snapshot.Name))
+			snapshotBrowser.showDialog(successDialog, nil)
+		})
+		// the snapshots which would have been destroyed are shown, if they have changed in the meantime
+		if err == nil || errors.Is(err, zfs.ErrRollbackImpactChanged) {
+			snapshotBrowser.Refresh(true)
+		}
+	}
+
+	rollbackDialog := dialog.NewRollbackDatasetDialog(snapshotBrowser.application, selection, datasetName, impact, changes, asyncWork, onComplete)
+	snapshotBrowser.showDialog(rollbackDialog, nil)
+}
+
 func (snapshotBrowser *SnapshotBrowserComponent) openMultiActionDialog(entries []*data.SnapshotBrowserEntry) {
 	if len(entries) <= 0 {
 		return
 	}
 
+	var estimate *zfs.DestroyEstimate
+	var estimateErr error
+
 	asyncWork := func(d *dialog.SelectionDialog, action dialog.DialogActionId) error {
 		switch action {
 		case dialog.MultiSnapshotDialogDestroySnapshotActionId:
-			for _, entry := range entries {
-				if err := snapshotBrowser.destroySnapshot(entry, false, false); err != nil {
-					logging.Error("Failed to destroy snapshot: %s", err.Error())
-					return err // Break early on failure
-				}
-			}
+			// a failed estimate is shown in the confirm dialog instead of preventing the destroy
+			estimate, estimateErr = zfs.EstimateDestroy(snapshotsOf(entries), false)
 		case dialog.MultiSnapshotDialogDestroySnapshotRecursivelyActionId:
-			for _, entry := range entries {
-				if err := snapshotBrowser.destroySnapshot(entry, true, true); err != nil {
-					logging.Error("Failed to destroy snapshot: %s", err.Error())
-					return err // Break early on failure
-				}
-			}
+			estimate, estimateErr = zfs.EstimateDestroy(snapshotsOf(entries), true)
 		}
 		return nil
 	}
 
 	onComplete := func(d *dialog.SelectionDialog, option *dialog.DialogOption, err error) {
+		switch option.Id {
+		case dialog.MultiSnapshotDialogDestroySnapshotActionId:
+			d.Chain(func() { snapshotBrowser.openDestroySnapshotsDialog(entries, false, estimate, estimateErr) })
+			return
+		case dialog.MultiSnapshotDialogDestroySnapshotRecursivelyActionId:
+			d.Chain(func() { snapshotBrowser.openDestroySnapshotsDialog(entries, true, estimate, estimateErr) })
+			return
+		}
+
 		d.Close()
 
-		// Always clear selection states after an action choice completes
-		if option.Id == dialog.MultiSnapshotDialogClearSelectionActionId || option.Id == dialog.MultiSnapshotDialogDestroySnapshotActionId || option.Id == dialog.MultiSnapshotDialogDestroySnapshotRecursivelyActionId {
+		if option.Id == dialog.MultiSnapshotDialogClearSelectionActionId {
 			snapshotBrowser.ClearMultiSelection()
 		}
+	}
 
-		if err != nil {
-			errDialog := dialog.NewErrorDialog(snapshotBrowser.application, "Batch Destroy Failed", err)
-			snapshotBrowser.showDialog(errDialog, nil)
-			return
+	actionDialog := dialog.NewMultiSnapshotActionDialog(snapshotBrowser.application, entries, asyncWork, onComplete)
+	snapshotBrowser.showDialog(actionDialog, nil)
+}
+
+// openDestroySnapshotsDialog confirms and destroys the given snapshots, reporting the outcome for each of them
+func (snapshotBrowser *SnapshotBrowserComponent) openDestroySnapshotsDialog(entries []*data.SnapshotBrowserEntry, recursive bool, estimate *zfs.DestroyEstimate, estimateErr error) {
+	var results []*zfs.DestroyResult
+
+	asyncWork := func(d *dialog.SelectionDialog, action dialog.DialogActionId) error {
+		if action == dialog.DestroySnapshotsDialogDestroyActionId {
+			results = zfs.DestroySnapshots(snapshotsOf(entries), recursive)
 		}
+		return nil
+	}
 
-		if option.Id == dialog.MultiSnapshotDialogDestroySnapshotActionId || option.Id == dialog.MultiSnapshotDialogDestroySnapshotRecursivelyActionId {
-			successDialog := dialog.NewSuccessDialog(snapshotBrowser.application, "Snapshots Destroyed", fmt.Sprintf("Successfully destroyed %d snapshots.", len(entries)))
-			snapshotBrowser.showDialog(successDialog, nil)
+	onComplete := func(d *dialog.SelectionDialog, option *dialog.DialogOption, err error) {
+		d.Close()
+		if option.Id != dialog.DestroySnapshotsDialogDestroyActionId {
+			return
 		}
+
+		snapshotBrowser.ClearMultiSelection()
+		reportDialog := dialog.NewDestroySnapshotsReportDialog(snapshotBrowser.application, results)
+		snapshotBrowser.showDialog(reportDialog, nil)
+		snapshotBrowser.Refresh(true)
 	}
 
-	actionDialog := dialog.NewMultiSnapshotActionDialog(snapshotBrowser.application, entries, asyncWork, onComplete)
-	snapshotBrowser.showDialog(actionDialog, nil)
+	confirmDialog := dialog.NewDestroySnapshotsDialog(snapshotBrowser.application, entries, recursive, estimate, estimateErr, asyncWork, onComplete)
+	snapshotBrowser.showDialog(confirmDialog, nil)
+}
+
+func snapshotsOf(entries []*data.SnapshotBrowserEntry) []*zfs.Snapshot {
+	snapshots := make([]*zfs.Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		snapshots = append(snapshots, entry.Snapshot)
+	}
+	return snapshots
 }
 
 func (snapshotBrowser *SnapshotBrowserComponent) openDeleteDialog(selection *data.SnapshotBrowserEntry) {
 	if selection == nil {
 		return
 	}
+	if selection.Snapshot.IsFromReplica() {
+		// replicas are usually backups with a long retention, they are only browsed and restored from
+		message := fmt.Sprintf("'%s' belongs to the replica '%s', which cannot be modified from here",
+			selection.Snapshot.Name, selection.Snapshot.ParentDataset.GetName())
+		snapshotBrowser.showStatusMessage(status_message.NewInfoStatusMessage(message))
+		return
+	}
 
 	asyncWork := func(d *dialog.SelectionDialog, action dialog.DialogActionId) error {
 		if action == dialog.DeleteSnapshotDialogDeleteSnapshotActionId {
@@ -702,7 +1365,7 @@ func (snapshotBrowser *SnapshotBrowserComponent) openColumnSelectionDialog() {
 	d := dialog.NewColumnSelectionDialog(
 		snapshotBrowser.application,
 		"Configure Snapshot Columns",
-		tableColumns,
+		snapshotBrowser.columns,
 		slices.Clone(currentActive),
 		func(activeColumns []*table.Column) {
 			snapshotBrowser.tableContainer.SetActiveColumns(activeColumns)
@@ -711,15 +1374,6 @@ func (snapshotBrowser *SnapshotBrowserComponent) openColumnSelectionDialog() {
 	snapshotBrowser.showDialog(d, nil)
 }
 
-func (snapshotBrowser *SnapshotBrowserComponent) createSnapshot(entry *data.SnapshotBrowserEntry) (name string, err error) {
-	name = fmt.Sprintf("zfh-%s", time.Now().Format(zfs.SnapshotTimeFormat))
-	err = entry.Snapshot.ParentDataset.CreateSnapshot(name)
-	if err != nil {
-		return "", err
-	}
-	return name, nil
-}
-
 func (snapshotBrowser *SnapshotBrowserComponent) destroySnapshot(entry *data.SnapshotBrowserEntry, recursive bool, dependantClones bool) (err error) {
 	snapshot := entry.Snapshot
 	return snapshot.Destroy(recursive, dependantClones)
@@ -744,6 +1398,17 @@ func (snapshotBrowser *SnapshotBrowserComponent) SelectLatest() {
 	snapshotBrowser.tableContainer.Select(latestEntry)
 }
 
+// supportsZfsActions reports whether the snapshots of the current dataset can be managed,
+// which is only the case for zfs datasets. Snapshots of other providers can only be browsed and restored from.
+func (snapshotBrowser *SnapshotBrowserComponent) supportsZfsActions() bool {
+	return snapshotBrowser.hostDataset == nil || snapshotBrowser.hostDataset.IsZfs()
+}
+
+func (snapshotBrowser *SnapshotBrowserComponent) showReadOnlyMessage() {
+	message := fmt.Sprintf("Snapshots provided by %s are read-only", snapshotBrowser.hostDataset.GetProvider().Name())
+	snapshotBrowser.showStatusMessage(status_message.NewInfoStatusMessage(message))
+}
+
 func (snapshotBrowser *SnapshotBrowserComponent) showStatusMessage(message *status_message.StatusMessage) {
 	snapshotBrowser.emit(StatusMessageEvent{
 		Message: message,
@@ -770,13 +1435,19 @@ func (snapshotBrowser *SnapshotBrowserComponent) GetShortcutMap() []shortcut_hel
 		uiutil.TableComponentShortcutPageUp,
 		uiutil.TableComponentShortcutPageDown,
 		uiutil.TableComponentShortcutColumns,
+		snapshotBrowserShortcutTools,
+	}
+	if snapshotBrowser.supportsZfsActions() {
+		shortcutMap = append(shortcutMap, snapshotBrowserShortcutBookmarks)
 	}
 
 	if snapshotBrowser.GetSelection() != nil {
-		shortcutMap = append(shortcutMap,
-			uiutil.TableComponentShortcutActions,
-			uiutil.TableComponentShortcutDelete,
-		)
+		if snapshotBrowser.supportsZfsActions() {
+			shortcutMap = append(shortcutMap,
+				uiutil.TableComponentShortcutActions,
+				uiutil.TableComponentShortcutDelete,
+			)
+		}
 	} else {
 		shortcutMap = append(shortcutMap,
 			uiutil.TableComponentShortcutFlipColumnDirection,
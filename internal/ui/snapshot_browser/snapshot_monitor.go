@@ -0,0 +1,46 @@
+package snapshot_browser
+
+import (
+	"time"
+	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/util"
+)
+
+// snapshotPollInterval is how often snapshot directories are listed to notice changes without inotify events
+const snapshotPollInterval = 10 * time.Second
+
+// snapshotMonitor notices snapshots which have been taken or destroyed by other tools, e.g. by a cron job.
+// Snapshot directories are watched for immediate updates, but the ".zfs/snapshot" directory of ZFS does not
+// raise inotify events for "zfs snapshot" or "zfs destroy", so all directories are polled as well.
+type snapshotMonitor struct {
+	dirs     []string
+	watchers []*util.FileWatcher
+	poller   *util.DirectoryPoller
+}
+
+// startSnapshotMonitor calls onChange from a background goroutine whenever the snapshots within dirs have changed
+func startSnapshotMonitor(dirs []string, onChange func()) *snapshotMonitor {
+	monitor := &snapshotMonitor{dirs: dirs}
+	for _, dir := range dirs {
+		watcher := util.NewFileWatcher(dir)
+		err := watcher.Watch(func(events []util.FileEvent) {
+			onChange()
+		})
+		if err != nil {
+			// not fatal, changes are still noticed by polling
+			logging.Debug("Cannot watch snapshot directory %s: %s", dir, err.Error())
+			continue
+		}
+		monitor.watchers = append(monitor.watchers, watcher)
+	}
+	monitor.poller = util.NewDirectoryPoller(dirs, snapshotPollInterval)
+	monitor.poller.Poll(onChange)
+	return monitor
+}
+
+func (monitor *snapshotMonitor) stop() {
+	for _, watcher := range monitor.watchers {
+		watcher.Stop()
+	}
+	monitor.poller.Stop()
+}
@@ -1,20 +1,48 @@
 package snapshot_browser
 
 import (
+	"cmp"
 	"fmt"
-	"math/big"
-	"sort"
 	"strings"
+	"time"
+	"zfs-file-history/internal/autosnap"
 	"zfs-file-history/internal/data"
 	"zfs-file-history/internal/data/diff_state"
+	"zfs-file-history/internal/retention"
 	"zfs-file-history/internal/ui/table"
 	"zfs-file-history/internal/ui/theme"
 	uiutil "zfs-file-history/internal/ui/util"
+	"zfs-file-history/internal/zfs"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+// userPropertyColumnIdOffset separates the ids of user property columns from the static columns
+const userPropertyColumnIdOffset table.ColumnId = 100
+
+// newUserPropertyColumns creates a table column for each of the given user properties,
+// using the property name as the column title
+func newUserPropertyColumns(properties []string) []*table.Column {
+	var result []*table.Column
+	for i, property := range properties {
+		result = append(result, &table.Column{
+			Id:        userPropertyColumnIdOffset + table.ColumnId(i),
+			Title:     property,
+			Alignment: tview.AlignLeft,
+		})
+	}
+	return result
+}
+
+// getUserPropertyName returns the name of the user property shown in the given column, if any
+func getUserPropertyName(column *table.Column) (string, bool) {
+	if column.Id < userPropertyColumnIdOffset {
+		return "", false
+	}
+	return column.Title, true
+}
+
 func (snapshotBrowser *SnapshotBrowserComponent) createSnapshotBrowserTable(application *tview.Application) *table.RowSelectionTable[data.SnapshotBrowserEntry] {
 	tableContainer := table.NewTableContainer[data.SnapshotBrowserEntry](
 		application,
@@ -33,7 +61,11 @@ func (snapshotBrowser *SnapshotBrowserComponent) createSnapshotBrowserTableCells
 		cellColor := determineBaseTextColor(entry)
 		switch column {
 		case columnDate:
-			cellText = entry.Snapshot.Properties.CreationDate.Format(theme.Style.Format.DateTime)
+			cellText = formatCreationDate(entry.Snapshot.Properties)
+		case columnCreationSource:
+			cellText = formatCreationDateSource(entry.Snapshot.Properties)
+		case columnSource:
+			cellText = formatSnapshotSource(entry.Snapshot)
 		case columnName:
 			cellText = entry.Snapshot.Name
 		case columnDiff:
@@ -64,11 +96,43 @@ func (snapshotBrowser *SnapshotBrowserComponent) createSnapshotBrowserTableCells
 			cellText = uiutil.StableLengthHumanizedBytes(entry.Snapshot.Properties.Used)
 		case columnRefer:
 			cellText = uiutil.StableLengthHumanizedBytes(entry.Snapshot.Properties.Referenced)
+		case columnWritten:
+			cellText = uiutil.StableLengthHumanizedBytes(entry.Snapshot.Properties.Written)
+		case columnAge:
+			cellAlign = tview.AlignRight
+			cellText = uiutil.FormatAge(time.Since(entry.Snapshot.Properties.CreationDate))
 		case columnRatio:
 			ratio := entry.Snapshot.Properties.CompressionRatio
 			cellText = fmt.Sprintf("%.2fx", ratio)
 		case columnClones:
 			cellText = fmt.Sprintf("%d", entry.Snapshot.Properties.Clones)
+		case columnTool:
+			cellText = ""
+			if entry.Origin != nil {
+				cellText = entry.Origin.Tool
+			}
+		case columnInterval:
+			cellText = ""
+			if entry.Origin != nil {
+				cellText = entry.Origin.Interval
+			}
+		case columnExpires:
+			cellText = ""
+			if entry.Origin != nil && !entry.Origin.Expires.IsZero() {
+				cellText = entry.Origin.Expires.Format(theme.Style.Format.DateTime)
+				if entry.Origin.Expires.Before(time.Now()) {
+					cellColor = tcell.ColorRed
+				}
+			}
+		case columnRetention:
+			cellText = formatRetention(entry.Retention)
+			if entry.Retention != nil && !entry.Retention.Keep {
+				cellColor = tcell.ColorRed
+			}
+		default:
+			if property, ok := getUserPropertyName(column); ok {
+				cellText, cellColor = formatUserProperty(entry.Snapshot, property, cellColor)
+			}
 		}
 		cell := tview.NewTableCell(cellText).
 			SetTextColor(cellColor).
@@ -84,6 +148,90 @@ func (snapshotBrowser *SnapshotBrowserComponent) createSnapshotBrowserTableCells
 	return result
 }
 
+func formatCreationDate(properties zfs.SnapshotProperties) string {
+	if properties.CreationDateSource == zfs.CreationDateSourceUnknown {
+		return "unknown"
+	}
+	return properties.CreationDate.Format(theme.Style.Format.DateTime)
+}
+
+// formatCreationDateSource shows whether the creation date was read from the "creation" property
+// or from the snapshot name, and which parser recognized the name
+func formatCreationDateSource(properties zfs.SnapshotProperties) string {
+	if properties.CreationDateSource == zfs.CreationDateSourceName {
+		return fmt.Sprintf("name (%s)", properties.CreationDateParser)
+	}
+	return properties.CreationDateSource.String()
+}
+
+// formatSnapshotSource returns the name of the dataset a snapshot belongs to,
+// which differs from the browsed dataset for snapshots of its replicas
+func formatSnapshotSource(snapshot *zfs.Snapshot) string {
+	if snapshot.ParentDataset == nil {
+		return ""
+	}
+	if name := snapshot.ParentDataset.GetName(); name != "" {
+		return name
+	}
+	return snapshot.ParentDataset.Path
+}
+
+// formatUserProperty returns the value of a user property of the snapshot along with where it comes from,
+// values which are not set on the snapshot itself (e.g. inherited from its dataset) are grayed out
+func formatUserProperty(snapshot *zfs.Snapshot, property string, color tcell.Color) (string, tcell.Color) {
+	value, ok := snapshot.GetUserProperty(property)
+	if !ok {
+		return "", color
+	}
+	switch {
+	case snapshot.IsUserPropertyInherited(property):
+		return value + " (inherited)", tcell.ColorGray
+	case snapshot.GetUserPropertySource(property) == "received":
+		return value + " (received)", tcell.ColorGray
+	default:
+		return value, color
+	}
+}
+
+// originKey returns the value an entry is grouped by when sorting by the tool or interval column.
+// Snapshots of unknown origin are sorted last.
+func originKey(origin *autosnap.Origin, column *table.Column) string {
+	if origin == nil {
+		return "\uffff"
+	}
+	if column == columnInterval {
+		return origin.Interval + "\x00" + origin.Tool
+	}
+	return origin.Tool + "\x00" + origin.Interval
+}
+
+// compareExpiry orders snapshots by their expiry, snapshots which never expire are sorted last
+func compareExpiry(a *autosnap.Origin, b *autosnap.Origin) int {
+	aUnknown := a == nil || a.Expires.IsZero()
+	bUnknown := b == nil || b.Expires.IsZero()
+	switch {
+	case aUnknown && bUnknown:
+		return 0
+	case aUnknown:
+		return 1
+	case bUnknown:
+		return -1
+	default:
+		return a.Expires.Compare(b.Expires)
+	}
+}
+
+func formatRetention(decision *retention.Decision) string {
+	switch {
+	case decision == nil:
+		return ""
+	case decision.Keep:
+		return fmt.Sprintf("keep (%s)", decision.Reason)
+	default:
+		return fmt.Sprintf("prune (%s)", decision.Reason)
+	}
+}
+
 func determineStatusColor(entry *data.SnapshotBrowserEntry) tcell.Color {
 	switch entry.DiffState {
 	case diff_state.Equal:
@@ -114,48 +262,80 @@ func determineBaseTextColor(entry *data.SnapshotBrowserEntry) tcell.Color {
 	}
 }
 
-func createSnapshotBrowserTableSortFunction(entries []*data.SnapshotBrowserEntry, columnToSortBy *table.Column, inverted bool) []*data.SnapshotBrowserEntry {
-	sort.SliceStable(entries, func(i, j int) bool {
-		a := entries[i]
-		b := entries[j]
+// snapshotSortKey contains the values of an entry which are compared while sorting, see table.SortByKeys
+type snapshotSortKey struct {
+	entry *data.SnapshotBrowserEntry
+	// text is the value of columns which are compared as text, computed once per entry
+	text string
+}
 
-		result := 0
-		switch columnToSortBy {
-		case columnName:
-			result = strings.Compare(strings.ToLower(a.Snapshot.Name), strings.ToLower(b.Snapshot.Name))
-		case columnDate:
-			result = a.Snapshot.Properties.CreationDate.Compare(b.Snapshot.Properties.CreationDate)
-		case columnDiff:
-			result = int(b.DiffState - a.DiffState)
-		case columnUsed:
-			result = int(b.Snapshot.Properties.Used - a.Snapshot.Properties.Used)
-		case columnRefer:
-			result = int(b.Snapshot.Properties.Referenced - a.Snapshot.Properties.Referenced)
-		case columnRatio:
-			ratioA := a.Snapshot.Properties.CompressionRatio
-			ratioB := b.Snapshot.Properties.CompressionRatio
-			result = big.NewFloat(ratioA).Cmp(big.NewFloat(ratioB))
-		case columnClones:
-			clonesA := a.Snapshot.Properties.Clones
-			clonesB := b.Snapshot.Properties.Clones
-			switch {
-			case clonesA < clonesB:
-				result = -1
-			case clonesA > clonesB:
-				result = 1
-			default:
-				result = 0
+func createSnapshotBrowserTableSortFunction(entries []*data.SnapshotBrowserEntry, columnToSortBy *table.Column, inverted bool) []*data.SnapshotBrowserEntry {
+	return table.SortByKeys(entries,
+		func(entry *data.SnapshotBrowserEntry) snapshotSortKey {
+			return snapshotSortKey{entry: entry, text: snapshotSortText(entry, columnToSortBy)}
+		},
+		func(a, b snapshotSortKey) int {
+			result := compareSnapshotEntries(a, b, columnToSortBy)
+			if inverted {
+				result *= -1
 			}
-		}
-		if inverted {
-			result *= -1
-		}
+			return result
+		},
+	)
+}
 
-		if result <= 0 {
-			return true
-		} else {
-			return false
+// snapshotSortText returns the value of the column for columns which are compared as text
+func snapshotSortText(entry *data.SnapshotBrowserEntry, column *table.Column) string {
+	switch column {
+	case columnName:
+		return strings.ToLower(entry.Snapshot.Name)
+	case columnRetention:
+		return formatRetention(entry.Retention)
+	case columnTool, columnInterval:
+		return originKey(entry.Origin, column)
+	case columnCreationSource:
+		return formatCreationDateSource(entry.Snapshot.Properties)
+	case columnSource:
+		return formatSnapshotSource(entry.Snapshot)
+	}
+	if property, ok := getUserPropertyName(column); ok {
+		value, _ := entry.Snapshot.GetUserProperty(property)
+		return strings.ToLower(value)
+	}
+	return ""
+}
+
+func compareSnapshotEntries(keyA, keyB snapshotSortKey, columnToSortBy *table.Column) int {
+	a := keyA.entry
+	b := keyB.entry
+	switch columnToSortBy {
+	case columnDate:
+		return a.Snapshot.Properties.CreationDate.Compare(b.Snapshot.Properties.CreationDate)
+	case columnDiff:
+		return int(b.DiffState - a.DiffState)
+	case columnUsed:
+		return cmp.Compare(b.Snapshot.Properties.Used, a.Snapshot.Properties.Used)
+	case columnRefer:
+		return cmp.Compare(b.Snapshot.Properties.Referenced, a.Snapshot.Properties.Referenced)
+	case columnWritten:
+		return cmp.Compare(a.Snapshot.Properties.Written, b.Snapshot.Properties.Written)
+	case columnAge:
+		// the newest snapshot has the lowest age
+		return b.Snapshot.Properties.CreationDate.Compare(a.Snapshot.Properties.CreationDate)
+	case columnRatio:
+		return cmp.Compare(a.Snapshot.Properties.CompressionRatio, b.Snapshot.Properties.CompressionRatio)
+	case columnClones:
+		return cmp.Compare(a.Snapshot.Properties.Clones, b.Snapshot.Properties.Clones)
+	case columnTool, columnInterval, columnSource:
+		// group snapshots of the same tool, interval or source, newest first
+		result := strings.Compare(keyA.text, keyB.text)
+		if result == 0 {
+			result = b.Snapshot.Properties.CreationDate.Compare(a.Snapshot.Properties.CreationDate)
 		}
-	})
-	return entries
+		return result
+	case columnExpires:
+		return compareExpiry(a.Origin, b.Origin)
+	default:
+		return strings.Compare(keyA.text, keyB.text)
+	}
 }
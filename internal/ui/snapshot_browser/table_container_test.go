@@ -3,11 +3,16 @@ package snapshot_browser
 import (
 	"math"
 	"testing"
+	"time"
+	"zfs-file-history/internal/autosnap"
 	"zfs-file-history/internal/data"
 	"zfs-file-history/internal/data/diff_state"
+	"zfs-file-history/internal/retention"
 	"zfs-file-history/internal/ui/table"
+	"zfs-file-history/internal/ui/theme"
 	"zfs-file-history/internal/zfs"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,3 +70,232 @@ func snapshotNames(entries []*data.SnapshotBrowserEntry) []string {
 	}
 	return result
 }
+
+func TestUserPropertyColumns(t *testing.T) {
+	columns := newUserPropertyColumns([]string{"zfh:note", "com.sun:auto-snapshot"})
+	noteColumn := columns[0]
+
+	property, ok := getUserPropertyName(noteColumn)
+	assert.True(t, ok)
+	assert.Equal(t, "zfh:note", property)
+	_, ok = getUserPropertyName(columnName)
+	assert.False(t, ok)
+
+	entries := []*data.SnapshotBrowserEntry{
+		newSnapshotEntryWithNote("b", "pre-deploy"),
+		newSnapshotEntryWithNote("a", ""),
+		newSnapshotEntryWithNote("c", "Backup"),
+	}
+
+	snapshotBrowser := &SnapshotBrowserComponent{}
+	cells := snapshotBrowser.createSnapshotBrowserTableCells(0, columns, entries[0])
+	if assert.Len(t, cells, 2) {
+		assert.Equal(t, "pre-deploy", cells[0].Text)
+		assert.Equal(t, "", cells[1].Text)
+	}
+
+	createSnapshotBrowserTableSortFunction(entries, noteColumn, false)
+	assert.Equal(t, []string{"a", "c", "b"}, snapshotNames(entries))
+
+	inherited := newSnapshotEntryWithNote("d", "keep")
+	inherited.Snapshot.Properties.UserSources = map[string]string{"zfh:note": "inherited from tank"}
+	cells = snapshotBrowser.createSnapshotBrowserTableCells(0, columns, inherited)
+	if assert.Len(t, cells, 2) {
+		assert.Equal(t, "keep (inherited)", cells[0].Text)
+		foreground, _, _ := cells[0].Style.Decompose()
+		assert.Equal(t, tcell.ColorGray, foreground)
+	}
+}
+
+func newSnapshotEntryWithNote(name string, note string) *data.SnapshotBrowserEntry {
+	user := map[string]string{}
+	if note != "" {
+		user["zfh:note"] = note
+	}
+	return &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{
+			Name:       name,
+			Properties: zfs.SnapshotProperties{User: user},
+		},
+		DiffState: diff_state.Unknown,
+	}
+}
+
+func TestCreateSnapshotBrowserTableCells_WrittenAndAgeColumns(t *testing.T) {
+	entry := &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{
+			Name: "snap-a",
+			Properties: zfs.SnapshotProperties{
+				CreationDate: time.Now().Add(-3 * time.Hour),
+				Written:      1024,
+			},
+		},
+		DiffState: diff_state.Unknown,
+	}
+
+	snapshotBrowser := &SnapshotBrowserComponent{}
+	cells := snapshotBrowser.createSnapshotBrowserTableCells(0, []*table.Column{columnWritten, columnAge}, entry)
+
+	if assert.Len(t, cells, 2) {
+		assert.Equal(t, "   1.0 KiB", cells[0].Text)
+		assert.Equal(t, "  3h", cells[1].Text)
+	}
+}
+
+func TestCreateSnapshotBrowserTableSortFunction_WrittenAndAge(t *testing.T) {
+	now := time.Now()
+	newEntry := func(name string, written uint64, age time.Duration) *data.SnapshotBrowserEntry {
+		return &data.SnapshotBrowserEntry{
+			Snapshot: &zfs.Snapshot{
+				Name: name,
+				Properties: zfs.SnapshotProperties{
+					CreationDate: now.Add(-age),
+					Written:      written,
+				},
+			},
+		}
+	}
+	entries := []*data.SnapshotBrowserEntry{
+		newEntry("old", math.MaxUint64, 48*time.Hour),
+		newEntry("new", 0, time.Hour),
+		newEntry("mid", 10, 24*time.Hour),
+	}
+
+	byWritten := append([]*data.SnapshotBrowserEntry{}, entries...)
+	createSnapshotBrowserTableSortFunction(byWritten, columnWritten, false)
+	assert.Equal(t, []string{"new", "mid", "old"}, snapshotNames(byWritten))
+
+	byAge := append([]*data.SnapshotBrowserEntry{}, entries...)
+	createSnapshotBrowserTableSortFunction(byAge, columnAge, false)
+	assert.Equal(t, []string{"new", "mid", "old"}, snapshotNames(byAge))
+}
+
+func TestCreateSnapshotBrowserTableCells_RetentionColumn(t *testing.T) {
+	newEntry := func(decision *retention.Decision) *data.SnapshotBrowserEntry {
+		return &data.SnapshotBrowserEntry{
+			Snapshot:  &zfs.Snapshot{Name: "snap-a"},
+			Retention: decision,
+		}
+	}
+
+	snapshotBrowser := &SnapshotBrowserComponent{}
+	columns := []*table.Column{columnRetention}
+
+	cells := snapshotBrowser.createSnapshotBrowserTableCells(0, columns, newEntry(&retention.Decision{Keep: true, Reason: "hourly, daily"}))
+	assert.Equal(t, "keep (hourly, daily)", cells[0].Text)
+
+	cells = snapshotBrowser.createSnapshotBrowserTableCells(0, columns, newEntry(&retention.Decision{Keep: false, Reason: "expired"}))
+	assert.Equal(t, "prune (expired)", cells[0].Text)
+
+	cells = snapshotBrowser.createSnapshotBrowserTableCells(0, columns, newEntry(nil))
+	assert.Equal(t, "", cells[0].Text)
+}
+
+func TestCreateSnapshotBrowserTableSortFunction_GroupByTool(t *testing.T) {
+	now := time.Now()
+	newEntry := func(name string, tool string, age time.Duration) *data.SnapshotBrowserEntry {
+		entry := &data.SnapshotBrowserEntry{
+			Snapshot: &zfs.Snapshot{
+				Name:       name,
+				Properties: zfs.SnapshotProperties{CreationDate: now.Add(-age)},
+			},
+		}
+		if tool != "" {
+			entry.Origin = &autosnap.Origin{Tool: tool, Interval: "daily"}
+		}
+		return entry
+	}
+	entries := []*data.SnapshotBrowserEntry{
+		newEntry("manual", "", time.Hour),
+		newEntry("sanoid-old", autosnap.ToolSanoid, 48*time.Hour),
+		newEntry("auto-snap", autosnap.ToolZfsAutoSnapshot, 2*time.Hour),
+		newEntry("sanoid-new", autosnap.ToolSanoid, 24*time.Hour),
+	}
+
+	createSnapshotBrowserTableSortFunction(entries, columnTool, false)
+	assert.Equal(t, []string{"sanoid-new", "sanoid-old", "auto-snap", "manual"}, snapshotNames(entries))
+}
+
+func TestCreateSnapshotBrowserTableCells_OriginColumns(t *testing.T) {
+	expires := time.Now().Add(24 * time.Hour)
+	entry := &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{Name: "autosnap_2026-10-01_00:00:00_daily"},
+		Origin:   &autosnap.Origin{Tool: autosnap.ToolSanoid, Interval: "daily", Expires: expires},
+	}
+
+	snapshotBrowser := &SnapshotBrowserComponent{}
+	columns := []*table.Column{columnTool, columnInterval, columnExpires}
+
+	cells := snapshotBrowser.createSnapshotBrowserTableCells(0, columns, entry)
+	if assert.Len(t, cells, 3) {
+		assert.Equal(t, "sanoid", cells[0].Text)
+		assert.Equal(t, "daily", cells[1].Text)
+		assert.Equal(t, expires.Format(theme.Style.Format.DateTime), cells[2].Text)
+	}
+
+	cells = snapshotBrowser.createSnapshotBrowserTableCells(0, columns, &data.SnapshotBrowserEntry{Snapshot: &zfs.Snapshot{Name: "manual"}})
+	assert.Equal(t, []string{"", "", ""}, []string{cells[0].Text, cells[1].Text, cells[2].Text})
+}
+
+func TestGetVisibleSnapshots(t *testing.T) {
+	sanoid := &zfs.Snapshot{Name: "autosnap_2026-10-01_00:00:00_daily"}
+	manual := &zfs.Snapshot{Name: "manual"}
+	snapshotBrowser := &SnapshotBrowserComponent{
+		currentSnapshots: []*zfs.Snapshot{sanoid, manual},
+		snapshotOrigins:  map[string]*autosnap.Origin{sanoid.Name: {Tool: autosnap.ToolSanoid}},
+	}
+
+	assert.Equal(t, []*zfs.Snapshot{sanoid, manual}, snapshotBrowser.getVisibleSnapshots())
+
+	snapshotBrowser.hiddenTools = map[string]bool{otherSnapshotTool: true}
+	assert.Equal(t, []*zfs.Snapshot{sanoid}, snapshotBrowser.getVisibleSnapshots())
+
+	snapshotBrowser.hiddenTools = map[string]bool{autosnap.ToolSanoid: true}
+	assert.Equal(t, []*zfs.Snapshot{manual}, snapshotBrowser.getVisibleSnapshots())
+	assert.Len(t, snapshotBrowser.currentSnapshots, 2)
+}
+
+func TestCreateSnapshotBrowserTableCells_CreationSource(t *testing.T) {
+	creation := time.Date(2026, 10, 1, 0, 17, 0, 0, time.Local)
+	newEntry := func(source zfs.CreationDateSource, parser string) *data.SnapshotBrowserEntry {
+		return &data.SnapshotBrowserEntry{
+			Snapshot: &zfs.Snapshot{
+				Name: "zrepl_20261001_001700_000",
+				Properties: zfs.SnapshotProperties{
+					CreationDate:       creation,
+					CreationDateSource: source,
+					CreationDateParser: parser,
+				},
+			},
+		}
+	}
+
+	snapshotBrowser := &SnapshotBrowserComponent{}
+	columns := []*table.Column{columnDate, columnCreationSource}
+
+	cells := snapshotBrowser.createSnapshotBrowserTableCells(0, columns, newEntry(zfs.CreationDateSourceName, "zrepl"))
+	assert.Equal(t, creation.Format(theme.Style.Format.DateTime), cells[0].Text)
+	assert.Equal(t, "name (zrepl)", cells[1].Text)
+
+	cells = snapshotBrowser.createSnapshotBrowserTableCells(0, columns, newEntry(zfs.CreationDateSourceProperty, ""))
+	assert.Equal(t, "property", cells[1].Text)
+
+	cells = snapshotBrowser.createSnapshotBrowserTableCells(0, columns, newEntry(zfs.CreationDateSourceUnknown, ""))
+	assert.Equal(t, "unknown", cells[0].Text)
+	assert.Equal(t, "unknown", cells[1].Text)
+}
+
+func TestSnapshotBrowserTable_SourceColumn(t *testing.T) {
+	snapshotBrowser := &SnapshotBrowserComponent{}
+	columns := []*table.Column{columnSource}
+
+	cells := snapshotBrowser.createSnapshotBrowserTableCells(0, columns, &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{Name: "daily", ParentDataset: &zfs.Dataset{Path: "/tank/home"}},
+	})
+	assert.Equal(t, "/tank/home", cells[0].Text)
+
+	cells = snapshotBrowser.createSnapshotBrowserTableCells(0, columns, &data.SnapshotBrowserEntry{
+		Snapshot: &zfs.Snapshot{Name: "daily"},
+	})
+	assert.Equal(t, "", cells[0].Text)
+}
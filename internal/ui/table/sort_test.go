@@ -0,0 +1,29 @@
+package table
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortByKeys(t *testing.T) {
+	entries := []*mockEntry{{id: "b"}, {id: "C"}, {id: "a"}, {id: "B"}}
+
+	keyCalls := 0
+	result := SortByKeys(entries,
+		func(entry *mockEntry) string {
+			keyCalls++
+			return strings.ToLower(entry.id)
+		},
+		strings.Compare,
+	)
+
+	var ids []string
+	for _, entry := range result {
+		ids = append(ids, entry.id)
+	}
+	// the sort is stable, "b" stays in front of "B"
+	assert.Equal(t, []string{"a", "b", "B", "C"}, ids)
+	assert.Equal(t, len(entries), keyCalls)
+}
@@ -51,6 +51,7 @@ type RowSelectionTable[T RowSelectionTableEntry] struct {
 
 	layout    *tview.Flex
 	table     *tview.Table
+	content   *tableContent[T]
 	scrollbar *scrollbar.ScrollbarComponent
 
 	entries      []*T
@@ -66,8 +67,10 @@ type RowSelectionTable[T RowSelectionTableEntry] struct {
 	sortTableEntries func(entries []*T, column *Column, inverted bool) []*T
 	toTableCells     func(row int, columns []*Column, entry *T) (cells []*tview.TableCell)
 
-	inputCapture             func(event *tcell.EventKey) *tcell.EventKey
-	selectionChangedCallback func(selectedEntry *T)
+	inputCapture               func(event *tcell.EventKey) *tcell.EventKey
+	selectionChangedCallback   func(selectedEntry *T)
+	visibleRowsChangedCallback func()
+	sortChangedCallback        func()
 
 	columnSpec   []*Column
 	sortInverted bool
@@ -79,6 +82,12 @@ type RowSelectionTable[T RowSelectionTableEntry] struct {
 
 	lastSyncHeight int
 	resizeTimer    *time.Timer
+
+	// lastVisibleOffset and lastVisibleHeight describe the rows which have been visible
+	// when the visibleRowsChangedCallback was called the last time
+	lastVisibleOffset int
+	lastVisibleHeight int
+	visibleRowsTimer  *time.Timer
 }
 
 func NewTableContainer[T RowSelectionTableEntry](
@@ -119,10 +128,33 @@ func (c *RowSelectionTable[T]) setupResizeMonitor() {
 				})
 			})
 		}
+		c.scheduleVisibleRowsCheck()
 		return x, y, width, height
 	})
 }
 
+// scheduleVisibleRowsCheck calls the visibleRowsChangedCallback shortly after drawing, if other rows have been scrolled into view.
+// The offset of the table is only updated while drawing its content, after the draw func has been called.
+func (c *RowSelectionTable[T]) scheduleVisibleRowsCheck() {
+	if c.visibleRowsChangedCallback == nil {
+		return
+	}
+	if c.visibleRowsTimer != nil {
+		c.visibleRowsTimer.Stop()
+	}
+	c.visibleRowsTimer = time.AfterFunc(50*time.Millisecond, func() {
+		c.application.QueueUpdate(func() {
+			offset, _ := c.table.GetOffset()
+			_, _, _, height := c.table.GetInnerRect()
+			if offset == c.lastVisibleOffset && height == c.lastVisibleHeight {
+				return
+			}
+			c.lastVisibleOffset, c.lastVisibleHeight = offset, height
+			c.visibleRowsChangedCallback()
+		})
+	})
+}
+
 func (c *RowSelectionTable[T]) SetMultiSelect(multiSelect bool) {
 	c.multiSelectEnabled = multiSelect
 	c.ClearMultiSelection()
@@ -143,6 +175,8 @@ func (c *RowSelectionTable[T]) createLayout() {
 	)
 
 	table.SetSelectable(true, false)
+	c.content = newTableContent(c)
+	table.SetContent(c.content)
 	table.SetSelectionChangedFunc(func(row, column int) {
 		if c.isUpdatingData {
 			return
@@ -331,30 +365,13 @@ func (c *RowSelectionTable[T]) SetData(entries []*T) {
 	c.updateTableContents()
 }
 
+// UpdateEntry redraws the row of the given entry, e.g. after its state has changed.
+// The order of the entries is not changed.
 func (c *RowSelectionTable[T]) UpdateEntry(entry *T) {
-	c.entriesMutex.Lock()
-	defer c.entriesMutex.Unlock()
-
 	if entry == nil {
 		return
 	}
-
-	index := slices.Index(c.entries, entry)
-	if index < 0 {
-		return
-	}
-
-	cells := c.toTableCells(index, c.columnSpec, entry)
-	for column, cell := range cells {
-		if c.isInMultiSelection(entry) {
-			cell.SetBackgroundColor(theme.Colors.Layout.Table.MultiSelectionBackground)
-			cell.SetTextColor(theme.Colors.Layout.Table.MultiSelectionForeground)
-			cell.SetSelectedStyle(
-				tcell.StyleDefault.Background(theme.Colors.Layout.Table.MultiSelectionBackground),
-			)
-		}
-		c.table.SetCell(index+1, column, cell)
-	}
+	c.content.invalidateEntry(entry)
 }
 
 func (c *RowSelectionTable[T]) SortBy(sortOption *Column, inverted bool) {
@@ -365,12 +382,29 @@ func (c *RowSelectionTable[T]) SortBy(sortOption *Column, inverted bool) {
 	c.entriesMutex.Unlock()
 }
 
+// GetSortColumn returns the column the entries are currently sorted by
+func (c *RowSelectionTable[T]) GetSortColumn() *Column {
+	return c.sortByColumn
+}
+
+// Resort sorts the entries again, e.g. after the values of the sort column have changed,
+// the selected entry stays selected
+func (c *RowSelectionTable[T]) Resort() {
+	selectedEntry := c.GetSelectedEntry()
+	c.SortBy(c.sortByColumn, c.sortInverted)
+	c.updateTableContents()
+	if selectedEntry != nil {
+		c.Select(selectedEntry)
+	}
+}
+
 func (c *RowSelectionTable[T]) nextSortOrder() {
 	currentIndex := slices.Index(c.columnSpec, c.sortByColumn)
 	nextIndex := (currentIndex + 1) % len(c.columnSpec)
 	column := c.columnSpec[nextIndex]
 	c.SortBy(column, c.sortInverted)
 	c.updateTableContents()
+	c.notifySortChanged()
 }
 
 func (c *RowSelectionTable[T]) previousSortOrder() {
@@ -379,71 +413,40 @@ func (c *RowSelectionTable[T]) previousSortOrder() {
 	column := c.columnSpec[nextIndex]
 	c.SortBy(column, c.sortInverted)
 	c.updateTableContents()
+	c.notifySortChanged()
 }
 
 func (c *RowSelectionTable[T]) toggleSortDirection() {
 	c.sortInverted = !c.sortInverted
 	c.SortBy(c.sortByColumn, c.sortInverted)
 	c.updateTableContents()
+	c.notifySortChanged()
 }
 
-func (c *RowSelectionTable[T]) updateTableContents() {
-
-	table := c.table
-	if table == nil {
-		return
+func (c *RowSelectionTable[T]) notifySortChanged() {
+	if c.sortChangedCallback != nil {
+		c.sortChangedCallback()
 	}
+}
 
-	table.Clear()
-
-	// Table Header
-	for column, tableColumn := range c.columnSpec {
-		cellColor := tcell.ColorWhite
-		cellAlignment := tableColumn.Alignment
-		cellExpansion := 0
-
-		cellText := tableColumn.Title
-		if tableColumn == c.sortByColumn {
-			var sortDirectionIndicator = "↓"
-			if !c.sortInverted {
-				sortDirectionIndicator = "↑"
-			}
-			cellText = fmt.Sprintf("%s %s", cellText, sortDirectionIndicator)
-		}
-
-		cell := tview.NewTableCell(cellText).
-			SetTextColor(cellColor).
-			SetAlign(cellAlignment).
-			SetExpansion(cellExpansion)
-		table.SetCell(0, column, cell)
-	}
-
-	// Table Content
-	for row, entry := range c.entries {
-		cells := c.toTableCells(row, c.columnSpec, entry)
-		for column, cell := range cells {
-			if c.isInMultiSelection(entry) {
-				cell.SetBackgroundColor(theme.Colors.Layout.Table.MultiSelectionBackground)
-				cell.SetTextColor(theme.Colors.Layout.Table.MultiSelectionForeground)
-				cell.SetSelectedStyle(
-					tcell.StyleDefault.Background(theme.Colors.Layout.Table.MultiSelectionBackground),
-				)
-			}
-			table.SetCell(row+1, column, cell)
-		}
+// updateTableContents invalidates all rows, which are converted into cells again when they are drawn.
+// Only the visible rows are ever converted, see tableContent.
+func (c *RowSelectionTable[T]) updateTableContents() {
+	if c.table == nil {
+		return
 	}
+	c.content.reset()
 	c.syncScrollbar()
 }
 
 func (c *RowSelectionTable[T]) Select(entry *T) {
 	index := 0
 	if entry != nil {
-		index = slices.Index(c.entries, entry)
-		if index < 0 {
+		entryIndex, ok := c.content.indexOf(entry)
+		if !ok {
 			return
-		} else {
-			index += 1
 		}
+		index = entryIndex + 1
 	}
 	if index <= 1 {
 		c.table.ScrollToBeginning()
@@ -460,6 +463,21 @@ func (c *RowSelectionTable[T]) GetEntries() []*T {
 	return c.entries
 }
 
+// GetVisibleEntries returns the entries of the rows which are currently scrolled into view
+func (c *RowSelectionTable[T]) GetVisibleEntries() []*T {
+	offset, _ := c.table.GetOffset()
+	_, _, _, height := c.table.GetInnerRect()
+	// the header row is always visible
+	return visibleRange(c.entries, offset, height-1)
+}
+
+// visibleRange returns up to count entries starting at offset
+func visibleRange[T any](entries []*T, offset int, count int) []*T {
+	start := min(max(offset, 0), len(entries))
+	end := min(start+max(count, 0), len(entries))
+	return entries[start:end]
+}
+
 func (c *RowSelectionTable[T]) GetSelectedEntry() *T {
 	row, _ := c.table.GetSelection()
 	row -= 1
@@ -482,6 +500,17 @@ func (c *RowSelectionTable[T]) SetSelectionChangedCallback(f func(selectedEntry
 	c.selectionChangedCallback = f
 }
 
+// SetVisibleRowsChangedCallback sets a function which is called whenever other rows have been scrolled into view,
+// e.g. after scrolling or resizing the table, see GetVisibleEntries
+func (c *RowSelectionTable[T]) SetVisibleRowsChangedCallback(f func()) {
+	c.visibleRowsChangedCallback = f
+}
+
+// SetSortChangedCallback sets a function which is called whenever the user changed the sort column or direction
+func (c *RowSelectionTable[T]) SetSortChangedCallback(f func()) {
+	c.sortChangedCallback = f
+}
+
 func (c *RowSelectionTable[T]) SelectHeader() {
 	row, col := c.table.GetSelection()
 	if row != 0 || col != 0 {
@@ -524,8 +553,10 @@ func (c *RowSelectionTable[T]) removeFromMultiSelection(entry *T) {
 	}
 	if c.isInMultiSelection(entry) {
 		entryId := c.createMultiSelectionEntryId(entry)
+		// the selected entry may be an older instance with the same id
+		c.content.invalidateEntry(c.multiSelectionEntryMap[entryId])
 		delete(c.multiSelectionEntryMap, entryId)
-		c.updateTableContents()
+		c.content.invalidateEntry(entry)
 	}
 }
 
@@ -536,7 +567,7 @@ func (c *RowSelectionTable[T]) addToMultiSelection(entry *T) {
 	}
 	entryId := c.createMultiSelectionEntryId(entry)
 	c.multiSelectionEntryMap[entryId] = entry
-	c.updateTableContents()
+	c.content.invalidateEntry(entry)
 }
 
 func (c *RowSelectionTable[T]) createMultiSelectionEntryId(entry *T) string {
@@ -568,14 +599,16 @@ func (c *RowSelectionTable[T]) HasMultiSelection() bool {
 
 // cleanupMultiSelection removes all entries from the "multi selection" feature that are not part of the current table entries.
 func (c *RowSelectionTable[T]) cleanupMultiSelection() {
-	currentEntryIds := []string{}
+	if len(c.multiSelectionEntryMap) == 0 {
+		return
+	}
+	currentEntryIds := make(map[string]bool, len(c.entries))
 	for _, entry := range c.entries {
-		entryId := c.createMultiSelectionEntryId(entry)
-		currentEntryIds = append(currentEntryIds, entryId)
+		currentEntryIds[c.createMultiSelectionEntryId(entry)] = true
 	}
 
 	for entryId := range c.multiSelectionEntryMap {
-		if !slices.Contains(currentEntryIds, entryId) {
+		if !currentEntryIds[entryId] {
 			delete(c.multiSelectionEntryMap, entryId)
 		}
 	}
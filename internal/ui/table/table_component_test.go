@@ -1,7 +1,9 @@
 package table
 
 import (
+	"fmt"
 	"testing"
+	"zfs-file-history/internal/ui/theme"
 
 	"github.com/rivo/tview"
 	"github.com/stretchr/testify/assert"
@@ -95,3 +97,139 @@ func TestCreateMultiSelectionEntryId(t *testing.T) {
 	e1 := &mockEntry{id: "test-id"}
 	assert.Equal(t, "test-id", table.createMultiSelectionEntryId(e1))
 }
+
+func TestVisibleRange(t *testing.T) {
+	entries := []*mockEntry{{id: "0"}, {id: "1"}, {id: "2"}, {id: "3"}}
+
+	assert.Equal(t, entries[1:3], visibleRange(entries, 1, 2))
+	assert.Equal(t, entries[2:], visibleRange(entries, 2, 10))
+	assert.Empty(t, visibleRange(entries, 10, 2))
+	assert.Empty(t, visibleRange(entries, 0, -1))
+	assert.Empty(t, visibleRange([]*mockEntry{}, 0, 5))
+}
+
+func newCountingTable(converted *int) *RowSelectionTable[mockEntry] {
+	table := NewTableContainer[mockEntry](
+		tview.NewApplication(),
+		func(row int, columns []*Column, entry *mockEntry) []*tview.TableCell {
+			*converted++
+			return []*tview.TableCell{tview.NewTableCell(entry.id)}
+		},
+		func(entries []*mockEntry, column *Column, inverted bool) []*mockEntry {
+			return entries
+		},
+	)
+	table.SetColumnSpec([]*Column{{Id: 0, Title: "Id"}}, nil, false)
+	return table
+}
+
+func createMockEntries(count int) []*mockEntry {
+	entries := make([]*mockEntry, count)
+	for i := range entries {
+		entries[i] = &mockEntry{id: fmt.Sprintf("%d", i)}
+	}
+	return entries
+}
+
+func TestTableContent_ConvertsRequestedRowsOnly(t *testing.T) {
+	converted := 0
+	table := newCountingTable(&converted)
+	table.SetData(createMockEntries(100_000))
+
+	assert.Equal(t, 0, converted)
+	assert.Equal(t, 100_001, table.table.GetRowCount())
+	assert.Equal(t, "Id ↑", table.table.GetCell(0, 0).Text)
+	assert.Equal(t, "41", table.table.GetCell(42, 0).Text)
+	assert.Equal(t, 1, converted)
+
+	// converted rows are cached
+	table.table.GetCell(42, 0)
+	assert.Equal(t, 1, converted)
+}
+
+func TestTableContent_UpdateEntry(t *testing.T) {
+	converted := 0
+	table := newCountingTable(&converted)
+	entries := createMockEntries(10)
+	table.SetData(entries)
+	table.table.GetCell(3, 0)
+	table.table.GetCell(4, 0)
+
+	entries[2].id = "changed"
+	table.UpdateEntry(entries[2])
+
+	assert.Equal(t, "changed", table.table.GetCell(3, 0).Text)
+	assert.Equal(t, "3", table.table.GetCell(4, 0).Text)
+	assert.Equal(t, 3, converted)
+}
+
+func TestTableContent_MultiSelection(t *testing.T) {
+	converted := 0
+	table := newCountingTable(&converted)
+	entries := createMockEntries(10)
+	table.SetData(entries)
+
+	table.addToMultiSelection(entries[1])
+	_, background, _ := table.table.GetCell(2, 0).Style.Decompose()
+	assert.Equal(t, theme.Colors.Layout.Table.MultiSelectionBackground, background)
+
+	table.removeFromMultiSelection(entries[1])
+	_, background, _ = table.table.GetCell(2, 0).Style.Decompose()
+	assert.NotEqual(t, theme.Colors.Layout.Table.MultiSelectionBackground, background)
+}
+
+func TestTableContent_MultiSelectionInvalidatesToggledRowOnly(t *testing.T) {
+	converted := 0
+	table := newCountingTable(&converted)
+	entries := createMockEntries(10)
+	table.SetData(entries)
+	for row := 1; row <= len(entries); row++ {
+		table.table.GetCell(row, 0)
+	}
+	assert.Equal(t, 10, converted)
+
+	table.toggleMultiSelection(entries[4])
+	table.toggleMultiSelection(entries[6])
+	for row := 1; row <= len(entries); row++ {
+		table.table.GetCell(row, 0)
+	}
+	assert.Equal(t, 12, converted)
+
+	table.toggleMultiSelection(entries[4])
+	for row := 1; row <= len(entries); row++ {
+		table.table.GetCell(row, 0)
+	}
+	assert.Equal(t, 13, converted)
+
+	// clearing the selection affects all rows
+	table.ClearMultiSelection()
+	for row := 1; row <= len(entries); row++ {
+		table.table.GetCell(row, 0)
+	}
+	assert.Equal(t, 23, converted)
+}
+
+func TestTableContent_Select(t *testing.T) {
+	converted := 0
+	table := newCountingTable(&converted)
+	entries := createMockEntries(10)
+	table.SetData(entries)
+
+	table.Select(entries[5])
+	assert.Equal(t, entries[5], table.GetSelectedEntry())
+
+	table.Select(&mockEntry{id: "unknown"})
+	assert.Equal(t, entries[5], table.GetSelectedEntry())
+}
+
+func BenchmarkSetData(b *testing.B) {
+	converted := 0
+	table := newCountingTable(&converted)
+	entries := createMockEntries(200_000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.SetData(entries)
+	}
+}
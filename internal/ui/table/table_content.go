@@ -0,0 +1,132 @@
+package table
+
+import (
+	"fmt"
+	"zfs-file-history/internal/ui/theme"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxCachedRows is the number of rows whose cells are kept, it should be larger than any visible window.
+// When exceeded, e.g. after scrolling through a huge table, the cache is cleared.
+const maxCachedRows = 2048
+
+// tableContent provides the cells of a RowSelectionTable to the underlying tview.Table on demand.
+// tview only requests the cells of the rows it draws, so regardless of the number of entries,
+// only the visible window is converted into cells. Converted rows are cached until they are invalidated.
+type tableContent[T RowSelectionTableEntry] struct {
+	tview.TableContentReadOnly
+
+	table *RowSelectionTable[T]
+
+	headerCells []*tview.TableCell
+	// rowCells contains the cells of already converted rows, by entry index
+	rowCells map[int][]*tview.TableCell
+	// rowOfEntry is the index of every entry in the current order
+	rowOfEntry map[*T]int
+}
+
+func newTableContent[T RowSelectionTableEntry](table *RowSelectionTable[T]) *tableContent[T] {
+	return &tableContent[T]{
+		table:      table,
+		rowCells:   map[int][]*tview.TableCell{},
+		rowOfEntry: map[*T]int{},
+	}
+}
+
+func (content *tableContent[T]) GetCell(row, column int) *tview.TableCell {
+	var cells []*tview.TableCell
+	if row == 0 {
+		cells = content.headerCells
+	} else {
+		cells = content.getRowCells(row - 1)
+	}
+	if column < 0 || column >= len(cells) {
+		return nil
+	}
+	return cells[column]
+}
+
+func (content *tableContent[T]) GetRowCount() int {
+	// +1 for the header row
+	return len(content.table.entries) + 1
+}
+
+func (content *tableContent[T]) GetColumnCount() int {
+	return len(content.table.columnSpec)
+}
+
+// getRowCells returns the cells of the entry with the given index, converting it if necessary
+func (content *tableContent[T]) getRowCells(index int) []*tview.TableCell {
+	entries := content.table.entries
+	if index < 0 || index >= len(entries) {
+		return nil
+	}
+	if cells, ok := content.rowCells[index]; ok {
+		return cells
+	}
+
+	if len(content.rowCells) >= maxCachedRows {
+		clear(content.rowCells)
+	}
+	entry := entries[index]
+	cells := content.table.toTableCells(index, content.table.columnSpec, entry)
+	if content.table.isInMultiSelection(entry) {
+		for _, cell := range cells {
+			cell.SetBackgroundColor(theme.Colors.Layout.Table.MultiSelectionBackground)
+			cell.SetTextColor(theme.Colors.Layout.Table.MultiSelectionForeground)
+			cell.SetSelectedStyle(
+				tcell.StyleDefault.Background(theme.Colors.Layout.Table.MultiSelectionBackground),
+			)
+		}
+	}
+	content.rowCells[index] = cells
+	return cells
+}
+
+// reset invalidates all rows and the header, it has to be called whenever the entries,
+// their order or the columns have changed. Changes of single entries only require invalidateEntry.
+func (content *tableContent[T]) reset() {
+	clear(content.rowCells)
+	clear(content.rowOfEntry)
+	for i, entry := range content.table.entries {
+		content.rowOfEntry[entry] = i
+	}
+	content.headerCells = content.createHeaderCells()
+}
+
+// invalidateEntry converts the given entry again when it is drawn the next time
+func (content *tableContent[T]) invalidateEntry(entry *T) {
+	if index, ok := content.indexOf(entry); ok {
+		delete(content.rowCells, index)
+	}
+}
+
+// indexOf returns the index of the given entry in the current order
+func (content *tableContent[T]) indexOf(entry *T) (int, bool) {
+	index, ok := content.rowOfEntry[entry]
+	return index, ok
+}
+
+func (content *tableContent[T]) createHeaderCells() []*tview.TableCell {
+	table := content.table
+	cells := make([]*tview.TableCell, 0, len(table.columnSpec))
+	for _, tableColumn := range table.columnSpec {
+		cellText := tableColumn.Title
+		if tableColumn == table.sortByColumn {
+			var sortDirectionIndicator = "↓"
+			if !table.sortInverted {
+				sortDirectionIndicator = "↑"
+			}
+			cellText = fmt.Sprintf("%s %s", cellText, sortDirectionIndicator)
+		}
+
+		cell := tview.NewTableCell(cellText).
+			SetTextColor(tcell.ColorWhite).
+			SetAlign(tableColumn.Alignment).
+			SetExpansion(0)
+		cells = append(cells, cell)
+	}
+	return cells
+}
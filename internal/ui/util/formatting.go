@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
 )
@@ -18,3 +19,35 @@ func StableLengthHumanizedBytes(u uint64) string {
 	}
 	return text
 }
+
+// FormatAge formats the given duration using its largest unit, e.g. "45s", "3h" or "2w".
+// The result is padded to a stable length of 4 characters.
+func FormatAge(age time.Duration) string {
+	const (
+		day   = 24 * time.Hour
+		week  = 7 * day
+		month = 30 * day
+		year  = 365 * day
+	)
+
+	var text string
+	switch {
+	case age < 0:
+		text = "0s"
+	case age < time.Minute:
+		text = fmt.Sprintf("%ds", int(age/time.Second))
+	case age < time.Hour:
+		text = fmt.Sprintf("%dm", int(age/time.Minute))
+	case age < day:
+		text = fmt.Sprintf("%dh", int(age/time.Hour))
+	case age < 2*week:
+		text = fmt.Sprintf("%dd", int(age/day))
+	case age < 2*month:
+		text = fmt.Sprintf("%dw", int(age/week))
+	case age < year:
+		text = fmt.Sprintf("%dmo", int(age/month))
+	default:
+		text = fmt.Sprintf("%dy", int(age/year))
+	}
+	return fmt.Sprintf("%4s", text)
+}
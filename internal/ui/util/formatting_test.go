@@ -2,6 +2,7 @@ package util
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -28,3 +29,25 @@ func TestStableLengthHumanizedBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{-time.Second, "  0s"},
+		{45 * time.Second, " 45s"},
+		{5 * time.Minute, "  5m"},
+		{3*time.Hour + 59*time.Minute, "  3h"},
+		{13 * 24 * time.Hour, " 13d"},
+		{3 * 7 * 24 * time.Hour, "  3w"},
+		{90 * 24 * time.Hour, " 3mo"},
+		{800 * 24 * time.Hour, "  2y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatAge(tt.input))
+		})
+	}
+}
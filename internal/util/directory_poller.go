@@ -0,0 +1,93 @@
+package util
+
+import (
+	"hash/fnv"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DirectoryPoller periodically lists directories and reports when their entries have changed.
+// It complements FileWatcher for directories which do not raise inotify events, like the virtual
+// ".zfs/snapshot" directory of ZFS. Entries are identified by name and inode, so an entry which has been
+// replaced by another one with the same name (e.g. a snapshot which has been destroyed and taken again) is noticed, too.
+type DirectoryPoller struct {
+	Paths []string
+
+	interval time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewDirectoryPoller(paths []string, interval time.Duration) *DirectoryPoller {
+	return &DirectoryPoller{
+		Paths:    paths,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Poll lists the directories every interval, action is called from a background goroutine
+// whenever the entries of any of them have changed since the previous listing
+func (poller *DirectoryPoller) Poll(action func()) {
+	go func() {
+		ticker := time.NewTicker(poller.interval)
+		defer ticker.Stop()
+
+		signatures := poller.signatures()
+		for {
+			select {
+			case <-ticker.C:
+				current := poller.signatures()
+				changed := false
+				for i := range current {
+					changed = changed || current[i] != signatures[i]
+				}
+				signatures = current
+				if changed {
+					action()
+				}
+			case <-poller.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops polling
+func (poller *DirectoryPoller) Stop() {
+	poller.stopOnce.Do(func() {
+		close(poller.stop)
+	})
+}
+
+func (poller *DirectoryPoller) signatures() []uint64 {
+	result := make([]uint64, len(poller.Paths))
+	for i, path := range poller.Paths {
+		result[i] = directorySignature(path)
+	}
+	return result
+}
+
+// directorySignature returns a hash of the names and inodes of all entries of the directory,
+// a directory which cannot be listed has the signature 0
+func directorySignature(path string) uint64 {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return 0
+	}
+	hash := fnv.New64a()
+	for _, entry := range entries {
+		_, _ = hash.Write([]byte(entry.Name()))
+		_, _ = hash.Write([]byte{0})
+		if info, err := entry.Info(); err == nil {
+			if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+				_, _ = hash.Write([]byte(strconv.FormatUint(stat.Ino, 10)))
+			}
+		}
+		_, _ = hash.Write([]byte{'\n'})
+	}
+	return hash.Sum64()
+}
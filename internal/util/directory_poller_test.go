@@ -0,0 +1,56 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectorySignature(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "snap1"), 0755))
+	initial := directorySignature(dir)
+	assert.Equal(t, initial, directorySignature(dir))
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "snap2"), 0755))
+	withSecond := directorySignature(dir)
+	assert.NotEqual(t, initial, withSecond)
+
+	// an entry which has been replaced by a new one with the same name
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "placeholder"), 0755))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "snap2")))
+	assert.NoError(t, os.Rename(filepath.Join(dir, "placeholder"), filepath.Join(dir, "snap2")))
+	assert.NotEqual(t, withSecond, directorySignature(dir))
+
+	assert.Equal(t, uint64(0), directorySignature(filepath.Join(dir, "missing")))
+}
+
+func TestDirectoryPoller(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir()}
+	poller := NewDirectoryPoller(dirs, 20*time.Millisecond)
+	assert.Equal(t, dirs, poller.Paths)
+
+	changes := make(chan struct{}, 10)
+	poller.Poll(func() {
+		changes <- struct{}{}
+	})
+	defer poller.Stop()
+
+	// let the poller take its initial listing
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-changes:
+		t.Fatal("unexpected change without modification")
+	default:
+	}
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dirs[1], "snap1"), 0755))
+	select {
+	case <-changes:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for directory poller")
+	}
+}
@@ -10,117 +10,157 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
+// DefaultFileWatcherDebounce is how long a FileWatcher waits for further events before delivering them
+const DefaultFileWatcherDebounce = 250 * time.Millisecond
+
+// FileEventType describes how a file changed
+type FileEventType int
+
+const (
+	FileCreated FileEventType = iota
+	FileRemoved
+	FileModified
+)
+
+func (eventType FileEventType) String() string {
+	switch eventType {
+	case FileCreated:
+		return "created"
+	case FileRemoved:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// FileEvent is a change of a single file, coalesced from all changes of the file within the debounce period
+type FileEvent struct {
+	Path string
+	Type FileEventType
+}
+
+// FileWatcher watches a directory and delivers its changes in batches.
+// Changes are collected until no further change happened for the debounce period (or at most
+// for maxDelay, to not starve on continuously modified files), multiple changes of the same file are coalesced.
 type FileWatcher struct {
-	RootPath   string
-	stop       chan bool
-	actionLock sync.Mutex
-	watcher    *fsnotify.Watcher
-	newEvent   *fsnotify.Event
+	RootPath string
+
+	debounce time.Duration
+	maxDelay time.Duration
+
+	watcher  *fsnotify.Watcher
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 func NewFileWatcher(path string) *FileWatcher {
 	return &FileWatcher{
-		RootPath:   path,
-		stop:       make(chan bool),
-		actionLock: sync.Mutex{},
+		RootPath: path,
+		debounce: DefaultFileWatcherDebounce,
+		maxDelay: 4 * DefaultFileWatcherDebounce,
+		stop:     make(chan struct{}),
 	}
 }
 
-func (fileWatcher *FileWatcher) Watch(action func(s string)) error {
-	return fileWatcher.watchDir(fileWatcher.RootPath, action)
-}
-
-func (fileWatcher *FileWatcher) WatchRecursive(action func(s string)) {
-	fileWatcher.watchDirRecursive(fileWatcher.RootPath, action)
+// SetDebounce sets how long the watcher waits for further events before delivering them
+func (fileWatcher *FileWatcher) SetDebounce(debounce time.Duration) *FileWatcher {
+	fileWatcher.debounce = debounce
+	fileWatcher.maxDelay = 4 * debounce
+	return fileWatcher
 }
 
-func (fileWatcher *FileWatcher) Stop() {
-	go func() {
-		fileWatcher.stop <- true
-	}()
+// Watch watches the files directly within RootPath. action is called from a background goroutine.
+func (fileWatcher *FileWatcher) Watch(action func(events []FileEvent)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(fileWatcher.RootPath); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	fileWatcher.watcher = watcher
+	go fileWatcher.run(action, false)
+	return nil
 }
 
-// watches all files and folders in the given path recursively
-func (fileWatcher *FileWatcher) watchDir(path string, action func(s string)) error {
-	// creates a new file watcher
-	fileWatcher.watcher, _ = fsnotify.NewWatcher()
-	err := fileWatcher.watcher.Add(path)
+// WatchRecursive watches all files and folders below RootPath, including folders created later on.
+// action is called from a background goroutine.
+func (fileWatcher *FileWatcher) WatchRecursive(action func(events []FileEvent)) error {
+	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
 	}
+	fileWatcher.watcher = watcher
+	if err := filepath.Walk(fileWatcher.RootPath, fileWatcher.addFolderWatch); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	go fileWatcher.run(action, true)
+	return nil
+}
 
-	t := time.NewTicker(1 * time.Second)
-
-	go func() {
-		defer t.Stop()
-		for {
-			select {
-			case <-t.C:
-				if fileWatcher.newEvent == nil {
-					continue
-				}
+// Stop stops watching, pending events are discarded
+func (fileWatcher *FileWatcher) Stop() {
+	fileWatcher.stopOnce.Do(func() {
+		close(fileWatcher.stop)
+	})
+}
 
-				fileWatcher.actionLock.Lock()
-				event := fileWatcher.newEvent
-				action(event.Name)
-				fileWatcher.actionLock.Unlock()
-			// watch for events
-			case event, ok := <-fileWatcher.watcher.Events:
-				if !ok {
-					return
-				}
-				fileWatcher.newEvent = &event
-			// watch for errors
-			case err := <-fileWatcher.watcher.Errors:
-				if err != nil {
-					logging.Error("Error watching files: %s", err.Error())
-				}
-			case <-fileWatcher.stop:
-				err := fileWatcher.watcher.Close()
-				if err != nil {
-					logging.Error("Error closing file watcher: %s", err.Error())
-				}
-				return
-			}
+func (fileWatcher *FileWatcher) run(action func(events []FileEvent), recursive bool) {
+	defer func() {
+		if err := fileWatcher.watcher.Close(); err != nil {
+			logging.Error("Error closing file watcher: %s", err.Error())
 		}
 	}()
-	return nil
-}
 
-// watches all files and folders in the given path recursively
-func (fileWatcher *FileWatcher) watchDirRecursive(path string, action func(s string)) {
-	// creates a new file watcher
-	fileWatcher.watcher, _ = fsnotify.NewWatcher()
+	timer := time.NewTimer(fileWatcher.debounce)
+	timer.Stop()
+	defer timer.Stop()
 
-	// starting at the root of the project, walk each file/directory searching for directories
-	if err := filepath.Walk(path, fileWatcher.addFolderWatch); err != nil {
-		logging.Error("Error walking path %s: %s", path, err.Error())
-		return
-	}
+	var pending fileEventBatch
+	var firstEventTime time.Time
 
-	go func() {
-		for {
-			select {
-			// watch for events
-			case event, ok := <-fileWatcher.watcher.Events:
-				if !ok {
-					return
-				}
-				fileWatcher.actionLock.Lock()
-				action(event.Name)
-				fileWatcher.actionLock.Unlock()
-			// watch for errors
-			case err := <-fileWatcher.watcher.Errors:
-				logging.Error("Error watching files: %s", err.Error())
-			case <-fileWatcher.stop:
-				err := fileWatcher.watcher.Close()
-				if err != nil {
-					logging.Error("Error closing file watcher: %s", err.Error())
+	for {
+		select {
+		case event, ok := <-fileWatcher.watcher.Events:
+			if !ok {
+				return
+			}
+			eventType, relevant := toFileEventType(event.Op)
+			if !relevant {
+				continue
+			}
+			if recursive && eventType == FileCreated {
+				if stat, err := os.Lstat(event.Name); err == nil && stat.IsDir() {
+					if err := filepath.Walk(event.Name, fileWatcher.addFolderWatch); err != nil {
+						logging.Error("Error watching new folder %s: %s", event.Name, err.Error())
+					}
 				}
+			}
+
+			if pending.isEmpty() {
+				firstEventTime = time.Now()
+			}
+			pending.add(event.Name, eventType)
+			// keep waiting for further events, unless the batch has been delayed for too long already
+			if time.Since(firstEventTime) < fileWatcher.maxDelay {
+				timer.Reset(fileWatcher.debounce)
+			}
+		case <-timer.C:
+			events := pending.take()
+			if len(events) > 0 {
+				action(events)
+			}
+		case err, ok := <-fileWatcher.watcher.Errors:
+			if !ok {
 				return
 			}
+			logging.Error("Error watching files: %s", err.Error())
+		case <-fileWatcher.stop:
+			return
 		}
-	}()
+	}
 }
 
 // adds a path to the watcher
@@ -137,3 +177,76 @@ func (fileWatcher *FileWatcher) addFolderWatch(path string, fi os.FileInfo, err
 
 	return nil
 }
+
+func toFileEventType(op fsnotify.Op) (FileEventType, bool) {
+	switch {
+	case op.Has(fsnotify.Create):
+		return FileCreated, true
+	case op.Has(fsnotify.Remove), op.Has(fsnotify.Rename):
+		// the new name of a renamed file is reported as a separate create event
+		return FileRemoved, true
+	case op.Has(fsnotify.Write), op.Has(fsnotify.Chmod):
+		return FileModified, true
+	default:
+		return 0, false
+	}
+}
+
+// fileEventBatch collects the changes of files in the order they were first seen
+type fileEventBatch struct {
+	paths []string
+	types map[string]FileEventType
+}
+
+func (batch *fileEventBatch) isEmpty() bool {
+	return len(batch.paths) == 0
+}
+
+func (batch *fileEventBatch) add(path string, eventType FileEventType) {
+	if batch.types == nil {
+		batch.types = map[string]FileEventType{}
+	}
+	previous, seen := batch.types[path]
+	if !seen {
+		batch.paths = append(batch.paths, path)
+		batch.types[path] = eventType
+		return
+	}
+	batch.types[path] = coalesceFileEventTypes(previous, eventType)
+}
+
+// take returns the collected events and resets the batch
+func (batch *fileEventBatch) take() []FileEvent {
+	var result []FileEvent
+	for _, path := range batch.paths {
+		eventType := batch.types[path]
+		if eventType < 0 {
+			continue
+		}
+		result = append(result, FileEvent{Path: path, Type: eventType})
+	}
+	batch.paths = nil
+	batch.types = nil
+	return result
+}
+
+// fileEventDropped marks a file which was created and removed again within a batch
+const fileEventDropped FileEventType = -1
+
+// coalesceFileEventTypes combines two consecutive changes of the same file into one
+func coalesceFileEventTypes(previous FileEventType, next FileEventType) FileEventType {
+	switch {
+	case previous == FileCreated && next == FileRemoved:
+		return fileEventDropped
+	case previous == FileCreated:
+		// modifications of a new file are part of its creation
+		return FileCreated
+	case previous == fileEventDropped && next == FileCreated:
+		return FileCreated
+	case previous == FileRemoved && next == FileCreated:
+		// replaced, e.g. by an editor writing to a temporary file and renaming it
+		return FileModified
+	default:
+		return next
+	}
+}
@@ -15,9 +15,9 @@ func TestFileWatcher(t *testing.T) {
 	fw := NewFileWatcher(tempDir)
 	assert.Equal(t, tempDir, fw.RootPath)
 
-	eventChan := make(chan string, 10)
-	action := func(path string) {
-		eventChan <- path
+	eventChan := make(chan []FileEvent, 10)
+	action := func(events []FileEvent) {
+		eventChan <- events
 	}
 
 	err := fw.Watch(action)
@@ -30,8 +30,9 @@ func TestFileWatcher(t *testing.T) {
 
 	// Wait for event or timeout
 	select {
-	case eventPath := <-eventChan:
-		assert.NotEmpty(t, eventPath)
+	case events := <-eventChan:
+		assert.NotEmpty(t, events)
+		assert.NotEmpty(t, events[0].Path)
 	case <-time.After(3 * time.Second):
 		t.Fatal("timed out waiting for file watcher event")
 	}
@@ -47,12 +48,13 @@ func TestFileWatcherRecursive(t *testing.T) {
 	assert.NoError(t, err)
 
 	fw := NewFileWatcher(tempDir)
-	eventChan := make(chan string, 10)
-	action := func(path string) {
-		eventChan <- path
+	eventChan := make(chan []FileEvent, 10)
+	action := func(events []FileEvent) {
+		eventChan <- events
 	}
 
-	fw.WatchRecursive(action)
+	err = fw.WatchRecursive(action)
+	assert.NoError(t, err)
 
 	// Write a new file in subdir
 	testFile := filepath.Join(subDir, "test.txt")
@@ -61,8 +63,9 @@ func TestFileWatcherRecursive(t *testing.T) {
 
 	// Wait for event or timeout
 	select {
-	case eventPath := <-eventChan:
-		assert.Contains(t, eventPath, "subdir")
+	case events := <-eventChan:
+		assert.NotEmpty(t, events)
+		assert.Contains(t, events[0].Path, "subdir")
 	case <-time.After(3 * time.Second):
 		t.Fatal("timed out waiting for recursive file watcher event")
 	}
@@ -70,3 +73,56 @@ func TestFileWatcherRecursive(t *testing.T) {
 	fw.Stop()
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestFileWatcher_CoalescesEventsAndDoesNotRepeatThem(t *testing.T) {
+	tempDir := t.TempDir()
+
+	fw := NewFileWatcher(tempDir).SetDebounce(100 * time.Millisecond)
+	eventChan := make(chan []FileEvent, 10)
+	err := fw.Watch(func(events []FileEvent) {
+		eventChan <- events
+	})
+	assert.NoError(t, err)
+	defer fw.Stop()
+
+	testFile := filepath.Join(tempDir, "test.txt")
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, os.WriteFile(testFile, []byte{byte(i)}, 0644))
+	}
+
+	select {
+	case events := <-eventChan:
+		assert.Equal(t, []FileEvent{{Path: testFile, Type: FileCreated}}, events)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for file watcher event")
+	}
+
+	// delivered events must not be delivered again
+	select {
+	case events := <-eventChan:
+		t.Fatalf("unexpected events: %v", events)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestFileEventBatch(t *testing.T) {
+	var batch fileEventBatch
+	batch.add("/a", FileCreated)
+	batch.add("/b", FileModified)
+	batch.add("/a", FileModified)
+	batch.add("/c", FileCreated)
+	batch.add("/c", FileRemoved)
+	batch.add("/d", FileRemoved)
+	batch.add("/d", FileCreated)
+	batch.add("/e", FileModified)
+	batch.add("/e", FileRemoved)
+
+	assert.Equal(t, []FileEvent{
+		{Path: "/a", Type: FileCreated},
+		{Path: "/b", Type: FileModified},
+		{Path: "/d", Type: FileModified},
+		{Path: "/e", Type: FileRemoved},
+	}, batch.take())
+	assert.True(t, batch.isEmpty())
+	assert.Empty(t, batch.take())
+}
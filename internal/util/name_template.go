@@ -0,0 +1,103 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+const (
+	NameTemplatePlaceholderDate     = "date"
+	NameTemplatePlaceholderUser     = "user"
+	NameTemplatePlaceholderHostname = "hostname"
+	NameTemplatePlaceholderPrompt   = "prompt"
+)
+
+// NameTemplateValues contains the values used to expand the placeholders of a name template
+type NameTemplateValues struct {
+	Time time.Time
+	// DateFormat is the Go time layout used for "{date}" placeholders without an explicit layout
+	DateFormat string
+	User       string
+	Hostname   string
+	Prompt     string
+}
+
+// CurrentNameTemplateValues returns template values for the current time, user and host
+func CurrentNameTemplateValues(dateFormat string, prompt string) NameTemplateValues {
+	values := NameTemplateValues{
+		Time:       time.Now(),
+		DateFormat: dateFormat,
+		Prompt:     prompt,
+	}
+	if currentUser, err := user.Current(); err == nil {
+		values.User = currentUser.Username
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		values.Hostname = hostname
+	}
+	return values
+}
+
+// ValidateNameTemplate checks that all placeholders of the given template are known and well-formed
+func ValidateNameTemplate(template string) error {
+	_, err := ExpandNameTemplate(template, NameTemplateValues{})
+	return err
+}
+
+// NameTemplateHasPrompt reports whether the template contains a "{prompt}" placeholder
+func NameTemplateHasPrompt(template string) bool {
+	return strings.Contains(template, "{"+NameTemplatePlaceholderPrompt+"}")
+}
+
+// ExpandNameTemplate replaces all placeholders within the given template.
+// Supported placeholders are "{date}", "{date:<go time layout>}", "{user}", "{hostname}" and "{prompt}".
+func ExpandNameTemplate(template string, values NameTemplateValues) (string, error) {
+	var sb strings.Builder
+	rest := template
+	for {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			sb.WriteString(rest)
+			return sb.String(), nil
+		}
+		if rest[start] == '}' {
+			return "", fmt.Errorf("unexpected '}' in name template '%s'", template)
+		}
+		sb.WriteString(rest[:start])
+
+		end := strings.IndexAny(rest[start+1:], "{}")
+		if end < 0 || rest[start+1+end] != '}' {
+			return "", fmt.Errorf("unclosed placeholder in name template '%s'", template)
+		}
+		placeholder := rest[start+1 : start+1+end]
+		rest = rest[start+1+end+1:]
+
+		name, argument, hasArgument := strings.Cut(placeholder, ":")
+		switch name {
+		case NameTemplatePlaceholderDate:
+			layout := values.DateFormat
+			if hasArgument {
+				if argument == "" {
+					return "", fmt.Errorf("empty date layout in name template '%s'", template)
+				}
+				layout = argument
+			}
+			sb.WriteString(values.Time.Format(layout))
+			continue
+		case NameTemplatePlaceholderUser:
+			sb.WriteString(values.User)
+		case NameTemplatePlaceholderHostname:
+			sb.WriteString(values.Hostname)
+		case NameTemplatePlaceholderPrompt:
+			sb.WriteString(values.Prompt)
+		default:
+			return "", fmt.Errorf("unknown placeholder '{%s}' in name template '%s'", placeholder, template)
+		}
+		if hasArgument {
+			return "", fmt.Errorf("placeholder '{%s}' does not accept an argument", name)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandNameTemplate(t *testing.T) {
+	values := NameTemplateValues{
+		Time:       time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		DateFormat: "2006-01-02-150405",
+		User:       "alice",
+		Hostname:   "nas",
+		Prompt:     "pre-deploy",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Default", template: "zfh-{date}", want: "zfh-2024-01-02-150405"},
+		{name: "Custom Date Layout", template: "{date:20060102}_{hostname}", want: "20240102_nas"},
+		{name: "All Placeholders", template: "{user}@{hostname}-{prompt}-{date}", want: "alice@nas-pre-deploy-2024-01-02-150405"},
+		{name: "No Placeholders", template: "manual", want: "manual"},
+		{name: "Unknown Placeholder", template: "zfh-{pool}", wantErr: true},
+		{name: "Unclosed Placeholder", template: "zfh-{date", wantErr: true},
+		{name: "Nested Placeholder", template: "zfh-{da{te}}", wantErr: true},
+		{name: "Stray Closing Brace", template: "zfh-date}", wantErr: true},
+		{name: "Empty Date Layout", template: "{date:}", wantErr: true},
+		{name: "Argument For User", template: "{user:x}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandNameTemplate(tt.template, values)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, ValidateNameTemplate(tt.template))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				assert.NoError(t, ValidateNameTemplate(tt.template))
+			}
+		})
+	}
+}
+
+func TestNameTemplateHasPrompt(t *testing.T) {
+	assert.True(t, NameTemplateHasPrompt("zfh-{prompt}-{date}"))
+	assert.False(t, NameTemplateHasPrompt("zfh-{date}"))
+}
@@ -0,0 +1,8 @@
+package util
+
+const (
+	// DefaultRestoreWorkers is the number of files which are copied in parallel, unless configured otherwise
+	DefaultRestoreWorkers = 4
+	// MaxRestoreWorkers is the maximum number of files which can be copied in parallel
+	MaxRestoreWorkers = 64
+)
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+const MaxUserPropertyNameLength = 256
+
+// ValidateUserPropertyName checks the given name against the naming rules of ZFS user properties,
+// which must contain a colon to distinguish them from native properties (e.g. "zfh:note").
+func ValidateUserPropertyName(name string) error {
+	if !strings.Contains(name, ":") {
+		return fmt.Errorf("user property '%s' must contain a ':'", name)
+	}
+	if len(name) > MaxUserPropertyNameLength {
+		return fmt.Errorf("user property name must not be longer than %d characters", MaxUserPropertyNameLength)
+	}
+	for _, r := range name {
+		valid := (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == ':' || r == '-' || r == '.' || r == '_'
+		if !valid {
+			return fmt.Errorf("user property '%s' contains invalid character '%c'", name, r)
+		}
+	}
+	return nil
+}
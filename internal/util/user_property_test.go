@@ -0,0 +1,19 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUserPropertyName(t *testing.T) {
+	assert.NoError(t, ValidateUserPropertyName("zfh:note"))
+	assert.NoError(t, ValidateUserPropertyName("com.sun:auto-snapshot"))
+	assert.NoError(t, ValidateUserPropertyName("org.example_1:a.b-c"))
+
+	assert.Error(t, ValidateUserPropertyName("compression"))
+	assert.Error(t, ValidateUserPropertyName("ZFH:Note"))
+	assert.Error(t, ValidateUserPropertyName("zfh:my note"))
+	assert.Error(t, ValidateUserPropertyName("zfh:"+strings.Repeat("a", MaxUserPropertyNameLength)))
+}
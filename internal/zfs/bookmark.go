@@ -0,0 +1,113 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bookmark marks a point in time of a dataset, which can be used as the source of an incremental send.
+// In contrast to a snapshot, a bookmark does not hold any data, so its contents cannot be browsed or restored.
+type Bookmark struct {
+	Name          string
+	FullName      string
+	CreationDate  time.Time
+	Guid          string
+	ParentDataset *Dataset
+}
+
+// GetBookmarks returns all bookmarks of this dataset, newest first
+func (dataset *Dataset) GetBookmarks() ([]*Bookmark, error) {
+	_ = dataset.lazyLoadGozfsData()
+	datasetName := dataset.GetName()
+	if datasetName == "" {
+		return nil, errors.New("cannot list bookmarks: no dataset metadata available")
+	}
+
+	output, err := runZfsCommand(
+		"list", "-H", "-p",
+		"-t", "bookmark", "-d", "1",
+		"-o", "name,creation,guid",
+		datasetName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks, err := parseBookmarks(output)
+	if err != nil {
+		return nil, err
+	}
+	for _, bookmark := range bookmarks {
+		bookmark.ParentDataset = dataset
+	}
+	return bookmarks, nil
+}
+
+// parseBookmarks parses the output of "zfs list -H -p -o name,creation,guid"
+func parseBookmarks(output string) ([]*Bookmark, error) {
+	var result []*Bookmark
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, errors.New("unexpected zfs list output: " + line)
+		}
+		fullName, creation, guid := fields[0], fields[1], fields[2]
+		_, name, found := strings.Cut(fullName, "#")
+		if !found {
+			return nil, errors.New("unexpected bookmark name: " + fullName)
+		}
+		timestamp, err := strconv.ParseInt(creation, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected creation time of bookmark %s: %w", fullName, err)
+		}
+		result = append(result, &Bookmark{
+			Name:         name,
+			FullName:     fullName,
+			CreationDate: time.Unix(timestamp, 0),
+			Guid:         guid,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreationDate.After(result[j].CreationDate)
+	})
+	return result, nil
+}
+
+// Destroy removes this bookmark. The snapshot it was created from is not affected.
+func (b *Bookmark) Destroy() error {
+	_, err := runZfsCommand("destroy", b.FullName)
+	return err
+}
+
+// CreateBookmark creates a bookmark with the given name from this snapshot
+func (s *Snapshot) CreateBookmark(name string) (*Bookmark, error) {
+	if err := ValidateBookmarkName(name); err != nil {
+		return nil, err
+	}
+	datasetName := s.ParentDataset.GetName()
+	if datasetName == "" {
+		return nil, errors.New("cannot create bookmark: no dataset metadata available")
+	}
+	fullName := fmt.Sprintf("%s#%s", datasetName, name)
+	if len(fullName) > maxDatasetNameLength {
+		return nil, fmt.Errorf("full bookmark name '%s' must not be longer than %d characters", fullName, maxDatasetNameLength)
+	}
+
+	_, err := runZfsCommand("bookmark", s.FullName, fullName)
+	if err != nil {
+		return nil, err
+	}
+	return &Bookmark{
+		Name:          name,
+		FullName:      fullName,
+		CreationDate:  s.Properties.CreationDate,
+		ParentDataset: s.ParentDataset,
+	}, nil
+}
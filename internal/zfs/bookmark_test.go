@@ -0,0 +1,46 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBookmarks(t *testing.T) {
+	output := "tank/data#replica-1\t1700000000\t1234567890123456789\n" +
+		"tank/data#replica-2\t1700086400\t9876543210987654321\n"
+
+	result, err := parseBookmarks(output)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, &Bookmark{
+		Name:         "replica-2",
+		FullName:     "tank/data#replica-2",
+		CreationDate: time.Unix(1700086400, 0),
+		Guid:         "9876543210987654321",
+	}, result[0])
+	assert.Equal(t, "replica-1", result[1].Name)
+
+	result, err = parseBookmarks("")
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	_, err = parseBookmarks("tank/data@snap1\t1700000000\t1\n")
+	assert.Error(t, err)
+	_, err = parseBookmarks("tank/data#b\tyesterday\t1\n")
+	assert.Error(t, err)
+	_, err = parseBookmarks("tank/data#b\t1700000000\n")
+	assert.Error(t, err)
+}
+
+func TestSnapshotCreateBookmark_Validation(t *testing.T) {
+	s := &Snapshot{FullName: "tank/data@snap1", ParentDataset: &Dataset{}}
+
+	_, err := s.CreateBookmark("")
+	assert.ErrorContains(t, err, "bookmark name must not be empty")
+	_, err = s.CreateBookmark("tank#b")
+	assert.ErrorContains(t, err, "invalid character")
+	_, err = s.CreateBookmark("valid")
+	assert.ErrorContains(t, err, "no dataset metadata")
+}
@@ -0,0 +1,79 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	gopath "path"
+	"strings"
+	"zfs-file-history/internal/util"
+)
+
+// MissingChildSnapshotError is reported when a child dataset has no snapshot with the same name
+// as the snapshot of its parent, i.e. the parent snapshot was not taken recursively.
+type MissingChildSnapshotError struct {
+	DatasetPath  string
+	SnapshotName string
+}
+
+func (e *MissingChildSnapshotError) Error() string {
+	return fmt.Sprintf("child dataset at '%s' has no snapshot '%s', skipped", e.DatasetPath, e.SnapshotName)
+}
+
+// IsDatasetRoot reports whether the given path is the mountpoint of a dataset
+func IsDatasetRoot(path string) bool {
+	stat, err := os.Lstat(gopath.Join(path, ".zfs"))
+	return err == nil && stat.IsDir()
+}
+
+// isChildDatasetRoot reports whether the given real path is the mountpoint of a dataset
+// nested within the parent dataset of this snapshot
+func (s *Snapshot) isChildDatasetRoot(realPath string) bool {
+	return s.ParentDataset.IsZfs() && realPath != s.ParentDataset.Path && IsDatasetRoot(realPath)
+}
+
+// ForChildDataset returns the snapshot with the same name as this one on the (child) dataset
+// containing the given real path, e.g. "pool/a/b@x" for "pool/a@x".
+// Returns a MissingChildSnapshotError if the child dataset has no such snapshot.
+func (s *Snapshot) ForChildDataset(realPath string) (*Snapshot, error) {
+	dataset, err := FindHostDataset(realPath)
+	if err != nil {
+		return nil, err
+	}
+	return s.ForDataset(dataset)
+}
+
+// ForDataset returns the snapshot with the same name as this one on the given child dataset,
+// see ForChildDataset.
+func (s *Snapshot) ForDataset(dataset *Dataset) (*Snapshot, error) {
+	if dataset.Path == s.ParentDataset.Path {
+		return s, nil
+	}
+	if !strings.HasPrefix(dataset.Path, s.ParentDataset.Path+"/") {
+		return nil, fmt.Errorf("dataset at '%s' is not a child of '%s'", dataset.Path, s.ParentDataset.Path)
+	}
+
+	snapshotPath := gopath.Join(dataset.GetSnapshotsDir(), s.Name)
+	if !util.FileExists(snapshotPath) {
+		return nil, &MissingChildSnapshotError{DatasetPath: dataset.Path, SnapshotName: s.Name}
+	}
+
+	return &Snapshot{
+		Name:          s.Name,
+		FullName:      fmt.Sprintf("%s@%s", dataset.GetName(), s.Name),
+		Path:          snapshotPath,
+		ParentDataset: dataset,
+		// recursive snapshots are created atomically and share their creation date
+		Properties: SnapshotProperties{
+			CreationDate:       s.Properties.CreationDate,
+			CreationDateSource: s.Properties.CreationDateSource,
+			CreationDateParser: s.Properties.CreationDateParser,
+		},
+	}, nil
+}
+
+// IsMissingChildSnapshot reports whether err is (or wraps) a MissingChildSnapshotError
+func IsMissingChildSnapshot(err error) bool {
+	var missing *MissingChildSnapshotError
+	return errors.As(err, &missing)
+}
@@ -0,0 +1,104 @@
+package zfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createNestedDatasets creates a fake dataset layout at "<tmp>/parent" with a child dataset at
+// "<tmp>/parent/child", each with a snapshot directory for the given snapshot names
+func createNestedDatasets(t *testing.T, parentSnapshots []string, childSnapshots []string) (*Snapshot, string) {
+	parentPath := filepath.Join(t.TempDir(), "parent")
+	childPath := filepath.Join(parentPath, "child")
+
+	for _, name := range parentSnapshots {
+		// within the parent snapshot, the child dataset is only an empty mountpoint
+		assert.NoError(t, os.MkdirAll(filepath.Join(parentPath, ".zfs", "snapshot", name, "child"), 0755))
+	}
+	for _, name := range childSnapshots {
+		assert.NoError(t, os.MkdirAll(filepath.Join(childPath, ".zfs", "snapshot", name), 0755))
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(childPath, ".zfs", "snapshot"), 0755))
+
+	snapshot := &Snapshot{
+		Name: parentSnapshots[0],
+		Path: filepath.Join(parentPath, ".zfs", "snapshot", parentSnapshots[0]),
+		ParentDataset: &Dataset{
+			Path:          parentPath,
+			HiddenZfsPath: filepath.Join(parentPath, ".zfs"),
+		},
+		Properties: SnapshotProperties{CreationDate: time.Unix(1700000000, 0)},
+	}
+	return snapshot, childPath
+}
+
+func TestIsDatasetRoot(t *testing.T) {
+	snapshot, childPath := createNestedDatasets(t, []string{"snap1"}, nil)
+
+	assert.True(t, IsDatasetRoot(snapshot.ParentDataset.Path))
+	assert.True(t, IsDatasetRoot(childPath))
+	assert.False(t, IsDatasetRoot(filepath.Join(childPath, ".zfs", "snapshot")))
+	assert.False(t, snapshot.isChildDatasetRoot(snapshot.ParentDataset.Path))
+	assert.True(t, snapshot.isChildDatasetRoot(childPath))
+}
+
+func TestSnapshotForChildDataset(t *testing.T) {
+	snapshot, childPath := createNestedDatasets(t, []string{"snap1"}, []string{"snap1"})
+
+	child, err := snapshot.ForChildDataset(childPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "snap1", child.Name)
+	assert.Equal(t, childPath, child.ParentDataset.Path)
+	assert.Equal(t, filepath.Join(childPath, ".zfs", "snapshot", "snap1"), child.Path)
+	assert.Equal(t, snapshot.Properties.CreationDate, child.Properties.CreationDate)
+
+	same, err := snapshot.ForChildDataset(snapshot.ParentDataset.Path)
+	assert.NoError(t, err)
+	assert.Same(t, snapshot, same)
+}
+
+func TestSnapshotForChildDataset_Missing(t *testing.T) {
+	snapshot, childPath := createNestedDatasets(t, []string{"snap1"}, []string{"other"})
+
+	_, err := snapshot.ForChildDataset(childPath)
+	assert.True(t, IsMissingChildSnapshot(err))
+	assert.ErrorContains(t, err, "has no snapshot 'snap1'")
+}
+
+func TestSnapshotRestoreRecursive_DescendsIntoChildDataset(t *testing.T) {
+	snapshot, childPath := createNestedDatasets(t, []string{"snap1"}, []string{"snap1"})
+	parentFile := filepath.Join(snapshot.Path, "parent.txt")
+	childFile := filepath.Join(childPath, ".zfs", "snapshot", "snap1", "child.txt")
+	assert.NoError(t, os.WriteFile(parentFile, []byte("parent"), 0644))
+	assert.NoError(t, os.WriteFile(childFile, []byte("child"), 0644))
+
+	report, err := snapshot.RestoreRecursive(snapshot.Path)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Warnings)
+
+	content, err := os.ReadFile(filepath.Join(snapshot.ParentDataset.Path, "parent.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "parent", string(content))
+	content, err = os.ReadFile(filepath.Join(childPath, "child.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "child", string(content))
+}
+
+func TestSnapshotRestoreRecursive_WarnsAboutMissingChildSnapshot(t *testing.T) {
+	snapshot, childPath := createNestedDatasets(t, []string{"snap1"}, nil)
+	assert.NoError(t, os.WriteFile(filepath.Join(snapshot.Path, "parent.txt"), []byte("parent"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(childPath, "local.txt"), []byte("local"), 0644))
+
+	report, err := snapshot.RestoreRecursive(snapshot.Path)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Warnings, 1) {
+		assert.True(t, IsMissingChildSnapshot(report.Warnings[0]))
+	}
+
+	assert.FileExists(t, filepath.Join(snapshot.ParentDataset.Path, "parent.txt"))
+	assert.FileExists(t, filepath.Join(childPath, "local.txt"))
+}
@@ -0,0 +1,44 @@
+package zfs
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runZfsCommand executes the zfs CLI with the given arguments and returns its stdout.
+// It is used for operations which are not covered by the go-zfs library.
+func runZfsCommand(args ...string) (string, error) {
+	return runZfsCommandWithInput("", args...)
+}
+
+// runZfsCommandWithInput executes the zfs CLI like runZfsCommand, passing the given input via stdin.
+func runZfsCommandWithInput(input string, args ...string) (string, error) {
+	return runCommand("zfs", input, args...)
+}
+
+// runZpoolCommand executes the zpool CLI with the given arguments and returns its stdout.
+func runZpoolCommand(args ...string) (string, error) {
+	return runCommand("zpool", "", args...)
+}
+
+func runCommand(name string, input string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("%s %s: %s", name, strings.Join(args, " "), message)
+	}
+	return stdout.String(), nil
+}
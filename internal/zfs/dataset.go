@@ -3,11 +3,13 @@ package zfs
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	gopath "path"
+	"slices"
 	"strconv"
-	"strings"
 	"time"
+	"zfs-file-history/internal/logging"
 	"zfs-file-history/internal/util"
 
 	golibzfs "github.com/kraudcloud/go-libzfs"
@@ -28,31 +30,43 @@ const (
 	propCasesensitivity = "casesensitivity"
 	propEncryption      = "encryption"
 	propKeystatus       = "keystatus"
+	propKeylocation     = "keylocation"
 	propOrigin          = "origin"
 	propSnapshotLimit   = "snapshot_limit"
 	propSnapshotCount   = "snapshot_count"
+	propClones          = "clones"
 )
 
 type Dataset struct {
 	Path          string
 	HiddenZfsPath string
+	// BindRoot is the path within the dataset which is mounted at Path.
+	// It is empty unless Path is a bind mount of a subdirectory of the dataset.
+	BindRoot string
 
+	name            string
 	rawGozfsData    *gozfs.Dataset
 	rawGolibzfsData *golibzfs.Dataset
+
+	// provider is the SnapshotProvider which found this dataset, nil for ZFS datasets
+	provider SnapshotProvider
+	// snapshotsDir is the directory containing the snapshots of datasets of other providers, if they have one
+	snapshotsDir string
+	// replicaOf is the dataset this dataset is a replica of, see GetReplicas
+	replicaOf *Dataset
 }
 
 func findDatasetNameByMountpoint(mountpoint string) (string, error) {
-	data, err := os.ReadFile("/proc/mounts")
+	mounts, err := readMountInfo()
 	if err != nil {
 		return "", err
 	}
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		if len(fields) >= 3 && fields[2] == "zfs" {
-			if gopath.Clean(fields[1]) == gopath.Clean(mountpoint) {
-				return fields[0], nil
-			}
+	mountpoint = gopath.Clean(mountpoint)
+	// later mounts shadow earlier ones on the same mountpoint
+	for i := len(mounts) - 1; i >= 0; i-- {
+		mount := mounts[i]
+		if mount.FsType == "zfs" && mount.MountPoint == mountpoint {
+			return mount.Source, nil
 		}
 	}
 	return "", fmt.Errorf("no zfs mount found for mountpoint: %s", mountpoint)
@@ -76,23 +90,55 @@ func NewDataset(path string, hiddenZfsPath string) (*Dataset, error) {
 		// If not cached, resolve it directly by looking up its mountpoint
 		datasetName, err := findDatasetNameByMountpoint(path)
 		if err == nil {
-			libds, err := golibzfs.DatasetOpen(datasetName)
-			if err == nil {
-				cacheMtx.Lock()
-				datasetCache[path] = &libds
-				cacheMtx.Unlock()
-				dataset.rawGolibzfsData = &libds
-			}
+			dataset.name = datasetName
+			dataset.rawGolibzfsData = openLibzfsDataset(path, datasetName)
 		}
 	}
 
 	return dataset, nil
 }
 
+// newDatasetFromMount creates a dataset which has been resolved via the mount table
+func newDatasetFromMount(mount *datasetMount) *Dataset {
+	dataset := &Dataset{
+		Path:          mount.MountPoint,
+		HiddenZfsPath: mount.HiddenZfsPath,
+		name:          mount.DatasetName,
+	}
+	if mount.Root != "/" {
+		dataset.BindRoot = mount.Root
+	}
+
+	cacheMtx.RLock()
+	ds, cached := datasetCache[dataset.Path]
+	cacheMtx.RUnlock()
+	if cached {
+		dataset.rawGolibzfsData = ds
+	} else {
+		dataset.rawGolibzfsData = openLibzfsDataset(dataset.Path, dataset.name)
+	}
+	return dataset
+}
+
+// openLibzfsDataset opens the dataset with the given name and caches it by path
+func openLibzfsDataset(path string, datasetName string) *golibzfs.Dataset {
+	libds, err := golibzfs.DatasetOpen(datasetName)
+	if err != nil {
+		return nil
+	}
+	cacheMtx.Lock()
+	datasetCache[path] = &libds
+	cacheMtx.Unlock()
+	return &libds
+}
+
 func (dataset *Dataset) lazyLoadGozfsData() error {
 	if dataset.rawGozfsData != nil {
 		return nil
 	}
+	if !dataset.IsZfs() {
+		return fmt.Errorf("%s is not a zfs dataset", dataset.Path)
+	}
 
 	if dataset.rawGolibzfsData != nil {
 		nameProp, err := dataset.rawGolibzfsData.GetProperty(golibzfs.DatasetPropName)
@@ -105,6 +151,14 @@ func (dataset *Dataset) lazyLoadGozfsData() error {
 		}
 	}
 
+	if dataset.name != "" {
+		gozfsDs, err := gozfs.GetDataset(dataset.name)
+		if err == nil {
+			dataset.rawGozfsData = gozfsDs
+			return nil
+		}
+	}
+
 	// Fallback: if we haven't found metadata yet, try mistify/go-zfs directly.
 	gozfsList, err := gozfs.Filesystems("")
 	if err == nil {
@@ -119,13 +173,53 @@ func (dataset *Dataset) lazyLoadGozfsData() error {
 	return errors.New("could not load gozfs metadata")
 }
 
-// FindHostDataset returns the root path of the dataset containing this path
+// FindHostDataset returns the dataset containing this path.
+// Every configured SnapshotProvider is asked for a dataset, if several of them cover the path
+// (e.g. a snapper subvolume below a ZFS mountpoint), the innermost dataset is used.
 func FindHostDataset(path string) (*Dataset, error) {
 	if path == "" {
 		return nil, errors.New("cannot find host dataset for empty path")
 	}
 
+	var result *Dataset
+	for _, provider := range getSnapshotProviders() {
+		dataset, err := provider.FindDataset(path)
+		if errors.Is(err, errNoDataset) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if result == nil || len(dataset.Path) > len(result.Path) {
+			result = dataset
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("%w for path: %s", errNoDataset, path)
+	}
+	return result, nil
+}
+
+// findZfsDataset returns the zfs dataset containing this path.
+// The dataset is resolved using the mount table, which also covers bind mounts and datasets with a legacy mountpoint.
+// If the mount table is unavailable, the nearest ".zfs" directory is used.
+func findZfsDataset(path string) (*Dataset, error) {
 	var currentPath = gopath.Clean(path)
+	// the ".zfs" lookup must not leave the filesystem of the path, to not pick up the dataset it is mounted on
+	boundary := "/"
+
+	mounts, err := readMountInfo()
+	if err == nil {
+		mount, err := resolveDatasetMount(mounts, currentPath)
+		if err == nil {
+			return newDatasetFromMount(mount), nil
+		} else if !errors.Is(err, errNotOnDataset) {
+			return nil, err
+		}
+		if mount := findMountForPath(mounts, currentPath); mount != nil {
+			boundary = mount.MountPoint
+		}
+	}
+
 	for {
 		pathToTest := gopath.Join(currentPath, ".zfs")
 		stat, err := os.Lstat(pathToTest)
@@ -140,8 +234,8 @@ func FindHostDataset(path string) (*Dataset, error) {
 		// Navigate up
 		old := currentPath
 		currentPath = gopath.Dir(currentPath)
-		if old == currentPath {
-			return nil, fmt.Errorf("could not find dataset for path: %s", path)
+		if old == currentPath || old == boundary {
+			return nil, fmt.Errorf("%w for path: %s", errNoDataset, path)
 		}
 	}
 }
@@ -270,13 +364,38 @@ func (dataset *Dataset) GetSnapshotCount() int {
 	return dataset.getPropertyInt(golibzfs.DatasetPropSnapshotCount, propSnapshotCount, 0)
 }
 
-func (dataset *Dataset) CreateSnapshot(name string) error {
-	_ = dataset.lazyLoadGozfsData()
-	if dataset.rawGozfsData != nil {
-		_, err := dataset.rawGozfsData.Snapshot(name, false)
+// CreateSnapshot creates a snapshot of this dataset with the given user properties.
+// recursive - atomically snapshot all descendant datasets as well
+func (dataset *Dataset) CreateSnapshot(name string, recursive bool, properties map[string]string) error {
+	if err := ValidateSnapshotName(name); err != nil {
 		return err
 	}
-	return errors.New("cannot create snapshot: no dataset metadata available")
+	for key, value := range properties {
+		if err := util.ValidateUserPropertyName(key); err != nil {
+			return err
+		}
+		if err := ValidateUserPropertyValue(key, value); err != nil {
+			return err
+		}
+	}
+
+	_ = dataset.lazyLoadGozfsData()
+	datasetName := dataset.GetName()
+	if datasetName == "" {
+		return errors.New("cannot create snapshot: no dataset metadata available")
+	}
+
+	args := []string{"snapshot"}
+	if recursive {
+		args = append(args, "-r")
+	}
+	for _, key := range slices.Sorted(maps.Keys(properties)) {
+		args = append(args, "-o", fmt.Sprintf("%s=%s", key, properties[key]))
+	}
+	args = append(args, fmt.Sprintf("%s@%s", datasetName, name))
+
+	_, err := runZfsCommand(args...)
+	return err
 }
 
 func (dataset *Dataset) DestroySnapshot(name string, recursive bool, dependantClones bool) error {
@@ -302,13 +421,38 @@ func (dataset *Dataset) DestroySnapshot(name string, recursive bool, dependantCl
 	return errors.New("cannot destroy snapshot: no dataset metadata available")
 }
 
+// GetSnapshotsDir returns the directory containing the snapshots of this dataset.
+// Returns an empty string if the snapshots of this dataset are not located in a common directory.
 func (dataset *Dataset) GetSnapshotsDir() string {
+	if dataset.snapshotsDir != "" {
+		return dataset.snapshotsDir
+	}
+	if !dataset.IsZfs() {
+		return ""
+	}
 	return gopath.Join(dataset.HiddenZfsPath, "snapshot")
 }
 
 // GetSnapshots returns all snapshots for this dataset
 // Note: depending on the amount of snapshots, this can be a slow operation.
 func (dataset *Dataset) GetSnapshots() ([]*Snapshot, error) {
+	return dataset.GetProvider().ListSnapshots(dataset)
+}
+
+// refreshSnapshots returns all snapshots for this dataset, reusing the known snapshots (by path) which still exist
+func (dataset *Dataset) refreshSnapshots(known map[string]*Snapshot) ([]*Snapshot, error) {
+	if dataset.IsZfs() {
+		return dataset.listZfsSnapshotsReusing(known)
+	}
+	// snapshots of other providers are cheap to create, so there is nothing to gain from reusing them
+	return dataset.GetSnapshots()
+}
+
+func (dataset *Dataset) listZfsSnapshots() ([]*Snapshot, error) {
+	return dataset.listZfsSnapshotsReusing(nil)
+}
+
+func (dataset *Dataset) listZfsSnapshotsReusing(known map[string]*Snapshot) ([]*Snapshot, error) {
 	var result []*Snapshot
 
 	snapshotDirs, err := util.ListFilesIn(dataset.GetSnapshotsDir())
@@ -317,13 +461,25 @@ func (dataset *Dataset) GetSnapshots() ([]*Snapshot, error) {
 	}
 
 	var rawSnapshots []golibzfs.Dataset
-	if dataset.rawGolibzfsData != nil {
-		rawSnapshots, _ = dataset.rawGolibzfsData.Snapshots()
-	}
+	rawSnapshotsLoaded := false
 
+	var reused []*Snapshot
 	for _, file := range snapshotDirs {
+		if snapshot, ok := known[file]; ok {
+			// a copy, so the previous snapshot isn't modified while it is still shown
+			refreshed := *snapshot
+			reused = append(reused, &refreshed)
+			result = append(result, &refreshed)
+			continue
+		}
 		_, name := gopath.Split(file)
 
+		// only list the raw snapshots if there is a snapshot which is not known yet
+		if !rawSnapshotsLoaded && dataset.rawGolibzfsData != nil {
+			rawSnapshots, _ = dataset.rawGolibzfsData.Snapshots()
+			rawSnapshotsLoaded = true
+		}
+
 		var s *golibzfs.Dataset
 		if len(rawSnapshots) > 0 {
 			s = findSnapshot(rawSnapshots, name)
@@ -332,9 +488,29 @@ func (dataset *Dataset) GetSnapshots() ([]*Snapshot, error) {
 		result = append(result, NewSnapshot(name, file, dataset, s))
 	}
 
+	// the details of new snapshots are up-to-date, but used space etc. of reused ones may have changed since
+	if err := dataset.refreshDynamicProperties(reused); err != nil {
+		logging.Warning("Cannot refresh snapshot properties of %s: %s", dataset.GetName(), err.Error())
+	}
+
+	dataset.pruneMetadataCache(result)
+
 	return result, nil
 }
 
+// pruneMetadataCache drops the cached metadata of snapshots which have been destroyed since the last listing.
+// Nothing is dropped if the guid of any snapshot is unknown, since it can't be told which snapshots are gone.
+func (dataset *Dataset) pruneMetadataCache(snapshots []*Snapshot) {
+	guids := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if snapshot.Properties.Guid == "" {
+			return
+		}
+		guids = append(guids, snapshot.Properties.Guid)
+	}
+	pruneMetadataCache(dataset.GetName(), guids)
+}
+
 func (dataset *Dataset) GetName() string {
 	if dataset.rawGozfsData != nil {
 		return dataset.rawGozfsData.Name
@@ -345,5 +521,5 @@ func (dataset *Dataset) GetName() string {
 			return nameProperty.Value
 		}
 	}
-	return ""
+	return dataset.name
 }
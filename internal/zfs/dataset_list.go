@@ -0,0 +1,163 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DatasetTypeFilesystem = "filesystem"
+	DatasetTypeVolume     = "volume"
+)
+
+// DatasetSummary describes a dataset of any imported pool, see ListDatasets
+type DatasetSummary struct {
+	Name       string
+	Type       string
+	Mountpoint string
+	Mounted    bool
+	// CanMount is the value of the canmount property ("on", "off" or "noauto")
+	CanMount      string
+	Used          uint64
+	SnapshotCount int
+	Encryption    string
+	KeyStatus     string
+}
+
+// GetPoolName returns the name of the pool containing this dataset
+func (d *DatasetSummary) GetPoolName() string {
+	return getPoolName(d.Name)
+}
+
+// GetParentName returns the name of the parent dataset, or an empty string for the root dataset of a pool
+func (d *DatasetSummary) GetParentName() string {
+	index := strings.LastIndex(d.Name, "/")
+	if index < 0 {
+		return ""
+	}
+	return d.Name[:index]
+}
+
+func (d *DatasetSummary) IsEncrypted() bool {
+	return d.Encryption != "" && d.Encryption != "-" && d.Encryption != "off"
+}
+
+// IsBrowsable reports whether the dataset is mounted at a path that can be opened in the file browser
+func (d *DatasetSummary) IsBrowsable() bool {
+	return d.Type == DatasetTypeFilesystem && d.Mounted && strings.HasPrefix(d.Mountpoint, "/")
+}
+
+// GetUnavailableReason explains why the dataset cannot be opened in the file browser.
+// It returns an empty string for browsable datasets.
+func (d *DatasetSummary) GetUnavailableReason() string {
+	switch {
+	case d.IsBrowsable():
+		return ""
+	case d.Type == DatasetTypeVolume:
+		return "volumes have no filesystem to browse"
+	case d.CanMount == "off":
+		return "canmount=off, the dataset is never mounted"
+	case d.IsEncrypted() && d.KeyStatus == KeyStatusUnavailable:
+		return "the encryption key is not loaded"
+	case d.Mountpoint == "none":
+		return "mountpoint=none"
+	case d.Mountpoint == "legacy" && !d.Mounted:
+		return "mountpoint=legacy and not mounted via fstab"
+	case d.CanMount == "noauto":
+		return "canmount=noauto and not mounted"
+	default:
+		return "not mounted"
+	}
+}
+
+// ListDatasets returns all filesystems and volumes of all imported pools, in hierarchical order
+func ListDatasets() ([]*DatasetSummary, error) {
+	output, err := runZfsCommand(
+		"list", "-H", "-p",
+		"-t", "filesystem,volume",
+		"-o", "name,type,mountpoint,mounted,canmount,used,encryption,keystatus",
+	)
+	if err != nil {
+		return nil, err
+	}
+	datasets, err := parseDatasetList(output)
+	if err != nil {
+		return nil, err
+	}
+
+	// the snapshot_count property is only maintained for datasets with a snapshot limit
+	snapshotOutput, err := runZfsCommand("list", "-H", "-t", "snapshot", "-o", "name")
+	if err != nil {
+		return nil, err
+	}
+	snapshotCounts := countSnapshotsPerDataset(snapshotOutput)
+	for _, dataset := range datasets {
+		dataset.SnapshotCount = snapshotCounts[dataset.Name]
+	}
+
+	if mounts, err := readMountInfo(); err == nil {
+		resolveLegacyMountpoints(datasets, mounts)
+	}
+	return datasets, nil
+}
+
+// resolveLegacyMountpoints replaces the mountpoint of mounted datasets with mountpoint=legacy
+// with the path they are actually mounted at
+func resolveLegacyMountpoints(datasets []*DatasetSummary, mounts []*mountInfo) {
+	for _, dataset := range datasets {
+		if dataset.Mountpoint != "legacy" || !dataset.Mounted {
+			continue
+		}
+		for _, mount := range mounts {
+			if mount.FsType == "zfs" && mount.Source == dataset.Name && mount.Root == "/" {
+				dataset.Mountpoint = mount.MountPoint
+				break
+			}
+		}
+	}
+}
+
+// parseDatasetList parses the output of
+// "zfs list -H -p -o name,type,mountpoint,mounted,canmount,used,encryption,keystatus"
+func parseDatasetList(output string) ([]*DatasetSummary, error) {
+	var result []*DatasetSummary
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 8 {
+			return nil, errors.New("unexpected zfs list output: " + line)
+		}
+		used, err := strconv.ParseUint(fields[5], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected used value of dataset %s: %w", fields[0], err)
+		}
+		result = append(result, &DatasetSummary{
+			Name:       fields[0],
+			Type:       fields[1],
+			Mountpoint: fields[2],
+			Mounted:    fields[3] == "yes",
+			CanMount:   fields[4],
+			Used:       used,
+			Encryption: fields[6],
+			KeyStatus:  fields[7],
+		})
+	}
+	return result, nil
+}
+
+// countSnapshotsPerDataset counts the snapshot names of "zfs list -H -t snapshot -o name" by dataset name
+func countSnapshotsPerDataset(output string) map[string]int {
+	result := map[string]int{}
+	for _, line := range strings.Split(output, "\n") {
+		datasetName, _, found := strings.Cut(strings.TrimSpace(line), "@")
+		if !found {
+			continue
+		}
+		result[datasetName]++
+	}
+	return result
+}
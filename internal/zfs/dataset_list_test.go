@@ -0,0 +1,85 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDatasetList(t *testing.T) {
+	output := "tank\tfilesystem\t/tank\tyes\ton\t1024\toff\t-\n" +
+		"tank/secure\tfilesystem\t/tank/secure\tno\ton\t2048\taes-256-gcm\tunavailable\n" +
+		"tank/vm\tvolume\t-\t-\t-\t4096\toff\t-\n"
+
+	result, err := parseDatasetList(output)
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	assert.Equal(t, &DatasetSummary{
+		Name:       "tank/secure",
+		Type:       DatasetTypeFilesystem,
+		Mountpoint: "/tank/secure",
+		Mounted:    false,
+		CanMount:   "on",
+		Used:       2048,
+		Encryption: "aes-256-gcm",
+		KeyStatus:  KeyStatusUnavailable,
+	}, result[1])
+
+	_, err = parseDatasetList("tank\tfilesystem\t/tank\n")
+	assert.Error(t, err)
+	_, err = parseDatasetList("tank\tfilesystem\t/tank\tyes\ton\tmuch\toff\t-\n")
+	assert.Error(t, err)
+}
+
+func TestCountSnapshotsPerDataset(t *testing.T) {
+	output := "tank@a\ntank/data@a\ntank/data@b\n\n"
+
+	assert.Equal(t, map[string]int{"tank": 1, "tank/data": 2}, countSnapshotsPerDataset(output))
+}
+
+func TestDatasetSummaryGetParentName(t *testing.T) {
+	assert.Equal(t, "", (&DatasetSummary{Name: "tank"}).GetParentName())
+	assert.Equal(t, "tank/data", (&DatasetSummary{Name: "tank/data/child"}).GetParentName())
+	assert.Equal(t, "tank", (&DatasetSummary{Name: "tank/data/child"}).GetPoolName())
+}
+
+func TestDatasetSummaryGetUnavailableReason(t *testing.T) {
+	mounted := &DatasetSummary{Type: DatasetTypeFilesystem, Mountpoint: "/tank", Mounted: true, CanMount: "on"}
+	assert.True(t, mounted.IsBrowsable())
+	assert.Equal(t, "", mounted.GetUnavailableReason())
+
+	tests := []struct {
+		dataset  *DatasetSummary
+		expected string
+	}{
+		{&DatasetSummary{Type: DatasetTypeVolume, Mountpoint: "-"}, "volumes"},
+		{&DatasetSummary{Type: DatasetTypeFilesystem, Mountpoint: "/tank/archive", CanMount: "off"}, "canmount=off"},
+		{&DatasetSummary{Type: DatasetTypeFilesystem, Mountpoint: "/tank/secure", CanMount: "on", Encryption: "aes-256-gcm", KeyStatus: KeyStatusUnavailable}, "encryption key"},
+		{&DatasetSummary{Type: DatasetTypeFilesystem, Mountpoint: "none", CanMount: "on"}, "mountpoint=none"},
+		{&DatasetSummary{Type: DatasetTypeFilesystem, Mountpoint: "legacy", CanMount: "on"}, "mountpoint=legacy"},
+		{&DatasetSummary{Type: DatasetTypeFilesystem, Mountpoint: "/tank/manual", CanMount: "noauto"}, "canmount=noauto"},
+		{&DatasetSummary{Type: DatasetTypeFilesystem, Mountpoint: "/tank/data", CanMount: "on"}, "not mounted"},
+	}
+	for _, test := range tests {
+		assert.False(t, test.dataset.IsBrowsable())
+		assert.Contains(t, test.dataset.GetUnavailableReason(), test.expected)
+	}
+}
+
+func TestResolveLegacyMountpoints(t *testing.T) {
+	datasets := []*DatasetSummary{
+		{Name: "tank/legacy", Mountpoint: "legacy", Mounted: true},
+		{Name: "tank/unmounted", Mountpoint: "legacy", Mounted: false},
+		{Name: "tank/data", Mountpoint: "/srv/data", Mounted: true},
+	}
+	mounts := []*mountInfo{
+		{Root: "/", MountPoint: "/mnt/legacy", FsType: "zfs", Source: "tank/legacy"},
+		{Root: "/", MountPoint: "/srv/data", FsType: "zfs", Source: "tank/data"},
+	}
+
+	resolveLegacyMountpoints(datasets, mounts)
+
+	assert.Equal(t, "/mnt/legacy", datasets[0].Mountpoint)
+	assert.Equal(t, "legacy", datasets[1].Mountpoint)
+	assert.Equal(t, "/srv/data", datasets[2].Mountpoint)
+}
@@ -1,6 +1,8 @@
 package zfs
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,3 +16,37 @@ func TestDataset_GetSnapshotsDir(t *testing.T) {
 
 	assert.Equal(t, "/pool/ds1/.zfs/snapshot", dataset.GetSnapshotsDir())
 }
+
+func TestDataset_GetSnapshotsDir_DirectoryProvider(t *testing.T) {
+	provider, err := NewDirectoryProvider("/home", "/backup/*/home")
+	assert.NoError(t, err)
+	dataset, err := provider.FindDataset("/home/user")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "", dataset.GetSnapshotsDir())
+}
+
+func TestDataset_RefreshSnapshots_ReusesKnownSnapshots(t *testing.T) {
+	root := t.TempDir()
+	snapshotsDir := filepath.Join(root, ".zfs", "snapshot")
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshotsDir, "kept"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshotsDir, "new"), 0755))
+	dataset := &Dataset{
+		Path:          root,
+		HiddenZfsPath: filepath.Join(root, ".zfs"),
+		name:          "pool/ds1",
+	}
+
+	kept := &Snapshot{Name: "kept", Path: filepath.Join(snapshotsDir, "kept"), ParentDataset: dataset}
+	removed := &Snapshot{Name: "removed", Path: filepath.Join(snapshotsDir, "removed"), ParentDataset: dataset}
+	snapshots, err := dataset.refreshSnapshots(map[string]*Snapshot{
+		kept.Path:    kept,
+		removed.Path: removed,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"kept", "new"}, snapshotNames(snapshots))
+	assert.Contains(t, snapshots, kept)
+	// the previous snapshot is not modified while its dynamic properties are refreshed
+	assert.NotSame(t, kept, snapshots[0])
+}
@@ -0,0 +1,184 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"zfs-file-history/internal/logging"
+)
+
+// DestroyStatus describes the outcome of destroying a single snapshot as part of a batch
+type DestroyStatus int
+
+const (
+	DestroyStatusDestroyed DestroyStatus = iota
+	// DestroyStatusSkippedHold means the snapshot was not destroyed, because it has user holds
+	DestroyStatusSkippedHold
+	// DestroyStatusSkippedClones means the snapshot was not destroyed, because it has dependent clones
+	DestroyStatusSkippedClones
+	DestroyStatusFailed
+)
+
+// DestroyResult is the per-snapshot outcome of DestroySnapshots
+type DestroyResult struct {
+	Snapshot *Snapshot
+	Status   DestroyStatus
+	// Reason explains why the snapshot was skipped or why destroying it failed
+	Reason string
+}
+
+// IsSkipped returns true if the snapshot was left untouched on purpose
+func (r *DestroyResult) IsSkipped() bool {
+	return r.Status == DestroyStatusSkippedHold || r.Status == DestroyStatusSkippedClones
+}
+
+// DestroyEstimate is the result of a dry-run destroy of a set of snapshots
+type DestroyEstimate struct {
+	// Reclaimable is the amount of space in bytes which would be freed
+	Reclaimable uint64
+	// Skipped contains the snapshots which would not be destroyed, and are therefore not part of the estimate
+	Skipped []*DestroyResult
+}
+
+// EstimateDestroy determines how much space would be freed by destroying the given snapshots,
+// equivalent to "zfs destroy -nvp pool/ds@a,b".
+// Snapshots which DestroySnapshots would skip are excluded, since a single one of them
+// would otherwise make the dry-run of the whole set fail.
+func EstimateDestroy(snapshots []*Snapshot, recursive bool) (*DestroyEstimate, error) {
+	result := &DestroyEstimate{}
+
+	holds, err := getHolds(snapshots)
+	if err != nil {
+		return nil, err
+	}
+
+	var datasetNames []string
+	snapshotNamesByDataset := map[string][]string{}
+	for _, snapshot := range snapshots {
+		if skipped := checkDestroyable(snapshot, holds[snapshot.FullName], recursive); skipped != nil {
+			result.Skipped = append(result.Skipped, skipped)
+			continue
+		}
+		datasetName := snapshot.ParentDataset.GetName()
+		if _, ok := snapshotNamesByDataset[datasetName]; !ok {
+			datasetNames = append(datasetNames, datasetName)
+		}
+		snapshotNamesByDataset[datasetName] = append(snapshotNamesByDataset[datasetName], snapshot.Name)
+	}
+
+	for _, datasetName := range datasetNames {
+		args := []string{"destroy", "-n", "-v", "-p"}
+		if recursive {
+			args = append(args, "-r", "-R")
+		}
+		args = append(args, fmt.Sprintf("%s@%s", datasetName, strings.Join(snapshotNamesByDataset[datasetName], ",")))
+
+		output, err := runZfsCommand(args...)
+		if err != nil {
+			return nil, err
+		}
+		reclaimable, err := parseDestroyDryRun(output)
+		if err != nil {
+			return nil, err
+		}
+		result.Reclaimable += reclaimable
+	}
+
+	return result, nil
+}
+
+// DestroySnapshots destroys all given snapshots one by one.
+// Snapshots with user holds, or with dependent clones in a non-recursive destroy, are skipped.
+// A failure does not abort the batch, so the returned results always cover every given snapshot.
+func DestroySnapshots(snapshots []*Snapshot, recursive bool) []*DestroyResult {
+	holds, err := getHolds(snapshots)
+	if err != nil {
+		// zfs itself refuses to destroy held snapshots, so we can still go ahead
+		logging.Error("Could not determine snapshot holds: %s", err.Error())
+	}
+
+	result := make([]*DestroyResult, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if skipped := checkDestroyable(snapshot, holds[snapshot.FullName], recursive); skipped != nil {
+			result = append(result, skipped)
+			continue
+		}
+		if err := snapshot.Destroy(recursive, recursive); err != nil {
+			logging.Error("Failed to destroy snapshot %s: %s", snapshot.FullName, err.Error())
+			result = append(result, &DestroyResult{Snapshot: snapshot, Status: DestroyStatusFailed, Reason: err.Error()})
+			continue
+		}
+		result = append(result, &DestroyResult{Snapshot: snapshot, Status: DestroyStatusDestroyed})
+	}
+	return result
+}
+
+// checkDestroyable returns a skipped result if the given snapshot must not be destroyed, nil otherwise
+func checkDestroyable(snapshot *Snapshot, holds []string, recursive bool) *DestroyResult {
+	if len(holds) > 0 {
+		return &DestroyResult{
+			Snapshot: snapshot,
+			Status:   DestroyStatusSkippedHold,
+			Reason:   fmt.Sprintf("held by %s", strings.Join(holds, ", ")),
+		}
+	}
+	if !recursive {
+		if clones := snapshot.GetClones(); clones > 0 {
+			return &DestroyResult{
+				Snapshot: snapshot,
+				Status:   DestroyStatusSkippedClones,
+				Reason:   fmt.Sprintf("has %d dependent clone(s)", clones),
+			}
+		}
+	}
+	return nil
+}
+
+// getHolds returns the hold tags of the given snapshots, keyed by their full name
+func getHolds(snapshots []*Snapshot) (map[string][]string, error) {
+	if len(snapshots) == 0 {
+		return map[string][]string{}, nil
+	}
+	args := []string{"holds", "-H"}
+	for _, snapshot := range snapshots {
+		args = append(args, snapshot.FullName)
+	}
+	output, err := runZfsCommand(args...)
+	if err != nil {
+		return map[string][]string{}, err
+	}
+	return parseHolds(output)
+}
+
+// parseHolds parses the output of "zfs holds -H"
+func parseHolds(output string) (map[string][]string, error) {
+	result := map[string][]string{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, errors.New("unexpected zfs holds output: " + line)
+		}
+		result[fields[0]] = append(result[fields[0]], fields[1])
+	}
+	return result, nil
+}
+
+// parseDestroyDryRun extracts the reclaimable space from the output of "zfs destroy -nvp"
+func parseDestroyDryRun(output string) (uint64, error) {
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "\t")
+		if !found || key != "reclaim" {
+			continue
+		}
+		reclaimable, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected reclaim value in zfs destroy output: %w", err)
+		}
+		return reclaimable, nil
+	}
+	return 0, errors.New("zfs destroy dry-run did not report reclaimable space")
+}
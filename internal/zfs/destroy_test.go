@@ -0,0 +1,57 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDestroyDryRun(t *testing.T) {
+	output := "destroy\ttank/data@a\n" +
+		"destroy\ttank/data@b\n" +
+		"reclaim\t1048576\n"
+
+	reclaimable, err := parseDestroyDryRun(output)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1048576), reclaimable)
+
+	_, err = parseDestroyDryRun("destroy\ttank/data@a\n")
+	assert.Error(t, err)
+	_, err = parseDestroyDryRun("reclaim\tlots\n")
+	assert.Error(t, err)
+}
+
+func TestParseHolds(t *testing.T) {
+	output := "tank/data@a\tkeep\tMon Jan  1 10:00 2024\n" +
+		"tank/data@a\tbackup\tMon Jan  1 11:00 2024\n" +
+		"tank/data@b\tkeep\tTue Jan  2 10:00 2024\n"
+
+	result, err := parseHolds(output)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"tank/data@a": {"keep", "backup"},
+		"tank/data@b": {"keep"},
+	}, result)
+
+	result, err = parseHolds("")
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	_, err = parseHolds("tank/data@a\tkeep\n")
+	assert.Error(t, err)
+}
+
+func TestCheckDestroyable(t *testing.T) {
+	s := &Snapshot{Name: "a", FullName: "tank/data@a", ParentDataset: &Dataset{}}
+
+	assert.Nil(t, checkDestroyable(s, nil, false))
+
+	result := checkDestroyable(s, []string{"keep", "backup"}, true)
+	assert.Equal(t, &DestroyResult{
+		Snapshot: s,
+		Status:   DestroyStatusSkippedHold,
+		Reason:   "held by keep, backup",
+	}, result)
+	assert.True(t, result.IsSkipped())
+	assert.False(t, (&DestroyResult{Status: DestroyStatusFailed}).IsSkipped())
+}
@@ -0,0 +1,65 @@
+package zfs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// dynamicSnapshotProperties are the properties of a snapshot which change while it exists,
+// e.g. "used" grows when a neighbouring snapshot sharing its blocks is destroyed
+var dynamicSnapshotProperties = []string{"used", "referenced", "written", "clones"}
+
+// refreshDynamicProperties reads the dynamic properties of the given snapshots of this dataset
+// with a single zfs call, so previously listed snapshots don't show outdated values
+func (dataset *Dataset) refreshDynamicProperties(snapshots []*Snapshot) error {
+	if len(snapshots) == 0 || !dataset.IsZfs() {
+		return nil
+	}
+	_ = dataset.lazyLoadGozfsData()
+	datasetName := dataset.GetName()
+	if datasetName == "" {
+		return errors.New("cannot refresh snapshot properties: no dataset metadata available")
+	}
+
+	output, err := runZfsCommand(
+		"get", "-H", "-p",
+		"-t", "snapshot", "-d", "1",
+		"-o", "name,property,value",
+		strings.Join(dynamicSnapshotProperties, ","),
+		datasetName,
+	)
+	if err != nil {
+		return err
+	}
+
+	properties, err := parseSnapshotProperties(output)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		applyDynamicProperties(&snapshot.Properties, properties[snapshot.FullName])
+	}
+	return nil
+}
+
+// applyDynamicProperties sets the given values of dynamicSnapshotProperties, values which are missing
+// or cannot be parsed are left unchanged
+func applyDynamicProperties(properties *SnapshotProperties, values map[string]string) {
+	if used, err := strconv.ParseUint(values["used"], 10, 64); err == nil {
+		properties.Used = used
+	}
+	if referenced, err := strconv.ParseUint(values["referenced"], 10, 64); err == nil {
+		properties.Referenced = referenced
+	}
+	if written, err := strconv.ParseUint(values["written"], 10, 64); err == nil {
+		properties.Written = written
+	}
+	if clones, ok := values["clones"]; ok {
+		// a comma separated list of the names of the clones
+		properties.Clones = 0
+		if clones != "" && clones != "-" {
+			properties.Clones = uint64(len(strings.Split(clones, ",")))
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyDynamicProperties(t *testing.T) {
+	properties := SnapshotProperties{Guid: "1234", Used: 1, Referenced: 2, Written: 3, Clones: 1}
+
+	applyDynamicProperties(&properties, map[string]string{
+		"used":       "1024",
+		"referenced": "2048",
+		"written":    "4096",
+		"clones":     "tank/clone1,tank/clone2",
+	})
+	assert.Equal(t, SnapshotProperties{Guid: "1234", Used: 1024, Referenced: 2048, Written: 4096, Clones: 2}, properties)
+
+	applyDynamicProperties(&properties, map[string]string{"used": "-", "clones": ""})
+	assert.Equal(t, uint64(1024), properties.Used)
+	assert.Equal(t, uint64(0), properties.Clones)
+
+	applyDynamicProperties(&properties, nil)
+	assert.Equal(t, uint64(4096), properties.Written)
+}
@@ -0,0 +1,132 @@
+package zfs
+
+import (
+	"errors"
+	gopath "path"
+	"sort"
+	"strings"
+
+	golibzfs "github.com/kraudcloud/go-libzfs"
+)
+
+const (
+	KeyStatusUnavailable = "unavailable"
+	// KeyLocationPrompt is the keylocation of datasets whose key has to be entered by the user
+	KeyLocationPrompt = "prompt"
+)
+
+// IsKeyUnavailable reports whether this dataset is encrypted and its key is not loaded.
+// The contents and snapshots of such a dataset are not accessible.
+func (dataset *Dataset) IsKeyUnavailable() bool {
+	return dataset.IsEncrypted() && dataset.GetKeyStatus() == KeyStatusUnavailable
+}
+
+func (dataset *Dataset) GetKeyLocation() string {
+	return dataset.getPropertyString(golibzfs.DatasetPropKeyLocation, propKeylocation)
+}
+
+// RequiresKeyPrompt reports whether the key of this dataset can only be loaded by entering it
+func (dataset *Dataset) RequiresKeyPrompt() bool {
+	return requiresKeyPrompt(dataset.GetKeyLocation())
+}
+
+// LoadKey loads the encryption key of this dataset and mounts it.
+// passphrase - the key to load, if empty the key is read from the keylocation of the dataset
+func (dataset *Dataset) LoadKey(passphrase string) error {
+	return loadKey(dataset.GetName(), passphrase)
+}
+
+// AsLockedDataset returns this dataset as a LockedDataset, e.g. to load its key
+func (dataset *Dataset) AsLockedDataset() *LockedDataset {
+	return &LockedDataset{
+		Name:        dataset.GetName(),
+		Mountpoint:  dataset.GetMountPoint(),
+		KeyLocation: dataset.GetKeyLocation(),
+	}
+}
+
+// LockedDataset is an encrypted dataset whose key is not loaded.
+// Such a dataset cannot be mounted, so its mountpoint is an empty directory of its parent (if it exists at all).
+type LockedDataset struct {
+	Name        string
+	Mountpoint  string
+	KeyLocation string
+}
+
+// RequiresKeyPrompt reports whether the key of this dataset can only be loaded by entering it
+func (dataset *LockedDataset) RequiresKeyPrompt() bool {
+	return requiresKeyPrompt(dataset.KeyLocation)
+}
+
+// LoadKey loads the encryption key of this dataset and mounts it, see Dataset.LoadKey
+func (dataset *LockedDataset) LoadKey(passphrase string) error {
+	return loadKey(dataset.Name, passphrase)
+}
+
+// FindLockedDatasets returns the encrypted datasets whose key is not loaded and which would be mounted
+// at or below the given path, ordered by their mountpoint. Since such datasets are not mounted,
+// they can't be found by looking at the mounts of the path.
+func FindLockedDatasets(path string) ([]*LockedDataset, error) {
+	output, err := runZfsCommand(
+		"list", "-H",
+		"-t", "filesystem",
+		"-o", "name,mountpoint,keystatus,keylocation",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseLockedDatasets(output, path)
+}
+
+// parseLockedDatasets parses the output of "zfs list -H -o name,mountpoint,keystatus,keylocation"
+// and returns the datasets with keystatus=unavailable mounted at or below path
+func parseLockedDatasets(output string, path string) ([]*LockedDataset, error) {
+	path = gopath.Clean(path)
+	var result []*LockedDataset
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			return nil, errors.New("unexpected zfs list output: " + line)
+		}
+		name, mountpoint, keyStatus, keyLocation := fields[0], fields[1], fields[2], fields[3]
+		// mountpoint may also be "none" or "legacy", which can't be located in the file system
+		if keyStatus != KeyStatusUnavailable || !strings.HasPrefix(mountpoint, "/") || !isPathWithin(mountpoint, path) {
+			continue
+		}
+		result = append(result, &LockedDataset{Name: name, Mountpoint: mountpoint, KeyLocation: keyLocation})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Mountpoint < result[j].Mountpoint
+	})
+	return result, nil
+}
+
+func requiresKeyPrompt(keyLocation string) bool {
+	return keyLocation == "" || keyLocation == KeyLocationPrompt
+}
+
+func loadKey(name string, passphrase string) error {
+	if name == "" {
+		return errors.New("cannot load key: no dataset metadata available")
+	}
+
+	var err error
+	if passphrase != "" {
+		_, err = runZfsCommandWithInput(passphrase+"\n", "load-key", "-L", KeyLocationPrompt, name)
+	} else {
+		_, err = runZfsCommand("load-key", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	// a dataset cannot be mounted without its key, so it has to be mounted now
+	_, err = runZfsCommand("mount", name)
+	if err != nil && !strings.Contains(err.Error(), "already mounted") {
+		return err
+	}
+	return nil
+}
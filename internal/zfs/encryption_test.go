@@ -0,0 +1,41 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLockedDatasets(t *testing.T) {
+	output := "tank\t/tank\tavailable\tprompt\n" +
+		"tank/home\t/tank/home\tavailable\tprompt\n" +
+		"tank/home/secret\t/tank/home/secret\tunavailable\tfile:///etc/zfs/secret.key\n" +
+		"tank/other\t/tank/other\tunavailable\tprompt\n" +
+		"tank/hidden\tnone\tunavailable\tprompt\n" +
+		"tank/plain\t/tank/plain\t-\tnone\n"
+
+	// the parent is mounted and has its key loaded, the locked child is only found by looking below the path
+	result, err := parseLockedDatasets(output, "/tank/home/")
+	assert.NoError(t, err)
+	assert.Equal(t, []*LockedDataset{
+		{Name: "tank/home/secret", Mountpoint: "/tank/home/secret", KeyLocation: "file:///etc/zfs/secret.key"},
+	}, result)
+	assert.False(t, result[0].RequiresKeyPrompt())
+
+	result, err = parseLockedDatasets(output, "/tank/home/secret")
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+
+	result, err = parseLockedDatasets(output, "/tank")
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "tank/other", result[1].Name)
+	assert.True(t, result[1].RequiresKeyPrompt())
+
+	result, err = parseLockedDatasets(output, "/tank/plain")
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	_, err = parseLockedDatasets("tank\t/tank\n", "/")
+	assert.Error(t, err)
+}
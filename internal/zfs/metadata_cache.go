@@ -0,0 +1,373 @@
+package zfs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	gopath "path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// metadataCacheVersion is part of the cache path, it has to be increased whenever the format of the cache changes
+	metadataCacheVersion      = "v2"
+	metadataCachePropertyFile = "snapshots.json"
+	metadataCacheStatsDir     = "stats"
+
+	// metadataCacheFlushInterval is the minimum time between two writes of FlushMetadataCacheThrottled
+	metadataCacheFlushInterval = 30 * time.Second
+)
+
+// FileMeta is the result of an lstat of a file within a snapshot
+type FileMeta struct {
+	Exists  bool        `json:"exists"`
+	IsDir   bool        `json:"isDir,omitempty"`
+	Size    int64       `json:"size,omitempty"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	ModTime time.Time   `json:"modTime,omitempty"`
+}
+
+func newFileMeta(stat os.FileInfo) FileMeta {
+	return FileMeta{
+		Exists:  true,
+		IsDir:   stat.IsDir(),
+		Size:    stat.Size(),
+		Mode:    stat.Mode(),
+		ModTime: stat.ModTime(),
+	}
+}
+
+// IsDifferentFrom reports whether the file described by stat differs from this one
+func (meta FileMeta) IsDifferentFrom(stat os.FileInfo) bool {
+	return meta.IsDir != stat.IsDir() ||
+		meta.Mode != stat.Mode() ||
+		!meta.ModTime.Equal(stat.ModTime()) ||
+		meta.Size != stat.Size()
+}
+
+// cachedSnapshotProperties are the properties of a snapshot which never change.
+// Properties like "used" or "clones" depend on other snapshots and clones and are always read from zfs.
+type cachedSnapshotProperties struct {
+	Creation         time.Time `json:"creation"`
+	Referenced       uint64    `json:"referenced"`
+	CompressionRatio float64   `json:"compressionRatio"`
+}
+
+// cachedPropertyFile is the content of the property file of the metadata cache
+type cachedPropertyFile struct {
+	Snapshots map[string]cachedSnapshotProperties `json:"snapshots"`
+	// Datasets maps the name of a dataset to the guids of its snapshots, as of the last time they have been listed
+	Datasets map[string][]string `json:"datasets"`
+}
+
+// metadataCache persists metadata of snapshots across runs.
+// Snapshots are immutable, so entries keyed by the guid of a snapshot never have to be invalidated.
+// Entries are dropped once their snapshot is no longer listed by any dataset.
+type metadataCache struct {
+	dir string
+
+	mtx sync.Mutex
+	// properties is nil until the property file has been read
+	properties        map[string]cachedSnapshotProperties
+	datasetGuids      map[string][]string
+	propertiesChanged bool
+	// stats maps the guid of a snapshot to the metadata of files within it, keyed by their path relative to the snapshot
+	stats        map[string]map[string]FileMeta
+	changedStats map[string]bool
+	// removedStats are the guids of pruned snapshots whose stats file still has to be deleted
+	removedStats map[string]bool
+	lastFlush    time.Time
+}
+
+var (
+	currentMetadataCache *metadataCache
+	metadataCacheMtx     sync.RWMutex
+)
+
+// ConfigureMetadataCache sets the directory of the persistent metadata cache, an empty dir disables it.
+// Pending changes of a previously configured cache are discarded.
+func ConfigureMetadataCache(dir string) {
+	var cache *metadataCache
+	if dir != "" {
+		cache = newMetadataCache(gopath.Join(dir, metadataCacheVersion))
+	}
+	metadataCacheMtx.Lock()
+	defer metadataCacheMtx.Unlock()
+	currentMetadataCache = cache
+}
+
+// FlushMetadataCache writes all new entries of the metadata cache to disk
+func FlushMetadataCache() error {
+	cache := getMetadataCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.flush()
+}
+
+// FlushMetadataCacheThrottled writes the new entries of the metadata cache to disk,
+// unless it has already been written within the last metadataCacheFlushInterval.
+// Skipped entries are written by a later call, or by FlushMetadataCache on exit.
+func FlushMetadataCacheThrottled() error {
+	cache := getMetadataCache()
+	if cache == nil || !cache.isFlushDue(time.Now()) {
+		return nil
+	}
+	return cache.flush()
+}
+
+// pruneMetadataCache records the guids of all snapshots of the given dataset
+// and drops the entries of its previous snapshots, which are not listed by any dataset anymore
+func pruneMetadataCache(dataset string, guids []string) {
+	cache := getMetadataCache()
+	if cache == nil {
+		return
+	}
+	cache.prune(dataset, guids)
+}
+
+func getMetadataCache() *metadataCache {
+	metadataCacheMtx.RLock()
+	defer metadataCacheMtx.RUnlock()
+	return currentMetadataCache
+}
+
+func newMetadataCache(dir string) *metadataCache {
+	return &metadataCache{
+		dir:          dir,
+		stats:        map[string]map[string]FileMeta{},
+		changedStats: map[string]bool{},
+		removedStats: map[string]bool{},
+	}
+}
+
+func (cache *metadataCache) getProperties(guid string) (cachedSnapshotProperties, bool) {
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	cache.loadPropertiesLocked()
+	properties, ok := cache.properties[guid]
+	return properties, ok
+}
+
+func (cache *metadataCache) putProperties(guid string, properties cachedSnapshotProperties) {
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	cache.loadPropertiesLocked()
+	cache.properties[guid] = properties
+	cache.propertiesChanged = true
+}
+
+func (cache *metadataCache) loadPropertiesLocked() {
+	if cache.properties != nil {
+		return
+	}
+	var file cachedPropertyFile
+	// a missing or unreadable cache is simply rebuilt
+	_ = readJsonFile(gopath.Join(cache.dir, metadataCachePropertyFile), &file)
+	cache.properties = file.Snapshots
+	if cache.properties == nil {
+		cache.properties = map[string]cachedSnapshotProperties{}
+	}
+	cache.datasetGuids = file.Datasets
+	if cache.datasetGuids == nil {
+		cache.datasetGuids = map[string][]string{}
+	}
+}
+
+func (cache *metadataCache) prune(dataset string, guids []string) {
+	guids = slices.Clone(guids)
+	slices.Sort(guids)
+
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	cache.loadPropertiesLocked()
+	previous := cache.datasetGuids[dataset]
+	if slices.Equal(previous, guids) {
+		return
+	}
+	cache.datasetGuids[dataset] = guids
+	cache.propertiesChanged = true
+
+	listed := map[string]bool{}
+	for _, datasetGuids := range cache.datasetGuids {
+		for _, guid := range datasetGuids {
+			listed[guid] = true
+		}
+	}
+	for _, guid := range previous {
+		if listed[guid] {
+			continue
+		}
+		delete(cache.properties, guid)
+		delete(cache.stats, guid)
+		delete(cache.changedStats, guid)
+		cache.removedStats[guid] = true
+	}
+}
+
+func (cache *metadataCache) getFileMeta(guid string, relativePath string) (FileMeta, bool) {
+	stats := cache.loadStats(guid)
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	meta, ok := stats[relativePath]
+	return meta, ok
+}
+
+func (cache *metadataCache) putFileMeta(guid string, relativePath string, meta FileMeta) {
+	stats := cache.loadStats(guid)
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	stats[relativePath] = meta
+	cache.changedStats[guid] = true
+	delete(cache.removedStats, guid)
+}
+
+// loadStats returns the cached file metadata of a snapshot, reading it from disk on first access
+func (cache *metadataCache) loadStats(guid string) map[string]FileMeta {
+	cache.mtx.Lock()
+	stats, loaded := cache.stats[guid]
+	cache.mtx.Unlock()
+	if loaded {
+		return stats
+	}
+
+	// read without holding the lock, to not block the lookups of other snapshots
+	stats = map[string]FileMeta{}
+	_ = readJsonFile(cache.statsFile(guid), &stats)
+
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	if existing, ok := cache.stats[guid]; ok {
+		return existing
+	}
+	cache.stats[guid] = stats
+	return stats
+}
+
+func (cache *metadataCache) statsFile(guid string) string {
+	return gopath.Join(cache.dir, metadataCacheStatsDir, guid+".json")
+}
+
+func (cache *metadataCache) isFlushDue(now time.Time) bool {
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	return now.Sub(cache.lastFlush) >= metadataCacheFlushInterval
+}
+
+func (cache *metadataCache) flush() error {
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	cache.lastFlush = time.Now()
+
+	var errs []error
+	if cache.propertiesChanged {
+		file := cachedPropertyFile{Snapshots: cache.properties, Datasets: cache.datasetGuids}
+		if err := writeJsonFile(gopath.Join(cache.dir, metadataCachePropertyFile), file); err != nil {
+			errs = append(errs, err)
+		} else {
+			cache.propertiesChanged = false
+		}
+	}
+	for guid := range cache.changedStats {
+		if err := writeJsonFile(cache.statsFile(guid), cache.stats[guid]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(cache.changedStats, guid)
+	}
+	for guid := range cache.removedStats {
+		if err := os.Remove(cache.statsFile(guid)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			continue
+		}
+		delete(cache.removedStats, guid)
+	}
+	return errors.Join(errs...)
+}
+
+func readJsonFile(path string, target any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, target)
+}
+
+// writeJsonFile replaces the given file atomically, so concurrent instances never read a partially written file
+func writeJsonFile(path string, value any) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	dir := gopath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("cannot create cache directory: %w", err)
+	}
+	file, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	if _, err := file.Write(content); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// StatFile returns the metadata of the given file of the dataset within this snapshot.
+// Results are kept in the metadata cache, if it is configured and the guid of this snapshot is known.
+func (s *Snapshot) StatFile(path string) (FileMeta, error) {
+	snapshotPath := s.GetSnapshotPath(path)
+	cache := getMetadataCache()
+	guid := s.Properties.Guid
+	relativePath, withinSnapshot := strings.CutPrefix(snapshotPath, s.Path)
+	useCache := cache != nil && guid != "" && withinSnapshot
+
+	if useCache {
+		if meta, ok := cache.getFileMeta(guid, relativePath); ok {
+			return meta, nil
+		}
+	}
+
+	var meta FileMeta
+	stat, err := os.Lstat(snapshotPath)
+	if err == nil {
+		meta = newFileMeta(stat)
+	} else if !os.IsNotExist(err) {
+		return FileMeta{}, err
+	}
+
+	if useCache {
+		cache.putFileMeta(guid, relativePath, meta)
+	}
+	return meta, nil
+}
+
+// fetchImmutableProperties returns the properties which never change, from the metadata cache if possible
+func (s *Snapshot) fetchImmutableProperties(guid string) cachedSnapshotProperties {
+	cache := getMetadataCache()
+	if cache != nil && guid != "" {
+		if properties, ok := cache.getProperties(guid); ok {
+			return properties
+		}
+	}
+
+	properties := cachedSnapshotProperties{
+		Creation:         s.GetCreationDate(),
+		Referenced:       s.GetReferenced(),
+		CompressionRatio: s.GetRatio(),
+	}
+	// without libzfs, only some of the properties are available, which must not be cached
+	if cache != nil && guid != "" && s.rawGolibzfsData != nil {
+		cache.putProperties(guid, properties)
+	}
+	return properties
+}
@@ -0,0 +1,125 @@
+package zfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot_StatFile_UsesMetadataCache(t *testing.T) {
+	defer ConfigureMetadataCache("")
+	cacheDir := t.TempDir()
+	ConfigureMetadataCache(cacheDir)
+
+	root := t.TempDir()
+	snapshotPath := filepath.Join(root, ".zfs", "snapshot", "daily")
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshotPath, "dir"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(snapshotPath, "dir", "file"), []byte("hello"), 0644))
+	dataset := &Dataset{Path: root, HiddenZfsPath: filepath.Join(root, ".zfs")}
+	snapshot := &Snapshot{
+		Name:          "daily",
+		Path:          snapshotPath,
+		ParentDataset: dataset,
+		Properties:    SnapshotProperties{Guid: "1234"},
+	}
+
+	meta, err := snapshot.StatFile(filepath.Join(root, "dir", "file"))
+	assert.NoError(t, err)
+	assert.True(t, meta.Exists)
+	assert.Equal(t, int64(5), meta.Size)
+
+	missing, err := snapshot.StatFile(filepath.Join(root, "dir", "missing"))
+	assert.NoError(t, err)
+	assert.False(t, missing.Exists)
+	assert.NoError(t, FlushMetadataCache())
+
+	// a fresh cache reads the entries written by the previous run, without touching the snapshot
+	assert.NoError(t, os.RemoveAll(snapshotPath))
+	ConfigureMetadataCache(cacheDir)
+	cached, err := snapshot.StatFile(filepath.Join(root, "dir", "file"))
+	assert.NoError(t, err)
+	assert.True(t, cached.Exists)
+	assert.Equal(t, meta.Size, cached.Size)
+	assert.True(t, meta.ModTime.Equal(cached.ModTime))
+	cachedMissing, err := snapshot.StatFile(filepath.Join(root, "dir", "missing"))
+	assert.NoError(t, err)
+	assert.False(t, cachedMissing.Exists)
+}
+
+func TestSnapshot_StatFile_WithoutGuidIsNotCached(t *testing.T) {
+	defer ConfigureMetadataCache("")
+	cacheDir := t.TempDir()
+	ConfigureMetadataCache(cacheDir)
+
+	root := t.TempDir()
+	snapshotPath := filepath.Join(root, ".zfs", "snapshot", "daily")
+	assert.NoError(t, os.MkdirAll(snapshotPath, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(snapshotPath, "file"), []byte("hello"), 0644))
+	snapshot := &Snapshot{
+		Name:          "daily",
+		Path:          snapshotPath,
+		ParentDataset: &Dataset{Path: root, HiddenZfsPath: filepath.Join(root, ".zfs")},
+	}
+
+	meta, err := snapshot.StatFile(filepath.Join(root, "file"))
+	assert.NoError(t, err)
+	assert.True(t, meta.Exists)
+	assert.NoError(t, FlushMetadataCache())
+
+	entries, err := os.ReadDir(cacheDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMetadataCache_Properties(t *testing.T) {
+	cacheDir := t.TempDir()
+	cache := newMetadataCache(cacheDir)
+	_, ok := cache.getProperties("1234")
+	assert.False(t, ok)
+
+	cache.putProperties("1234", cachedSnapshotProperties{Referenced: 42, CompressionRatio: 1.5})
+	assert.NoError(t, cache.flush())
+
+	properties, ok := newMetadataCache(cacheDir).getProperties("1234")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(42), properties.Referenced)
+	assert.Equal(t, 1.5, properties.CompressionRatio)
+}
+
+func TestMetadataCache_Prune(t *testing.T) {
+	cacheDir := t.TempDir()
+	cache := newMetadataCache(cacheDir)
+	cache.putProperties("1", cachedSnapshotProperties{Referenced: 1})
+	cache.putProperties("2", cachedSnapshotProperties{Referenced: 2})
+	cache.putProperties("3", cachedSnapshotProperties{Referenced: 3})
+	cache.putFileMeta("1", "/file", FileMeta{Exists: true})
+	cache.prune("pool/data", []string{"1", "2", "3"})
+	// "2" has been sent to the replica as well
+	cache.prune("backup/data", []string{"2"})
+	assert.NoError(t, cache.flush())
+	assert.FileExists(t, cache.statsFile("1"))
+
+	cache.prune("pool/data", []string{"3"})
+	assert.NoError(t, cache.flush())
+
+	reloaded := newMetadataCache(cacheDir)
+	_, ok := reloaded.getProperties("1")
+	assert.False(t, ok)
+	_, ok = reloaded.getProperties("2")
+	assert.True(t, ok)
+	_, ok = reloaded.getProperties("3")
+	assert.True(t, ok)
+	assert.NoFileExists(t, cache.statsFile("1"))
+}
+
+func TestMetadataCache_IsFlushDue(t *testing.T) {
+	cache := newMetadataCache(t.TempDir())
+	assert.True(t, cache.isFlushDue(time.Now()))
+
+	assert.NoError(t, cache.flush())
+	assert.False(t, cache.isFlushDue(time.Now()))
+	assert.True(t, cache.isFlushDue(time.Now().Add(metadataCacheFlushInterval)))
+}
@@ -0,0 +1,183 @@
+package zfs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	gopath "path"
+	"strconv"
+	"strings"
+)
+
+const mountInfoPath = "/proc/self/mountinfo"
+
+var errNotOnDataset = errors.New("path is not located on a zfs dataset")
+
+// mountInfo is a single entry of /proc/self/mountinfo, see proc(5)
+type mountInfo struct {
+	MountId  int
+	ParentId int
+	// Root is the path within the filesystem which is mounted at MountPoint ("/" unless bind mounted)
+	Root       string
+	MountPoint string
+	FsType     string
+	// Source is the dataset name for zfs mounts
+	Source string
+}
+
+// datasetMount describes how a dataset is reachable from the current mount namespace
+type datasetMount struct {
+	DatasetName string
+	// MountPoint is the path the dataset (or a subdirectory of it) is mounted at
+	MountPoint string
+	// Root is the path within the dataset which is mounted at MountPoint ("/" unless bind mounted)
+	Root string
+	// HiddenZfsPath is the path of the ".zfs" directory of the dataset
+	HiddenZfsPath string
+}
+
+func readMountInfo() ([]*mountInfo, error) {
+	file, err := os.Open(mountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseMountInfo(file)
+}
+
+// parseMountInfo parses the format of /proc/self/mountinfo:
+// "36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue"
+func parseMountInfo(reader io.Reader) ([]*mountInfo, error) {
+	var result []*mountInfo
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		separator := -1
+		for i, field := range fields {
+			if field == "-" && i >= 6 {
+				separator = i
+				break
+			}
+		}
+		if separator < 0 || len(fields) < separator+3 {
+			return nil, errors.New("unexpected mountinfo line: " + line)
+		}
+
+		mountId, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected mountinfo line: %s: %w", line, err)
+		}
+		parentId, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected mountinfo line: %s: %w", line, err)
+		}
+
+		result = append(result, &mountInfo{
+			MountId:    mountId,
+			ParentId:   parentId,
+			Root:       unescapeMountInfoField(fields[3]),
+			MountPoint: unescapeMountInfoField(fields[4]),
+			FsType:     fields[separator+1],
+			Source:     unescapeMountInfoField(fields[separator+2]),
+		})
+	}
+	return result, scanner.Err()
+}
+
+// unescapeMountInfoField decodes the octal escapes (e.g. "\040" for a space) used by the kernel
+func unescapeMountInfoField(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+3 < len(value) {
+			if code, err := strconv.ParseUint(value[i+1:i+4], 8, 8); err == nil {
+				builder.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(value[i])
+	}
+	return builder.String()
+}
+
+// findMountForPath returns the mount the given path resides on.
+// If multiple mounts share the same mountpoint, the topmost one (which shadows the others) wins.
+func findMountForPath(mounts []*mountInfo, path string) *mountInfo {
+	path = gopath.Clean(path)
+	var candidates []*mountInfo
+	for _, mount := range mounts {
+		if !isPathWithin(path, mount.MountPoint) {
+			continue
+		}
+		if len(candidates) > 0 && len(mount.MountPoint) < len(candidates[0].MountPoint) {
+			continue
+		}
+		if len(candidates) > 0 && len(mount.MountPoint) > len(candidates[0].MountPoint) {
+			candidates = nil
+		}
+		candidates = append(candidates, mount)
+	}
+	return findTopmostMount(candidates)
+}
+
+// findTopmostMount returns the mount which is not covered by any of the other given mounts of the same mountpoint.
+// A mount covering another one has it as its parent, regardless of the order of /proc/self/mountinfo.
+// If the parent chain doesn't tell (e.g. all are mounted on the same parent), the last one wins.
+func findTopmostMount(mounts []*mountInfo) *mountInfo {
+	covered := map[int]bool{}
+	for _, mount := range mounts {
+		covered[mount.ParentId] = true
+	}
+	for i := len(mounts) - 1; i >= 0; i-- {
+		if !covered[mounts[i].MountId] {
+			return mounts[i]
+		}
+	}
+	if len(mounts) == 0 {
+		return nil
+	}
+	return mounts[len(mounts)-1]
+}
+
+// isPathWithin reports whether path is equal to or located below parent
+func isPathWithin(path string, parent string) bool {
+	return parent == "/" || path == parent || strings.HasPrefix(path, parent+"/")
+}
+
+// resolveDatasetMount determines the dataset containing the given path and where its snapshots are accessible.
+// Bind mounts of dataset subdirectories are mapped back to a mount of the dataset root.
+func resolveDatasetMount(mounts []*mountInfo, path string) (*datasetMount, error) {
+	mount := findMountForPath(mounts, path)
+	if mount == nil || mount.FsType != "zfs" {
+		return nil, fmt.Errorf("%w: %s", errNotOnDataset, path)
+	}
+
+	result := &datasetMount{
+		DatasetName: mount.Source,
+		MountPoint:  mount.MountPoint,
+		Root:        mount.Root,
+	}
+
+	if mount.Root == "/" {
+		result.HiddenZfsPath = gopath.Join(mount.MountPoint, ".zfs")
+		return result, nil
+	}
+
+	// the ".zfs" directory only exists at the root of the dataset, so look for a mount of it
+	for _, other := range mounts {
+		if other.FsType == "zfs" && other.Source == mount.Source && other.Root == "/" {
+			result.HiddenZfsPath = gopath.Join(other.MountPoint, ".zfs")
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("snapshots of dataset %s are not accessible, only its subdirectory %s is mounted at %s", mount.Source, mount.Root, mount.MountPoint)
+}
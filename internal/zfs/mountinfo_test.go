@@ -0,0 +1,187 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMountInfo = `22 1 0:21 / / rw,relatime shared:1 - zfs rpool/ROOT/default rw,xattr,noacl
+45 22 0:40 / /home rw,relatime shared:20 - zfs rpool/home rw,xattr,noacl
+46 22 0:41 / /srv/data rw,relatime shared:21 - zfs tank/data rw,xattr,noacl
+47 22 0:41 /projects/web /var/www rw,relatime shared:21 - zfs tank/data rw,xattr,noacl
+48 22 0:42 /media /var/lib/media rw,relatime shared:22 - zfs tank/media rw,xattr,noacl
+49 22 0:43 / /mnt/legacy\040disk rw,relatime shared:23 - zfs tank/legacy rw,xattr,noacl
+50 22 0:44 / /tmp rw,nosuid,nodev shared:24 - tmpfs tmpfs rw
+`
+
+func TestParseMountInfo(t *testing.T) {
+	// WHEN
+	mounts, err := parseMountInfo(strings.NewReader(testMountInfo))
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Len(t, mounts, 7)
+	assert.Equal(t, &mountInfo{
+		MountId:    47,
+		ParentId:   22,
+		Root:       "/projects/web",
+		MountPoint: "/var/www",
+		FsType:     "zfs",
+		Source:     "tank/data",
+	}, mounts[3])
+	assert.Equal(t, "/mnt/legacy disk", mounts[5].MountPoint)
+	assert.Equal(t, "tmpfs", mounts[6].FsType)
+}
+
+func TestParseMountInfoWithoutOptionalFields(t *testing.T) {
+	// WHEN
+	mounts, err := parseMountInfo(strings.NewReader("36 35 98:0 / /mnt rw,noatime - zfs tank/mnt rw\n"))
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Len(t, mounts, 1)
+	assert.Equal(t, "zfs", mounts[0].FsType)
+	assert.Equal(t, "tank/mnt", mounts[0].Source)
+}
+
+func TestParseMountInfoInvalid(t *testing.T) {
+	// WHEN
+	_, err := parseMountInfo(strings.NewReader("36 35 98:0 / /mnt rw\n"))
+
+	// THEN
+	assert.Error(t, err)
+}
+
+func TestUnescapeMountInfoField(t *testing.T) {
+	assert.Equal(t, "/plain", unescapeMountInfoField("/plain"))
+	assert.Equal(t, "/with space", unescapeMountInfoField("/with\\040space"))
+	assert.Equal(t, "/tab\there", unescapeMountInfoField("/tab\\011here"))
+	assert.Equal(t, "/back\\slash", unescapeMountInfoField("/back\\134slash"))
+	assert.Equal(t, "/trailing\\04", unescapeMountInfoField("/trailing\\04"))
+}
+
+func TestFindMountForPath(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(testMountInfo))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 22, findMountForPath(mounts, "/etc/hosts").MountId)
+	assert.Equal(t, 45, findMountForPath(mounts, "/home").MountId)
+	assert.Equal(t, 45, findMountForPath(mounts, "/home/user/file.txt").MountId)
+	assert.Equal(t, 22, findMountForPath(mounts, "/homeless").MountId)
+	assert.Equal(t, 47, findMountForPath(mounts, "/var/www/index.html").MountId)
+}
+
+func TestFindMountForPathShadowed(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(`22 1 0:21 / / rw - zfs rpool/ROOT rw
+30 22 0:30 / /mnt rw - zfs tank/first rw
+31 22 0:31 / /mnt rw - zfs tank/second rw
+`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "tank/second", findMountForPath(mounts, "/mnt/file").Source)
+}
+
+func TestFindMountForPathOverMounted(t *testing.T) {
+	// the mount covering /mnt is listed before the one it covers, e.g. after the latter has been remounted
+	mounts, err := parseMountInfo(strings.NewReader(`22 1 0:21 / / rw - zfs rpool/ROOT rw
+31 30 0:31 / /mnt rw - zfs tank/second rw
+30 22 0:30 / /mnt rw - zfs tank/first rw
+40 31 0:40 / /mnt/nested rw - zfs tank/nested rw
+`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "tank/second", findMountForPath(mounts, "/mnt/file").Source)
+	assert.Equal(t, "tank/second", findMountForPath(mounts, "/mnt").Source)
+	assert.Equal(t, "tank/nested", findMountForPath(mounts, "/mnt/nested/file").Source)
+	assert.Nil(t, findMountForPath(nil, "/mnt"))
+}
+
+func TestResolveDatasetMount(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(testMountInfo))
+	assert.NoError(t, err)
+
+	// WHEN
+	result, err := resolveDatasetMount(mounts, "/srv/data/projects")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, &datasetMount{
+		DatasetName:   "tank/data",
+		MountPoint:    "/srv/data",
+		Root:          "/",
+		HiddenZfsPath: "/srv/data/.zfs",
+	}, result)
+}
+
+func TestResolveDatasetMountBindMount(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(testMountInfo))
+	assert.NoError(t, err)
+
+	// WHEN
+	result, err := resolveDatasetMount(mounts, "/var/www/assets")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, &datasetMount{
+		DatasetName:   "tank/data",
+		MountPoint:    "/var/www",
+		Root:          "/projects/web",
+		HiddenZfsPath: "/srv/data/.zfs",
+	}, result)
+}
+
+func TestResolveDatasetMountLegacyMountpoint(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(testMountInfo))
+	assert.NoError(t, err)
+
+	// WHEN
+	result, err := resolveDatasetMount(mounts, "/mnt/legacy disk/file")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "tank/legacy", result.DatasetName)
+	assert.Equal(t, "/mnt/legacy disk/.zfs", result.HiddenZfsPath)
+}
+
+func TestResolveDatasetMountSnapshotsNotAccessible(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(testMountInfo))
+	assert.NoError(t, err)
+
+	// WHEN
+	result, err := resolveDatasetMount(mounts, "/var/lib/media/movie.mkv")
+
+	// THEN
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "tank/media")
+	assert.NotErrorIs(t, err, errNotOnDataset)
+}
+
+func TestResolveDatasetMountNotOnDataset(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(testMountInfo))
+	assert.NoError(t, err)
+
+	// WHEN
+	_, err = resolveDatasetMount(mounts, "/tmp/file")
+
+	// THEN
+	assert.ErrorIs(t, err, errNotOnDataset)
+}
+
+func TestSnapshotPathMappingOfBindMount(t *testing.T) {
+	snapshot := &Snapshot{
+		Name: "snap1",
+		Path: "/srv/data/.zfs/snapshot/snap1",
+		ParentDataset: &Dataset{
+			Path:          "/var/www",
+			HiddenZfsPath: "/srv/data/.zfs",
+			BindRoot:      "/projects/web",
+		},
+	}
+
+	assert.Equal(t, "/srv/data/.zfs/snapshot/snap1/projects/web/assets/logo.png", snapshot.GetSnapshotPath("/var/www/assets/logo.png"))
+	assert.Equal(t, "/srv/data/.zfs/snapshot/snap1/projects/web", snapshot.GetSnapshotPath("/var/www"))
+	assert.Equal(t, "/var/www/assets/logo.png", snapshot.GetRealPath("/srv/data/.zfs/snapshot/snap1/projects/web/assets/logo.png"))
+	assert.Equal(t, "/var/www", snapshot.GetRealPath("/srv/data/.zfs/snapshot/snap1/projects/web"))
+}
@@ -0,0 +1,178 @@
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+	"zfs-file-history/internal/autosnap"
+)
+
+// CreationDateSource describes where the creation date of a snapshot was taken from
+type CreationDateSource int
+
+const (
+	CreationDateSourceUnknown CreationDateSource = iota
+	// CreationDateSourceProperty is the "creation" property of the snapshot, or the metadata of other providers
+	CreationDateSourceProperty
+	// CreationDateSourceName is a timestamp within the name of the snapshot
+	CreationDateSourceName
+	// CreationDateSourceModTime is the modification time of the snapshot directory, for providers without metadata
+	CreationDateSourceModTime
+)
+
+func (source CreationDateSource) String() string {
+	switch source {
+	case CreationDateSourceProperty:
+		return "property"
+	case CreationDateSourceName:
+		return "name"
+	case CreationDateSourceModTime:
+		return "mtime"
+	default:
+		return "unknown"
+	}
+}
+
+// NameTimestampParser extracts the creation date of a snapshot from its name
+type NameTimestampParser struct {
+	Name       string
+	expression *regexp.Regexp
+	layout     string
+	location   *time.Location
+}
+
+// NewNameTimestampParser creates a parser which matches snapshot names against the given regular expression.
+// The text of the capture group named "time", or of the whole match if there is no such group,
+// is parsed using the given Go time layout.
+func NewNameTimestampParser(name string, pattern string, layout string, utc bool) (*NameTimestampParser, error) {
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern of timestamp parser '%s': %w", name, err)
+	}
+	if layout == "" {
+		return nil, fmt.Errorf("timestamp parser '%s' has no layout", name)
+	}
+	location := time.Local
+	if utc {
+		location = time.UTC
+	}
+	return &NameTimestampParser{
+		Name:       name,
+		expression: expression,
+		layout:     layout,
+		location:   location,
+	}, nil
+}
+
+// Parse returns the timestamp contained in the given snapshot name
+func (parser *NameTimestampParser) Parse(snapshotName string) (time.Time, bool) {
+	match := parser.expression.FindStringSubmatch(snapshotName)
+	if match == nil {
+		return time.Time{}, false
+	}
+	text := match[0]
+	if index := parser.expression.SubexpIndex("time"); index >= 0 {
+		text = match[index]
+	}
+	timestamp, err := time.ParseInLocation(parser.layout, text, parser.location)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timestamp, true
+}
+
+func mustNameTimestampParser(name string, pattern string, layout string, utc bool) *NameTimestampParser {
+	parser, err := NewNameTimestampParser(name, pattern, layout, utc)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}
+
+// BuiltinNameTimestampParsers cover the naming schemes of common snapshot tools
+var BuiltinNameTimestampParsers = []*NameTimestampParser{
+	mustNameTimestampParser(autosnap.ToolSanoid, autosnap.SanoidPattern, autosnap.SanoidTimeLayout, false),
+	mustNameTimestampParser(autosnap.ToolZfsAutoSnapshot, autosnap.ZfsAutoSnapshotPattern, autosnap.ZfsAutoSnapshotTimeLayout, true),
+	// e.g. "zrepl_20261001_001700_000"
+	mustNameTimestampParser("zrepl", `(?P<time>\d{8}_\d{6})_\d{3}$`, "20060102_150405", true),
+	// names rendered with the {date} placeholder, e.g. "zfh-2026-10-01-001700"
+	mustNameTimestampParser("zfs-file-history", `(?P<time>\d{4}-\d{2}-\d{2}-\d{6})`, SnapshotTimeFormat, false),
+}
+
+var (
+	nameTimestampParsers = BuiltinNameTimestampParsers
+	preferNameTimestamps = false
+	nameTimestampMtx     sync.RWMutex
+)
+
+// ConfigureNameTimestamps sets which parsers are used to determine the creation date of snapshots from their name.
+// builtins - names of the BuiltinNameTimestampParsers to use
+// custom - additional parsers, which are tried before the builtin ones
+// preferName - whether a timestamp in the name takes precedence over the "creation" property
+func ConfigureNameTimestamps(builtins []string, custom []*NameTimestampParser, preferName bool) error {
+	parsers := append([]*NameTimestampParser{}, custom...)
+	for _, name := range builtins {
+		found := false
+		for _, parser := range BuiltinNameTimestampParsers {
+			if parser.Name == name {
+				parsers = append(parsers, parser)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown timestamp parser: %s", name)
+		}
+	}
+
+	nameTimestampMtx.Lock()
+	defer nameTimestampMtx.Unlock()
+	nameTimestampParsers = parsers
+	preferNameTimestamps = preferName
+	return nil
+}
+
+// ParseNameTimestamp returns the timestamp contained in the given snapshot name,
+// together with the name of the parser which recognized it
+func ParseNameTimestamp(snapshotName string) (time.Time, string, bool) {
+	nameTimestampMtx.RLock()
+	parsers := nameTimestampParsers
+	nameTimestampMtx.RUnlock()
+
+	for _, parser := range parsers {
+		if timestamp, ok := parser.Parse(snapshotName); ok {
+			return timestamp, parser.Name, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// resolveCreationDate determines the creation date of the snapshot, either from its "creation" property
+// or from its name, depending on which one is available and preferred
+func (s *Snapshot) resolveCreationDate() (time.Time, CreationDateSource, string) {
+	return resolveCreationDateOf(s.Name, s.GetCreationDate())
+}
+
+// resolveCreationDateOf chooses between the creation date recorded for a snapshot (zero if unknown)
+// and the timestamp within its name
+func resolveCreationDateOf(snapshotName string, recorded time.Time) (time.Time, CreationDateSource, string) {
+	nameTimestampMtx.RLock()
+	preferName := preferNameTimestamps
+	nameTimestampMtx.RUnlock()
+
+	if preferName {
+		if timestamp, parser, ok := ParseNameTimestamp(snapshotName); ok {
+			return timestamp, CreationDateSourceName, parser
+		}
+	}
+	if !recorded.IsZero() {
+		return recorded, CreationDateSourceProperty, ""
+	}
+	if !preferName {
+		if timestamp, parser, ok := ParseNameTimestamp(snapshotName); ok {
+			return timestamp, CreationDateSourceName, parser
+		}
+	}
+	return time.Time{}, CreationDateSourceUnknown, ""
+}
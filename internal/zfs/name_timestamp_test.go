@@ -0,0 +1,100 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNameTimestamp_Builtin(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   string
+		expected time.Time
+	}{
+		{"autosnap_2026-10-01_12:30:45_daily", "sanoid", time.Date(2026, 10, 1, 12, 30, 45, 0, time.Local)},
+		{"zfs-auto-snap_hourly-2026-10-01-0017", "zfs-auto-snapshot", time.Date(2026, 10, 1, 0, 17, 0, 0, time.UTC)},
+		{"zrepl_20261001_001700_000", "zrepl", time.Date(2026, 10, 1, 0, 17, 0, 0, time.UTC)},
+		{"zfh-2026-10-01-001700", "zfs-file-history", time.Date(2026, 10, 1, 0, 17, 0, 0, time.Local)},
+	}
+	for _, test := range tests {
+		timestamp, parser, ok := ParseNameTimestamp(test.name)
+		assert.True(t, ok, test.name)
+		assert.Equal(t, test.parser, parser, test.name)
+		assert.True(t, test.expected.Equal(timestamp), test.name)
+	}
+
+	_, _, ok := ParseNameTimestamp("before-upgrade")
+	assert.False(t, ok)
+	_, _, ok = ParseNameTimestamp("zfh-2026-13-01-001700")
+	assert.False(t, ok)
+}
+
+func TestNewNameTimestampParser(t *testing.T) {
+	parser, err := NewNameTimestampParser("backup", `^backup-(?P<time>\d{8})`, "20060102", true)
+	assert.NoError(t, err)
+	timestamp, ok := parser.Parse("backup-20261001-nightly")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), timestamp)
+
+	// without a "time" group, the whole match is parsed
+	parser, err = NewNameTimestampParser("date", `\d{4}\.\d{2}\.\d{2}`, "2006.01.02", true)
+	assert.NoError(t, err)
+	timestamp, ok = parser.Parse("weekly-2026.10.01")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), timestamp)
+
+	_, err = NewNameTimestampParser("invalid", `(`, "2006", false)
+	assert.Error(t, err)
+	_, err = NewNameTimestampParser("invalid", `\d+`, "", false)
+	assert.Error(t, err)
+}
+
+func TestConfigureNameTimestamps(t *testing.T) {
+	defer func() {
+		assert.NoError(t, ConfigureNameTimestamps([]string{"sanoid", "zfs-auto-snapshot", "zrepl", "zfs-file-history"}, nil, false))
+	}()
+
+	custom, err := NewNameTimestampParser("custom", `(?P<time>\d{4}-\d{2}-\d{2})`, "2006-01-02", true)
+	assert.NoError(t, err)
+	assert.NoError(t, ConfigureNameTimestamps([]string{"sanoid"}, []*NameTimestampParser{custom}, false))
+
+	// custom parsers take precedence over builtin ones
+	_, parser, ok := ParseNameTimestamp("zfh-2026-10-01-001700")
+	assert.True(t, ok)
+	assert.Equal(t, "custom", parser)
+
+	_, _, ok = ParseNameTimestamp("zrepl_20261001_001700_000")
+	assert.False(t, ok)
+
+	assert.Error(t, ConfigureNameTimestamps([]string{"zfsnap"}, nil, false))
+}
+
+func TestResolveCreationDate(t *testing.T) {
+	defer func() {
+		assert.NoError(t, ConfigureNameTimestamps([]string{"sanoid", "zfs-auto-snapshot", "zrepl", "zfs-file-history"}, nil, false))
+	}()
+
+	// no raw handles are available, so the name is the only source
+	s := &Snapshot{Name: "zrepl_20261001_001700_000"}
+	timestamp, source, parser := s.resolveCreationDate()
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 17, 0, 0, time.UTC), timestamp)
+	assert.Equal(t, CreationDateSourceName, source)
+	assert.Equal(t, "zrepl", parser)
+
+	timestamp, source, parser = (&Snapshot{Name: "manual"}).resolveCreationDate()
+	assert.True(t, timestamp.IsZero())
+	assert.Equal(t, CreationDateSourceUnknown, source)
+	assert.Equal(t, "", parser)
+
+	assert.NoError(t, ConfigureNameTimestamps([]string{"zrepl"}, nil, true))
+	_, source, _ = s.resolveCreationDate()
+	assert.Equal(t, CreationDateSourceName, source)
+}
+
+func TestCreationDateSource_String(t *testing.T) {
+	assert.Equal(t, "property", CreationDateSourceProperty.String())
+	assert.Equal(t, "name", CreationDateSourceName.String())
+	assert.Equal(t, "unknown", CreationDateSourceUnknown.String())
+}
@@ -0,0 +1,126 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"zfs-file-history/internal/util"
+)
+
+// maxDatasetNameLength mirrors ZFS_MAX_DATASET_NAME_LEN (including the terminating NUL byte)
+const maxDatasetNameLength = 256 - 1
+
+// isValidNameChar reports whether r is allowed within a single dataset name component
+func isValidNameChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') ||
+		(r >= 'A' && r <= 'Z') ||
+		(r >= '0' && r <= '9') ||
+		r == '_' || r == '-' || r == '.' || r == ':' || r == ' '
+}
+
+// ValidateDatasetName checks the given name against the naming rules of ZFS filesystems
+func ValidateDatasetName(name string) error {
+	if name == "" {
+		return errors.New("dataset name must not be empty")
+	}
+	if len(name) > maxDatasetNameLength {
+		return fmt.Errorf("dataset name must not be longer than %d characters", maxDatasetNameLength)
+	}
+	if strings.ContainsAny(name, "@#%") {
+		return errors.New("dataset name must not contain '@', '#' or '%'")
+	}
+
+	components := strings.Split(name, "/")
+	for _, component := range components {
+		if component == "" {
+			return errors.New("dataset name must not contain empty components")
+		}
+		if component == "." || component == ".." {
+			return fmt.Errorf("dataset name must not contain '%s' components", component)
+		}
+		for _, r := range component {
+			if !isValidNameChar(r) {
+				return fmt.Errorf("dataset name contains invalid character '%c'", r)
+			}
+		}
+	}
+
+	pool := components[0]
+	first := pool[0]
+	if !(first >= 'a' && first <= 'z') && !(first >= 'A' && first <= 'Z') {
+		return errors.New("pool name must begin with a letter")
+	}
+
+	return nil
+}
+
+// ValidateSnapshotName checks the given name (the part after '@') against the naming rules of ZFS snapshots
+func ValidateSnapshotName(name string) error {
+	return validateNameComponent("snapshot", name)
+}
+
+// ValidateBookmarkName checks the name of a bookmark (the part after the '#'), which follows the same rules as snapshot names
+func ValidateBookmarkName(name string) error {
+	return validateNameComponent("bookmark", name)
+}
+
+func validateNameComponent(kind string, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s name must not be empty", kind)
+	}
+	if len(name) > maxDatasetNameLength {
+		return fmt.Errorf("%s name must not be longer than %d characters", kind, maxDatasetNameLength)
+	}
+	for _, r := range name {
+		if !isValidNameChar(r) {
+			return fmt.Errorf("%s name contains invalid character '%c'", kind, r)
+		}
+	}
+	return nil
+}
+
+const maxUserPropertyValueLength = 8192
+
+// ValidateUserPropertyValue checks the length limit of ZFS user property values
+func ValidateUserPropertyValue(key string, value string) error {
+	if len(value) > maxUserPropertyValueLength {
+		return fmt.Errorf("value of user property '%s' must not be longer than %d characters", key, maxUserPropertyValueLength)
+	}
+	return nil
+}
+
+// ParseUserProperty parses a "key=value" assignment of a user property
+func ParseUserProperty(assignment string) (key string, value string, err error) {
+	key, value, found := strings.Cut(assignment, "=")
+	key = strings.TrimSpace(key)
+	if !found {
+		return "", "", fmt.Errorf("user property '%s' must be of the form key=value", assignment)
+	}
+	if err := util.ValidateUserPropertyName(key); err != nil {
+		return "", "", err
+	}
+	if err := ValidateUserPropertyValue(key, value); err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+// getPoolName returns the name of the pool a dataset or snapshot name belongs to
+func getPoolName(name string) string {
+	name, _, _ = strings.Cut(name, "@")
+	pool, _, _ := strings.Cut(name, "/")
+	return pool
+}
+
+// suggestCloneName returns a sibling dataset name for a clone of the given snapshot
+func suggestCloneName(datasetName string, snapshotName string) string {
+	if !strings.Contains(datasetName, "/") {
+		return fmt.Sprintf("%s/%s-clone", datasetName, snapshotName)
+	}
+	return fmt.Sprintf("%s-%s", datasetName, snapshotName)
+}
+
+// RenderSnapshotName expands the given name template for a new snapshot
+func RenderSnapshotName(template string, prompt string) (string, error) {
+	return util.ExpandNameTemplate(template, util.CurrentNameTemplateValues(SnapshotTimeFormat, prompt))
+}
@@ -0,0 +1,105 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDatasetName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "Pool", input: "tank", wantErr: false},
+		{name: "Nested", input: "tank/data/app-1.0_old:v2", wantErr: false},
+		{name: "Space", input: "tank/my data", wantErr: false},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "Snapshot", input: "tank/data@snap", wantErr: true},
+		{name: "Bookmark", input: "tank/data#mark", wantErr: true},
+		{name: "Trailing Slash", input: "tank/data/", wantErr: true},
+		{name: "Double Slash", input: "tank//data", wantErr: true},
+		{name: "Dot Component", input: "tank/./data", wantErr: true},
+		{name: "Invalid Character", input: "tank/dätä", wantErr: true},
+		{name: "Pool Starts With Digit", input: "1tank/data", wantErr: true},
+		{name: "Too Long", input: "tank/" + strings.Repeat("a", maxDatasetNameLength), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDatasetName(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateSnapshotName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "Timestamp", input: "zfh-2024-01-02-150405", wantErr: false},
+		{name: "Descriptive", input: "before-upgrade-pg16", wantErr: false},
+		{name: "Auto Snapshot", input: "zfs-auto-snap_daily-2024-01-02-0000", wantErr: false},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "Full Name", input: "pool/ds@snap", wantErr: true},
+		{name: "Slash", input: "a/b", wantErr: true},
+		{name: "Bookmark", input: "a#b", wantErr: true},
+		{name: "Too Long", input: strings.Repeat("a", maxDatasetNameLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSnapshotName(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseUserProperty(t *testing.T) {
+	key, value, err := ParseUserProperty("zfh:note=pre-deploy")
+	assert.NoError(t, err)
+	assert.Equal(t, "zfh:note", key)
+	assert.Equal(t, "pre-deploy", value)
+
+	key, value, err = ParseUserProperty(" com.sun:auto-snapshot=false")
+	assert.NoError(t, err)
+	assert.Equal(t, "com.sun:auto-snapshot", key)
+	assert.Equal(t, "false", value)
+
+	key, value, err = ParseUserProperty("zfh:note=a=b c")
+	assert.NoError(t, err)
+	assert.Equal(t, "zfh:note", key)
+	assert.Equal(t, "a=b c", value)
+
+	_, _, err = ParseUserProperty("zfh:note")
+	assert.Error(t, err)
+	_, _, err = ParseUserProperty("compression=lz4")
+	assert.Error(t, err)
+	_, _, err = ParseUserProperty("ZFH:Note=x")
+	assert.Error(t, err)
+	_, _, err = ParseUserProperty("zfh:note=" + strings.Repeat("x", maxUserPropertyValueLength+1))
+	assert.Error(t, err)
+}
+
+func TestGetPoolName(t *testing.T) {
+	assert.Equal(t, "tank", getPoolName("tank"))
+	assert.Equal(t, "tank", getPoolName("tank/data/app"))
+	assert.Equal(t, "tank", getPoolName("tank/data@snap/with/slash"))
+}
+
+func TestSuggestCloneName(t *testing.T) {
+	assert.Equal(t, "tank/data-snap1", suggestCloneName("tank/data", "snap1"))
+	assert.Equal(t, "tank/snap1-clone", suggestCloneName("tank", "snap1"))
+}
@@ -0,0 +1,337 @@
+package zfs
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	gopath "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"zfs-file-history/internal/util"
+)
+
+const (
+	ProviderZfs       = "zfs"
+	ProviderSnapper   = "snapper"
+	ProviderNetApp    = "netapp"
+	ProviderDirectory = "directory"
+)
+
+const (
+	snapperSnapshotsDirName = ".snapshots"
+	snapperDateLayout       = "2006-01-02 15:04:05"
+	netAppSnapshotsDirName  = ".snapshot"
+	// btrfsSubvolumeRootInode is the inode number of the root directory of every btrfs subvolume
+	btrfsSubvolumeRootInode = 256
+)
+
+var errNoDataset = errors.New("could not find dataset")
+
+// SnapshotProvider discovers datasets and their snapshots.
+// Besides ZFS, snapshots are provided by filesystems and backup tools which keep read-only copies of a directory tree.
+// Such snapshots can be browsed and restored from like ZFS snapshots, but do not support any ZFS specific action.
+type SnapshotProvider interface {
+	// Name identifies the kind of provider, e.g. ProviderSnapper
+	Name() string
+	// FindDataset returns the dataset of this provider containing the given path.
+	// Returns an error wrapping errNoDataset if the path is not covered by this provider.
+	FindDataset(path string) (*Dataset, error)
+	// ListSnapshots returns all snapshots of a dataset found by this provider
+	ListSnapshots(dataset *Dataset) ([]*Snapshot, error)
+}
+
+var (
+	snapshotProviders   = []SnapshotProvider{zfsProvider{}}
+	snapshotProviderMtx sync.RWMutex
+)
+
+// ConfigureSnapshotProviders sets the providers which are asked for datasets in addition to ZFS
+func ConfigureSnapshotProviders(providers ...SnapshotProvider) {
+	snapshotProviderMtx.Lock()
+	defer snapshotProviderMtx.Unlock()
+	snapshotProviders = append([]SnapshotProvider{zfsProvider{}}, providers...)
+}
+
+func getSnapshotProviders() []SnapshotProvider {
+	snapshotProviderMtx.RLock()
+	defer snapshotProviderMtx.RUnlock()
+	return snapshotProviders
+}
+
+// GetProvider returns the provider which found this dataset
+func (dataset *Dataset) GetProvider() SnapshotProvider {
+	if dataset.provider == nil {
+		return zfsProvider{}
+	}
+	return dataset.provider
+}
+
+// IsZfs reports whether this is a ZFS dataset.
+// Datasets of other providers only support browsing and restoring from their snapshots.
+func (dataset *Dataset) IsZfs() bool {
+	return dataset.provider == nil
+}
+
+// newProviderDataset creates a dataset which is not managed by ZFS
+func newProviderDataset(provider SnapshotProvider, path string, snapshotsDir string) *Dataset {
+	return &Dataset{
+		Path:         path,
+		name:         path,
+		provider:     provider,
+		snapshotsDir: snapshotsDir,
+	}
+}
+
+// newProviderSnapshot creates a snapshot of a dataset which is not managed by ZFS.
+// If the provider does not know the creation date, it is taken from the name of the snapshot
+// or the modification time of its directory.
+func newProviderSnapshot(dataset *Dataset, name string, path string, created time.Time) *Snapshot {
+	creationDate, source, parser := resolveCreationDateOf(name, created)
+	if source == CreationDateSourceUnknown {
+		if stat, err := os.Stat(path); err == nil {
+			creationDate, source = stat.ModTime(), CreationDateSourceModTime
+		}
+	}
+	return &Snapshot{
+		Name:          name,
+		FullName:      fmt.Sprintf("%s@%s", dataset.GetName(), name),
+		Path:          path,
+		ParentDataset: dataset,
+		Properties: SnapshotProperties{
+			CreationDate:       creationDate,
+			CreationDateSource: source,
+			CreationDateParser: parser,
+		},
+	}
+}
+
+type zfsProvider struct{}
+
+func (zfsProvider) Name() string {
+	return ProviderZfs
+}
+
+func (zfsProvider) FindDataset(path string) (*Dataset, error) {
+	return findZfsDataset(path)
+}
+
+func (zfsProvider) ListSnapshots(dataset *Dataset) ([]*Snapshot, error) {
+	return dataset.listZfsSnapshots()
+}
+
+// snapperProvider finds btrfs snapshots managed by snapper, which are located at
+// "<subvolume>/.snapshots/<number>/snapshot" and described by "<subvolume>/.snapshots/<number>/info.xml"
+type snapperProvider struct{}
+
+// NewSnapperProvider creates a provider for the snapshots of btrfs subvolumes managed by snapper
+func NewSnapperProvider() SnapshotProvider {
+	return snapperProvider{}
+}
+
+func (snapperProvider) Name() string {
+	return ProviderSnapper
+}
+
+func (provider snapperProvider) FindDataset(path string) (*Dataset, error) {
+	root, err := findSnapshotsDirRoot(path, snapperSnapshotsDirName, true)
+	if err != nil {
+		return nil, err
+	}
+	return newProviderDataset(provider, root, gopath.Join(root, snapperSnapshotsDirName)), nil
+}
+
+func (snapperProvider) ListSnapshots(dataset *Dataset) ([]*Snapshot, error) {
+	dirs, err := util.ListFilesIn(dataset.snapshotsDir)
+	if err != nil {
+		return []*Snapshot{}, err
+	}
+
+	var result []*Snapshot
+	for _, dir := range dirs {
+		name := gopath.Base(dir)
+		if _, err := strconv.Atoi(name); err != nil {
+			continue
+		}
+		snapshotPath := gopath.Join(dir, "snapshot")
+		if !isDir(snapshotPath) {
+			continue
+		}
+		// without info.xml, the creation date is determined like for any other provider
+		created, _ := readSnapperInfo(gopath.Join(dir, "info.xml"))
+		result = append(result, newProviderSnapshot(dataset, name, snapshotPath, created))
+	}
+	return result, nil
+}
+
+type snapperInfo struct {
+	// Date is the creation date in UTC, e.g. "2026-10-01 00:00:01"
+	Date string `xml:"date"`
+}
+
+func readSnapperInfo(path string) (time.Time, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseSnapperInfo(content)
+}
+
+func parseSnapperInfo(content []byte) (time.Time, error) {
+	var info snapperInfo
+	if err := xml.Unmarshal(content, &info); err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(snapperDateLayout, strings.TrimSpace(info.Date), time.UTC)
+}
+
+// netAppProvider finds the ".snapshot/<name>" directories of NFS shares, as exported by NetApp filers and others
+type netAppProvider struct{}
+
+// NewNetAppProvider creates a provider for the ".snapshot" directories of NFS shares
+func NewNetAppProvider() SnapshotProvider {
+	return netAppProvider{}
+}
+
+func (netAppProvider) Name() string {
+	return ProviderNetApp
+}
+
+func (provider netAppProvider) FindDataset(path string) (*Dataset, error) {
+	// ".snapshot" is accessible in every directory of a volume, its topmost occurrence is the root of the volume
+	root, err := findSnapshotsDirRoot(path, netAppSnapshotsDirName, false)
+	if err != nil {
+		return nil, err
+	}
+	return newProviderDataset(provider, root, gopath.Join(root, netAppSnapshotsDirName)), nil
+}
+
+func (netAppProvider) ListSnapshots(dataset *Dataset) ([]*Snapshot, error) {
+	dirs, err := util.ListFilesIn(dataset.snapshotsDir)
+	if err != nil {
+		return []*Snapshot{}, err
+	}
+
+	var result []*Snapshot
+	for _, dir := range dirs {
+		if !isDir(dir) {
+			continue
+		}
+		result = append(result, newProviderSnapshot(dataset, gopath.Base(dir), dir, time.Time{}))
+	}
+	return result, nil
+}
+
+// directoryProvider finds the copies of a directory within a backup tree, e.g. of rsnapshot or a restic mount
+type directoryProvider struct {
+	path    string
+	pattern string
+	// nameSegment is the index of the path segment of pattern which contains the first wildcard
+	nameSegment int
+}
+
+// NewDirectoryProvider creates a provider which treats every directory matching the given glob pattern
+// as a snapshot of the directory at path. The path segment matched by the first wildcard is used as the snapshot name.
+func NewDirectoryProvider(path string, pattern string) (SnapshotProvider, error) {
+	if !gopath.IsAbs(path) || !gopath.IsAbs(pattern) {
+		return nil, fmt.Errorf("snapshot directories of '%s' must be configured with absolute paths", path)
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid snapshot pattern '%s': %w", pattern, err)
+	}
+	pattern = gopath.Clean(pattern)
+	for i, segment := range strings.Split(pattern, "/") {
+		if strings.ContainsAny(segment, "*?[") {
+			return &directoryProvider{
+				path:        gopath.Clean(path),
+				pattern:     pattern,
+				nameSegment: i,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot pattern '%s' must contain a wildcard for the snapshot name", pattern)
+}
+
+func (provider *directoryProvider) Name() string {
+	return ProviderDirectory
+}
+
+func (provider *directoryProvider) FindDataset(path string) (*Dataset, error) {
+	if !isPathWithin(gopath.Clean(path), provider.path) {
+		return nil, fmt.Errorf("%w: %s is not within %s", errNoDataset, path, provider.path)
+	}
+	return newProviderDataset(provider, provider.path, ""), nil
+}
+
+func (provider *directoryProvider) ListSnapshots(dataset *Dataset) ([]*Snapshot, error) {
+	matches, err := filepath.Glob(provider.pattern)
+	if err != nil {
+		return []*Snapshot{}, err
+	}
+
+	var result []*Snapshot
+	for _, match := range matches {
+		if !isDir(match) {
+			continue
+		}
+		name := strings.Split(match, "/")[provider.nameSegment]
+		result = append(result, newProviderSnapshot(dataset, name, match, time.Time{}))
+	}
+	return result, nil
+}
+
+// findSnapshotsDirRoot returns the directory containing a directory with the given name, starting at path
+// and walking up, without leaving the filesystem (or btrfs subvolume) of path.
+// nearest - return the closest match instead of the topmost one
+func findSnapshotsDirRoot(path string, name string, nearest bool) (string, error) {
+	currentPath := gopath.Clean(path)
+	boundary := "/"
+	fsType := ""
+	if mounts, err := readMountInfo(); err == nil {
+		if mount := findMountForPath(mounts, currentPath); mount != nil {
+			boundary = mount.MountPoint
+			fsType = mount.FsType
+		}
+	}
+
+	root := ""
+	for {
+		if isDir(gopath.Join(currentPath, name)) {
+			root = currentPath
+			if nearest {
+				break
+			}
+		}
+		// subvolumes are not part of the snapshots of their parent subvolume
+		if currentPath == boundary || (fsType == "btrfs" && isBtrfsSubvolumeRoot(currentPath)) {
+			break
+		}
+		parent := gopath.Dir(currentPath)
+		if parent == currentPath {
+			break
+		}
+		currentPath = parent
+	}
+
+	if root == "" {
+		return "", fmt.Errorf("%w: no %s directory found for path: %s", errNoDataset, name, path)
+	}
+	return root, nil
+}
+
+func isBtrfsSubvolumeRoot(path string) bool {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return false
+	}
+	return stat.Ino == btrfsSubvolumeRootInode
+}
+
+func isDir(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
@@ -0,0 +1,166 @@
+package zfs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func snapshotNames(snapshots []*Snapshot) []string {
+	var result []string
+	for _, snapshot := range snapshots {
+		result = append(result, snapshot.Name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestSnapperProvider(t *testing.T) {
+	root := t.TempDir()
+	snapshotsDir := filepath.Join(root, ".snapshots")
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshotsDir, "1", "snapshot", "home"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(snapshotsDir, "1", "info.xml"), []byte(`<?xml version="1.0"?>
+<snapshot>
+  <type>single</type>
+  <num>1</num>
+  <date>2026-10-01 12:30:00</date>
+  <description>before upgrade</description>
+</snapshot>`), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshotsDir, "2", "snapshot"), 0755))
+	// neither a number nor a snapshot directory
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshotsDir, "tmp"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshotsDir, "3"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "home", "user"), 0755))
+
+	provider := NewSnapperProvider()
+	dataset, err := provider.FindDataset(filepath.Join(root, "home", "user"))
+	assert.NoError(t, err)
+	assert.Equal(t, root, dataset.Path)
+	assert.False(t, dataset.IsZfs())
+	assert.Equal(t, ProviderSnapper, dataset.GetProvider().Name())
+	assert.Equal(t, snapshotsDir, dataset.GetSnapshotsDir())
+
+	snapshots, err := dataset.GetSnapshots()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, snapshotNames(snapshots))
+
+	for _, snapshot := range snapshots {
+		switch snapshot.Name {
+		case "1":
+			assert.Equal(t, CreationDateSourceProperty, snapshot.Properties.CreationDateSource)
+			assert.True(t, time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC).Equal(snapshot.Properties.CreationDate))
+			assert.Equal(t, filepath.Join(snapshotsDir, "1", "snapshot", "home", "user", "file"), snapshot.GetSnapshotPath(filepath.Join(root, "home", "user", "file")))
+			assert.Equal(t, filepath.Join(root, "home", "user", "file"), snapshot.GetRealPath(filepath.Join(snapshotsDir, "1", "snapshot", "home", "user", "file")))
+		case "2":
+			assert.Equal(t, CreationDateSourceModTime, snapshot.Properties.CreationDateSource)
+			assert.False(t, snapshot.Properties.CreationDate.IsZero())
+		}
+	}
+}
+
+func TestNetAppProvider_UsesTopmostSnapshotDir(t *testing.T) {
+	root := t.TempDir()
+	volume := filepath.Join(root, "volume")
+	assert.NoError(t, os.MkdirAll(filepath.Join(volume, ".snapshot", "hourly.2026-10-01_0005"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(volume, ".snapshot", "nightly.0"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(volume, "projects", ".snapshot", "nightly.0"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(volume, "projects", "app"), 0755))
+
+	dataset, err := NewNetAppProvider().FindDataset(filepath.Join(volume, "projects", "app"))
+	assert.NoError(t, err)
+	assert.Equal(t, volume, dataset.Path)
+
+	snapshots, err := dataset.GetSnapshots()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hourly.2026-10-01_0005", "nightly.0"}, snapshotNames(snapshots))
+	assert.Equal(t,
+		filepath.Join(volume, ".snapshot", "nightly.0", "projects", "app"),
+		snapshots[1].GetSnapshotPath(filepath.Join(volume, "projects", "app")),
+	)
+}
+
+func TestNetAppProvider_NoSnapshotDir(t *testing.T) {
+	_, err := NewNetAppProvider().FindDataset(t.TempDir())
+	assert.ErrorIs(t, err, errNoDataset)
+}
+
+func TestDirectoryProvider(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	backup := filepath.Join(root, "backup")
+	assert.NoError(t, os.MkdirAll(filepath.Join(backup, "daily.0", "localhost", "home", "user"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(backup, "daily.1", "localhost", "home"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(backup, "zfh-2026-10-01-001700", "localhost", "home"), 0755))
+	// a backup which does not contain the directory
+	assert.NoError(t, os.MkdirAll(filepath.Join(backup, "weekly.0", "localhost"), 0755))
+
+	provider, err := NewDirectoryProvider(home, filepath.Join(backup, "*", "localhost", "home"))
+	assert.NoError(t, err)
+
+	dataset, err := provider.FindDataset(filepath.Join(home, "user"))
+	assert.NoError(t, err)
+	assert.Equal(t, home, dataset.Path)
+	assert.Equal(t, ProviderDirectory, dataset.GetProvider().Name())
+
+	_, err = provider.FindDataset(filepath.Join(root, "homework"))
+	assert.ErrorIs(t, err, errNoDataset)
+
+	snapshots, err := dataset.GetSnapshots()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"daily.0", "daily.1", "zfh-2026-10-01-001700"}, snapshotNames(snapshots))
+	for _, snapshot := range snapshots {
+		if snapshot.Name == "zfh-2026-10-01-001700" {
+			assert.Equal(t, CreationDateSourceName, snapshot.Properties.CreationDateSource)
+		} else {
+			assert.Equal(t, CreationDateSourceModTime, snapshot.Properties.CreationDateSource)
+		}
+		if snapshot.Name == "daily.0" {
+			assert.Equal(t,
+				filepath.Join(backup, "daily.0", "localhost", "home", "user"),
+				snapshot.GetSnapshotPath(filepath.Join(home, "user")),
+			)
+		}
+	}
+}
+
+func TestNewDirectoryProvider_Invalid(t *testing.T) {
+	_, err := NewDirectoryProvider("home", "/backup/*/home")
+	assert.Error(t, err)
+	_, err = NewDirectoryProvider("/home", "/backup/daily.0/home")
+	assert.Error(t, err)
+	_, err = NewDirectoryProvider("/home", "/backup/[/home")
+	assert.Error(t, err)
+}
+
+func TestFindHostDataset_ConfiguredProvider(t *testing.T) {
+	defer ConfigureSnapshotProviders()
+
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, "user"), 0755))
+	provider, err := NewDirectoryProvider(home, filepath.Join(root, "backup", "*", "home"))
+	assert.NoError(t, err)
+
+	ConfigureSnapshotProviders(provider)
+	dataset, err := FindHostDataset(filepath.Join(home, "user"))
+	assert.NoError(t, err)
+	assert.Equal(t, home, dataset.Path)
+	assert.False(t, dataset.IsZfs())
+
+	ConfigureSnapshotProviders()
+	_, err = FindHostDataset(filepath.Join(home, "user"))
+	assert.Error(t, err)
+}
+
+func TestParseSnapperInfo(t *testing.T) {
+	created, err := parseSnapperInfo([]byte(`<snapshot><num>42</num><date>2026-10-18 08:15:00</date></snapshot>`))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 18, 8, 15, 0, 0, time.UTC), created)
+
+	_, err = parseSnapperInfo([]byte(`<snapshot><num>42</num></snapshot>`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	gopath "path"
+	"slices"
+	"strings"
+	"sync"
+
+	golibzfs "github.com/kraudcloud/go-libzfs"
+)
+
+var (
+	// replicaSources maps the name or mountpoint of a dataset to the names or mountpoints of its replicas
+	replicaSources = map[string][]string{}
+	replicaMtx     sync.RWMutex
+)
+
+// ConfigureReplicas sets the replicas of datasets, e.g. copies received with "zfs recv" on a backup pool.
+// Both the datasets (keys) and their replicas (values) are given by their name or mountpoint.
+func ConfigureReplicas(replicas map[string][]string) {
+	replicaMtx.Lock()
+	defer replicaMtx.Unlock()
+	replicaSources = replicas
+}
+
+// IsReplica reports whether this dataset is a replica returned by GetReplicas
+func (dataset *Dataset) IsReplica() bool {
+	return dataset.replicaOf != nil
+}
+
+// IsFromReplica reports whether this snapshot belongs to a replica of the dataset it is shown for
+func (s *Snapshot) IsFromReplica() bool {
+	return s.ParentDataset != nil && s.ParentDataset.IsReplica()
+}
+
+func (dataset *Dataset) getReplicaSources() []string {
+	replicaMtx.RLock()
+	defer replicaMtx.RUnlock()
+	sources := slices.Concat(replicaSources[dataset.GetName()], replicaSources[dataset.Path])
+	slices.Sort(sources)
+	return slices.Compact(sources)
+}
+
+// GetReplicas returns the configured replicas of this dataset.
+// A replica is presented as if it was mounted at the path of this dataset, so files within its snapshots
+// map to the working copy of this dataset and restores write to it.
+// Replicas which are not accessible are reported in the error, all others are returned nonetheless.
+func (dataset *Dataset) GetReplicas() ([]*Dataset, error) {
+	if !dataset.IsZfs() || dataset.IsReplica() {
+		return nil, nil
+	}
+	sources := dataset.getReplicaSources()
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	mounts, err := readMountInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Dataset
+	var errs []error
+	for _, source := range sources {
+		mountPoint, name, err := resolveReplicaSource(mounts, source)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		replica := &Dataset{
+			Path:          dataset.Path,
+			HiddenZfsPath: gopath.Join(mountPoint, ".zfs"),
+			BindRoot:      dataset.BindRoot,
+			name:          name,
+			replicaOf:     dataset,
+		}
+		// not cached, as the cache is keyed by path, which is shared with the primary dataset
+		if libds, err := golibzfs.DatasetOpen(name); err == nil {
+			replica.rawGolibzfsData = &libds
+		}
+		result = append(result, replica)
+	}
+	return result, errors.Join(errs...)
+}
+
+// resolveReplicaSource returns the mountpoint and dataset name of a replica given by either of them
+func resolveReplicaSource(mounts []*mountInfo, source string) (string, string, error) {
+	byMountPoint := strings.HasPrefix(source, "/")
+	if byMountPoint {
+		source = gopath.Clean(source)
+	}
+	// later mounts shadow earlier ones on the same mountpoint
+	for i := len(mounts) - 1; i >= 0; i-- {
+		mount := mounts[i]
+		if mount.FsType != "zfs" || mount.Root != "/" {
+			continue
+		}
+		if (byMountPoint && mount.MountPoint == source) || (!byMountPoint && mount.Source == source) {
+			return mount.MountPoint, mount.Source, nil
+		}
+	}
+	return "", "", fmt.Errorf("replica %s is not mounted", source)
+}
+
+// GetSnapshotsWithReplicas returns the snapshots of this dataset and of its replicas.
+// Snapshots which exist on several of them are only returned once, preferring this dataset over its replicas.
+// Replicas whose snapshots cannot be listed are reported in warnings.
+func (dataset *Dataset) GetSnapshotsWithReplicas() (snapshots []*Snapshot, warnings []error, err error) {
+	return dataset.RefreshSnapshotsWithReplicas(nil)
+}
+
+// RefreshSnapshotsWithReplicas works like GetSnapshotsWithReplicas, but reuses the immutable details of the given
+// previously listed snapshots which still exist, so only new snapshots have to be fetched in full.
+// Reused snapshots are returned as copies with their dynamic properties (used, written, ...) read again.
+func (dataset *Dataset) RefreshSnapshotsWithReplicas(previous []*Snapshot) (snapshots []*Snapshot, warnings []error, err error) {
+	known := map[string]*Snapshot{}
+	for _, snapshot := range previous {
+		known[snapshot.Path] = snapshot
+	}
+
+	snapshots, err = dataset.refreshSnapshots(known)
+	if err != nil {
+		return snapshots, nil, err
+	}
+
+	replicas, err := dataset.GetReplicas()
+	if err != nil {
+		warnings = append(warnings, err)
+	}
+	for _, replica := range replicas {
+		replicaSnapshots, err := replica.refreshSnapshots(known)
+		if err != nil {
+			warnings = append(warnings, fmt.Errorf("cannot list snapshots of replica %s: %w", replica.GetName(), err))
+			continue
+		}
+		snapshots = mergeSnapshots(snapshots, replicaSnapshots)
+	}
+	return snapshots, warnings, nil
+}
+
+// mergeSnapshots appends the additional snapshots which are not contained in snapshots yet.
+// Snapshots are identical if they have the same guid or, if the guid of either of them is unknown, the same name.
+func mergeSnapshots(snapshots []*Snapshot, additional []*Snapshot) []*Snapshot {
+	guids := map[string]bool{}
+	names := map[string]bool{}
+	namesWithoutGuid := map[string]bool{}
+	for _, snapshot := range snapshots {
+		names[snapshot.Name] = true
+		if snapshot.Properties.Guid != "" {
+			guids[snapshot.Properties.Guid] = true
+		} else {
+			namesWithoutGuid[snapshot.Name] = true
+		}
+	}
+
+	result := snapshots
+	for _, snapshot := range additional {
+		if guid := snapshot.Properties.Guid; guid != "" {
+			if guids[guid] || namesWithoutGuid[snapshot.Name] {
+				continue
+			}
+		} else if names[snapshot.Name] {
+			continue
+		}
+		result = append(result, snapshot)
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeSnapshots(t *testing.T) {
+	primary := []*Snapshot{
+		{Name: "daily-1", Properties: SnapshotProperties{Guid: "1"}},
+		{Name: "daily-2", Properties: SnapshotProperties{Guid: "2"}},
+		{Name: "manual"},
+	}
+	replica := []*Snapshot{
+		// received from the primary
+		{Name: "daily-1", Properties: SnapshotProperties{Guid: "1"}},
+		// renamed on the replica, but still the same snapshot
+		{Name: "daily-2-renamed", Properties: SnapshotProperties{Guid: "2"}},
+		// recreated on the primary after it was sent
+		{Name: "daily-2", Properties: SnapshotProperties{Guid: "22"}},
+		// pruned on the primary
+		{Name: "daily-0", Properties: SnapshotProperties{Guid: "0"}},
+		// the guid of the primary snapshot is unknown
+		{Name: "manual", Properties: SnapshotProperties{Guid: "3"}},
+		{Name: "daily-1"},
+	}
+
+	merged := mergeSnapshots(primary, replica)
+	var names []string
+	for _, snapshot := range merged {
+		names = append(names, snapshot.Name+"#"+snapshot.Properties.Guid)
+	}
+	assert.Equal(t, []string{"daily-1#1", "daily-2#2", "manual#", "daily-2#22", "daily-0#0"}, names)
+}
+
+func TestResolveReplicaSource(t *testing.T) {
+	mounts, err := parseMountInfo(strings.NewReader(`
+40 1 0:40 / /tank/home rw - zfs tank/home rw
+41 1 0:41 / /mnt/backup/home rw - zfs backup/tank/home rw
+42 1 0:41 /user /srv/user rw - zfs backup/tank/home rw
+`))
+	assert.NoError(t, err)
+
+	mountPoint, name, err := resolveReplicaSource(mounts, "backup/tank/home")
+	assert.NoError(t, err)
+	assert.Equal(t, "/mnt/backup/home", mountPoint)
+	assert.Equal(t, "backup/tank/home", name)
+
+	mountPoint, name, err = resolveReplicaSource(mounts, "/mnt/backup/home/")
+	assert.NoError(t, err)
+	assert.Equal(t, "/mnt/backup/home", mountPoint)
+	assert.Equal(t, "backup/tank/home", name)
+
+	_, _, err = resolveReplicaSource(mounts, "backup/tank/srv")
+	assert.Error(t, err)
+	_, _, err = resolveReplicaSource(mounts, "/srv/user")
+	assert.Error(t, err)
+}
+
+func TestGetReplicaSources(t *testing.T) {
+	defer ConfigureReplicas(map[string][]string{})
+	ConfigureReplicas(map[string][]string{
+		"tank/home":  {"backup/home"},
+		"/tank/home": {"/mnt/offsite/home", "backup/home"},
+	})
+
+	dataset := &Dataset{Path: "/tank/home", name: "tank/home"}
+	assert.Equal(t, []string{"/mnt/offsite/home", "backup/home"}, dataset.getReplicaSources())
+
+	other := &Dataset{Path: "/tank/srv", name: "tank/srv"}
+	assert.Empty(t, other.getReplicaSources())
+}
+
+func TestReplicaSnapshot_MapsToPrimaryPath(t *testing.T) {
+	primary := &Dataset{Path: "/tank/home", HiddenZfsPath: "/tank/home/.zfs", name: "tank/home"}
+	replica := &Dataset{Path: primary.Path, HiddenZfsPath: "/mnt/backup/home/.zfs", name: "backup/home", replicaOf: primary}
+	snapshot := &Snapshot{Name: "daily-0", Path: "/mnt/backup/home/.zfs/snapshot/daily-0", ParentDataset: replica}
+
+	assert.True(t, snapshot.IsFromReplica())
+	assert.Equal(t, "/mnt/backup/home/.zfs/snapshot/daily-0/user/file", snapshot.GetSnapshotPath("/tank/home/user/file"))
+	assert.Equal(t, "/tank/home/user/file", snapshot.GetRealPath("/mnt/backup/home/.zfs/snapshot/daily-0/user/file"))
+
+	replicas, err := replica.GetReplicas()
+	assert.NoError(t, err)
+	assert.Empty(t, replicas)
+}
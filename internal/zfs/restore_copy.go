@@ -0,0 +1,222 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// CopyMethod describes how the content of a restored file has been copied
+type CopyMethod int
+
+const (
+	// CopyMethodClone shares the blocks of the snapshot using the FICLONE ioctl, no data is copied and no space is used
+	CopyMethodClone CopyMethod = iota
+	// CopyMethodCopyFileRange copies within the kernel using copy_file_range,
+	// which OpenZFS 2.2+ turns into block cloning if it is enabled on the pool
+	CopyMethodCopyFileRange
+	// CopyMethodBuffered reads and writes the content through a buffer
+	CopyMethodBuffered
+)
+
+func (m CopyMethod) String() string {
+	switch m {
+	case CopyMethodClone:
+		return "block-cloned"
+	case CopyMethodCopyFileRange:
+		return "copied by the kernel (copy_file_range)"
+	case CopyMethodBuffered:
+		return "copied"
+	default:
+		return fmt.Sprintf("CopyMethod(%d)", int(m))
+	}
+}
+
+// copyBufferSize is the size of the buffer used if the kernel cannot copy a file itself
+const copyBufferSize = 1024 * 1024
+
+// RestoreReport summarizes a restore
+type RestoreReport struct {
+	// CopyMethods counts the restored files by the way their content has been copied
+	CopyMethods map[CopyMethod]int
+	// Warnings contains problems which did not abort the restore, e.g. skipped child datasets
+	Warnings []error
+}
+
+func (r *RestoreReport) addFile(method CopyMethod) {
+	if r.CopyMethods == nil {
+		r.CopyMethods = map[CopyMethod]int{}
+	}
+	r.CopyMethods[method]++
+}
+
+func (r *RestoreReport) merge(other RestoreReport) {
+	for method, count := range other.CopyMethods {
+		if r.CopyMethods == nil {
+			r.CopyMethods = map[CopyMethod]int{}
+		}
+		r.CopyMethods[method] += count
+	}
+	r.Warnings = append(r.Warnings, other.Warnings...)
+}
+
+// Summary describes how the files have been restored, e.g. "Restored 3 files: 2 block-cloned, 1 copied"
+func (r RestoreReport) Summary() string {
+	total := 0
+	var methods []CopyMethod
+	for method, count := range r.CopyMethods {
+		total += count
+		methods = append(methods, method)
+	}
+	if total == 0 {
+		return "No file content had to be restored"
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i] < methods[j]
+	})
+
+	var parts []string
+	for _, method := range methods {
+		parts = append(parts, fmt.Sprintf("%d %s", r.CopyMethods[method], method))
+	}
+	return fmt.Sprintf("Restored %d %s: %s", total, pluralize(total, "file", "files"), strings.Join(parts, ", "))
+}
+
+func pluralize(count int, singular string, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
+
+// copyFileContent copies the content of src to dst, which must be empty.
+// Blocks are cloned if possible, otherwise only the data regions of src are copied, so holes of sparse files are preserved.
+// Copied bytes are throttled by the given limiter, which may be nil. Cloned blocks are not, as no data is copied.
+func copyFileContent(dst *os.File, src *os.File, size int64, limiter *bandwidthLimiter) (CopyMethod, error) {
+	if size == 0 {
+		return CopyMethodBuffered, nil
+	}
+	if err := unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())); err == nil {
+		return CopyMethodClone, nil
+	}
+
+	segments, err := dataSegments(src, size)
+	if err != nil {
+		return CopyMethodBuffered, err
+	}
+
+	method := CopyMethodCopyFileRange
+	for _, segment := range segments {
+		if method == CopyMethodCopyFileRange {
+			copied, err := copySegmentInKernel(dst, src, segment, limiter)
+			if err == nil {
+				continue
+			} else if !isCopyFileRangeUnsupported(err) {
+				return method, err
+			}
+			// e.g. an older kernel, or a file system without support, continue behind what has been copied
+			method = CopyMethodBuffered
+			segment.start += copied
+		}
+		if err := copySegmentBuffered(dst, src, segment, limiter); err != nil {
+			return method, err
+		}
+	}
+
+	// a trailing hole is not covered by any data segment
+	return method, dst.Truncate(size)
+}
+
+// dataSegment is a region [start, end) of a file which contains data, as opposed to a hole
+type dataSegment struct {
+	start int64
+	end   int64
+}
+
+// dataSegments returns the regions of the file which contain data using SEEK_DATA and SEEK_HOLE.
+// If the file system does not support them, the whole file is a single segment.
+func dataSegments(file *os.File, size int64) ([]dataSegment, error) {
+	fd := int(file.Fd())
+	var segments []dataSegment
+	offset := int64(0)
+	for offset < size {
+		start, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			// no more data behind offset
+			break
+		} else if err != nil {
+			return []dataSegment{{start: 0, end: size}}, nil
+		}
+		end, err := unix.Seek(fd, start, unix.SEEK_HOLE)
+		if err != nil {
+			return []dataSegment{{start: 0, end: size}}, nil
+		}
+		end = min(end, size)
+		if end <= start {
+			break
+		}
+		segments = append(segments, dataSegment{start: start, end: end})
+		offset = end
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+// copySegmentInKernel copies the segment using copy_file_range, returning the number of bytes copied before an error
+func copySegmentInKernel(dst *os.File, src *os.File, segment dataSegment, limiter *bandwidthLimiter) (int64, error) {
+	srcOffset := segment.start
+	dstOffset := segment.start
+	for srcOffset < segment.end {
+		length := segment.end - srcOffset
+		if limiter.isLimited() {
+			// copy in small chunks, so the bandwidth is spread evenly
+			length = min(length, copyBufferSize)
+			limiter.wait(length)
+		}
+		n, err := unix.CopyFileRange(int(src.Fd()), &srcOffset, int(dst.Fd()), &dstOffset, int(length), 0)
+		if err != nil {
+			return srcOffset - segment.start, err
+		}
+		if n == 0 {
+			// the file has been truncated while copying it
+			break
+		}
+	}
+	return srcOffset - segment.start, nil
+}
+
+func isCopyFileRangeUnsupported(err error) bool {
+	return errors.Is(err, unix.ENOSYS) ||
+		errors.Is(err, unix.EXDEV) ||
+		errors.Is(err, unix.EOPNOTSUPP) ||
+		errors.Is(err, unix.EINVAL)
+}
+
+// copySegmentBuffered copies the segment by reading and writing it through a buffer
+func copySegmentBuffered(dst *os.File, src *os.File, segment dataSegment, limiter *bandwidthLimiter) error {
+	reader := io.NewSectionReader(src, segment.start, segment.end-segment.start)
+	writer := &throttledWriter{
+		writer:  io.NewOffsetWriter(dst, segment.start),
+		limiter: limiter,
+	}
+	_, err := io.CopyBuffer(writer, reader, make([]byte, copyBufferSize))
+	return err
+}
+
+// throttledWriter waits for the limiter before each write
+type throttledWriter struct {
+	writer  io.Writer
+	limiter *bandwidthLimiter
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	w.limiter.wait(int64(len(p)))
+	return w.writer.Write(p)
+}
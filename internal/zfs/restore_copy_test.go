@@ -0,0 +1,169 @@
+package zfs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createSparseTestFile creates a file with data at its start and in its middle, and a trailing hole
+func createSparseTestFile(t *testing.T, path string, size int64) []byte {
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	head := bytes.Repeat([]byte("a"), 4096)
+	middle := bytes.Repeat([]byte("b"), 4096)
+	_, err = file.WriteAt(head, 0)
+	assert.NoError(t, err)
+	_, err = file.WriteAt(middle, size/2)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Truncate(size))
+
+	content := make([]byte, size)
+	copy(content, head)
+	copy(content[size/2:], middle)
+	return content
+}
+
+func copyTestFile(t *testing.T, srcPath string, dstPath string) CopyMethod {
+	src, err := os.Open(srcPath)
+	assert.NoError(t, err)
+	defer func() { _ = src.Close() }()
+	dst, err := os.Create(dstPath)
+	assert.NoError(t, err)
+	defer func() { _ = dst.Close() }()
+	stat, err := src.Stat()
+	assert.NoError(t, err)
+
+	method, err := copyFileContent(dst, src, stat.Size(), nil)
+	assert.NoError(t, err)
+	return method
+}
+
+func allocatedBlocks(t *testing.T, path string) int64 {
+	stat, err := os.Stat(path)
+	assert.NoError(t, err)
+	return stat.Sys().(*syscall.Stat_t).Blocks
+}
+
+func TestCopyFileContent(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src")
+	dstPath := filepath.Join(dir, "dst")
+	content := bytes.Repeat([]byte("0123456789"), 300_000)
+	assert.NoError(t, os.WriteFile(srcPath, content, 0644))
+
+	copyTestFile(t, srcPath, dstPath)
+
+	result, err := os.ReadFile(dstPath)
+	assert.NoError(t, err)
+	assert.Equal(t, content, result)
+}
+
+func TestCopyFileContent_Empty(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src")
+	dstPath := filepath.Join(dir, "dst")
+	assert.NoError(t, os.WriteFile(srcPath, nil, 0644))
+
+	assert.Equal(t, CopyMethodBuffered, copyTestFile(t, srcPath, dstPath))
+
+	result, err := os.ReadFile(dstPath)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestCopyFileContent_PreservesSparseFiles(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src")
+	dstPath := filepath.Join(dir, "dst")
+	size := int64(64 * 1024 * 1024)
+	content := createSparseTestFile(t, srcPath, size)
+
+	copyTestFile(t, srcPath, dstPath)
+
+	result, err := os.ReadFile(dstPath)
+	assert.NoError(t, err)
+	assert.Equal(t, len(content), len(result))
+	assert.True(t, bytes.Equal(content, result))
+	assert.LessOrEqual(t, allocatedBlocks(t, dstPath), allocatedBlocks(t, srcPath))
+}
+
+func TestDataSegments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sparse")
+	size := int64(64 * 1024 * 1024)
+	createSparseTestFile(t, path, size)
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	segments, err := dataSegments(file, size)
+	assert.NoError(t, err)
+
+	// file systems without hole support report a single segment covering the whole file
+	if assert.NotEmpty(t, segments) {
+		assert.Equal(t, int64(0), segments[0].start)
+		assert.LessOrEqual(t, segments[len(segments)-1].end, size)
+	}
+	for i := 1; i < len(segments); i++ {
+		assert.Greater(t, segments[i].start, segments[i-1].end)
+	}
+}
+
+func TestCopySegmentBuffered(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src")
+	assert.NoError(t, os.WriteFile(srcPath, []byte("0123456789"), 0644))
+	src, err := os.Open(srcPath)
+	assert.NoError(t, err)
+	defer func() { _ = src.Close() }()
+	dst, err := os.Create(filepath.Join(dir, "dst"))
+	assert.NoError(t, err)
+	defer func() { _ = dst.Close() }()
+
+	assert.NoError(t, copySegmentBuffered(dst, src, dataSegment{start: 2, end: 5}, nil))
+	assert.NoError(t, copySegmentBuffered(dst, src, dataSegment{start: 7, end: 10}, nil))
+
+	result, err := os.ReadFile(dst.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("\x00\x00234\x00\x00789"), result)
+}
+
+func TestRestoreReport_Summary(t *testing.T) {
+	assert.Equal(t, "No file content had to be restored", RestoreReport{}.Summary())
+
+	report := RestoreReport{}
+	report.addFile(CopyMethodBuffered)
+	assert.Equal(t, "Restored 1 file: 1 copied", report.Summary())
+
+	other := RestoreReport{Warnings: []error{errors.New("skipped")}}
+	other.addFile(CopyMethodClone)
+	other.addFile(CopyMethodClone)
+	report.merge(other)
+	assert.Equal(t, "Restored 3 files: 2 block-cloned, 1 copied", report.Summary())
+	assert.Len(t, report.Warnings, 1)
+}
+
+func TestSnapshotRestoreFile(t *testing.T) {
+	snapshot, _ := createNestedDatasets(t, []string{"snap1"}, nil)
+	srcPath := filepath.Join(snapshot.Path, "dir", "file.txt")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(srcPath), 0755))
+	assert.NoError(t, os.WriteFile(srcPath, []byte("restored"), 0640))
+
+	_, err := snapshot.RestoreFile(srcPath)
+	assert.NoError(t, err)
+
+	dstPath := filepath.Join(snapshot.ParentDataset.Path, "dir", "file.txt")
+	content, err := os.ReadFile(dstPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "restored", string(content))
+	stat, err := os.Stat(dstPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+}
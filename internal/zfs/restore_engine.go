@@ -0,0 +1,341 @@
+package zfs
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"time"
+	"zfs-file-history/internal/logging"
+	"zfs-file-history/internal/util"
+
+	"golang.org/x/sys/unix"
+)
+
+// values of the ioprio_set syscall, see ioprio_set(2)
+const (
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// RestoreSettings controls how much of the I/O capacity of the system a restore may use
+type RestoreSettings struct {
+	// Workers is the number of files which are copied in parallel
+	Workers int
+	// BandwidthLimit is the maximum number of bytes per second which are copied, 0 is unlimited
+	BandwidthLimit int64
+	// IdleIoPriority copies files with the idle I/O scheduling class, so other processes are served first
+	IdleIoPriority bool
+}
+
+// normalize clamps the settings to valid values
+func (settings RestoreSettings) normalize() RestoreSettings {
+	settings.Workers = min(max(settings.Workers, 1), util.MaxRestoreWorkers)
+	settings.BandwidthLimit = max(settings.BandwidthLimit, 0)
+	return settings
+}
+
+var (
+	currentRestoreSettings = RestoreSettings{Workers: util.DefaultRestoreWorkers}
+	restoreSettingsMtx     sync.RWMutex
+)
+
+// ConfigureRestore sets the settings used by restores which are started afterward
+func ConfigureRestore(settings RestoreSettings) {
+	restoreSettingsMtx.Lock()
+	defer restoreSettingsMtx.Unlock()
+	currentRestoreSettings = settings.normalize()
+}
+
+// GetRestoreSettings returns the configured restore settings
+func GetRestoreSettings() RestoreSettings {
+	restoreSettingsMtx.RLock()
+	defer restoreSettingsMtx.RUnlock()
+	return currentRestoreSettings
+}
+
+// RestoreEngine restores files of snapshots, copying multiple files in parallel.
+// Its settings can be changed while a restore is running and apply to the files copied afterward.
+type RestoreEngine struct {
+	mtx      sync.Mutex
+	settings RestoreSettings
+	// workerFreed is signalled whenever a worker has finished or the number of workers has changed
+	workerFreed   *sync.Cond
+	activeWorkers int
+	limiter       *bandwidthLimiter
+}
+
+func NewRestoreEngine(settings RestoreSettings) *RestoreEngine {
+	engine := &RestoreEngine{
+		limiter: &bandwidthLimiter{},
+	}
+	engine.workerFreed = sync.NewCond(&engine.mtx)
+	engine.SetSettings(settings)
+	return engine
+}
+
+// Settings returns the current settings of the engine
+func (e *RestoreEngine) Settings() RestoreSettings {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.settings
+}
+
+// SetSettings changes the settings, a running restore adopts them with the next file it copies
+func (e *RestoreEngine) SetSettings(settings RestoreSettings) {
+	settings = settings.normalize()
+	e.mtx.Lock()
+	e.settings = settings
+	e.mtx.Unlock()
+	e.limiter.setLimit(settings.BandwidthLimit)
+	e.workerFreed.Broadcast()
+}
+
+// Restore restores the given snapshot path, the content of directories is not restored
+func (e *RestoreEngine) Restore(snapshot *Snapshot, srcPath string) (RestoreReport, error) {
+	stat, err := os.Lstat(srcPath)
+	if err != nil {
+		return RestoreReport{}, err
+	}
+	dstPath := snapshot.GetRealPath(srcPath)
+	if stat.IsDir() && snapshot.isChildDatasetRoot(dstPath) {
+		// the directory within this snapshot is only the (empty) mountpoint of the child dataset
+		child, err := snapshot.ForChildDataset(dstPath)
+		if err != nil {
+			return RestoreReport{}, err
+		}
+		return e.Restore(child, child.GetSnapshotPath(dstPath))
+	}
+	if stat.IsDir() {
+		return RestoreReport{}, snapshot.RestoreDir(dstPath, stat)
+	}
+
+	run := &restoreRun{engine: e}
+	run.copyFile(snapshot, srcPath)
+	return run.wait()
+}
+
+// RestoreRecursive restores the given snapshot path and everything below it.
+// Child datasets are restored from their snapshot with the same name, if one exists.
+// Child datasets without such a snapshot are skipped and reported in the warnings of the report.
+// The properties of directories are restored after all of their content, so their modification time is not affected.
+func (e *RestoreEngine) RestoreRecursive(snapshot *Snapshot, srcPath string) (RestoreReport, error) {
+	run := &restoreRun{engine: e}
+	err := run.walk(snapshot, srcPath)
+	report, copyErr := run.wait()
+	if err == nil {
+		err = copyErr
+	}
+	if err != nil {
+		return report, err
+	}
+	return report, run.restoreDirectories()
+}
+
+// acquireWorker blocks until less than the configured number of workers are active
+func (e *RestoreEngine) acquireWorker() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	for e.activeWorkers >= e.settings.Workers {
+		e.workerFreed.Wait()
+	}
+	e.activeWorkers++
+}
+
+func (e *RestoreEngine) releaseWorker() {
+	e.mtx.Lock()
+	e.activeWorkers--
+	e.mtx.Unlock()
+	e.workerFreed.Broadcast()
+}
+
+// restoreFile restores a single file, it must be called on a goroutine of its own,
+// which ends afterward, since the I/O priority of its thread might be changed
+func (e *RestoreEngine) restoreFile(snapshot *Snapshot, srcPath string) (CopyMethod, error) {
+	if e.Settings().IdleIoPriority {
+		// the I/O priority applies to the current thread only.
+		// The thread is not unlocked again, so it is discarded when the goroutine ends.
+		runtime.LockOSThread()
+		if err := setIdleIoPriority(); err != nil {
+			logging.Warning("Cannot set idle I/O priority: %s", err.Error())
+		}
+	}
+	return snapshot.restoreFile(srcPath, e.limiter)
+}
+
+// setIdleIoPriority moves the current thread into the idle I/O scheduling class,
+// it is only served when no other process needs the disk. Only I/O schedulers which support priorities respect it.
+func setIdleIoPriority() error {
+	_, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, 0, ioprioClassIdle<<ioprioClassShift)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// restoredDirectory is a directory whose properties are restored after all of its content
+type restoredDirectory struct {
+	snapshot *Snapshot
+	dstPath  string
+	stat     os.FileInfo
+}
+
+// restoreRun is a single restore of a RestoreEngine
+type restoreRun struct {
+	engine *RestoreEngine
+	wg     sync.WaitGroup
+
+	mtx    sync.Mutex
+	report RestoreReport
+	err    error
+
+	// directories in the order they have been created, parents before their children
+	directories []restoredDirectory
+}
+
+// walk creates the directories below srcPath and passes all files to the workers of the engine
+func (run *restoreRun) walk(snapshot *Snapshot, srcPath string) error {
+	stat, err := os.Lstat(srcPath)
+	if err != nil {
+		return err
+	}
+	dstPath := snapshot.GetRealPath(srcPath)
+	if stat.IsDir() && snapshot.isChildDatasetRoot(dstPath) {
+		child, err := snapshot.ForChildDataset(dstPath)
+		if IsMissingChildSnapshot(err) {
+			logging.Warning("%s", err.Error())
+			run.addWarning(err)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return run.walk(child, child.GetSnapshotPath(dstPath))
+	}
+
+	if !stat.IsDir() {
+		run.copyFile(snapshot, srcPath)
+		return nil
+	}
+
+	// the directory has to stay writable until all of its content is restored
+	err = os.MkdirAll(dstPath, stat.Mode()|OS_USER_RWX)
+	if err != nil {
+		return err
+	}
+	run.directories = append(run.directories, restoredDirectory{snapshot: snapshot, dstPath: dstPath, stat: stat})
+
+	files, err := util.ListFilesIn(srcPath)
+	if err != nil {
+		logging.Error("Cannot list path: %s", err.Error())
+		return err
+	}
+	for _, file := range files {
+		if run.failed() {
+			return nil
+		}
+		err = run.walk(snapshot, file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile restores the file on a worker as soon as one is available
+func (run *restoreRun) copyFile(snapshot *Snapshot, srcPath string) {
+	run.engine.acquireWorker()
+	run.wg.Add(1)
+	go func() {
+		defer run.wg.Done()
+		defer run.engine.releaseWorker()
+		method, err := run.engine.restoreFile(snapshot, srcPath)
+
+		run.mtx.Lock()
+		defer run.mtx.Unlock()
+		if err != nil {
+			if run.err == nil {
+				run.err = err
+			}
+			return
+		}
+		run.report.addFile(method)
+	}()
+}
+
+func (run *restoreRun) addWarning(warning error) {
+	run.mtx.Lock()
+	defer run.mtx.Unlock()
+	run.report.Warnings = append(run.report.Warnings, warning)
+}
+
+// failed returns true if a file could not be restored, no further files are started in that case
+func (run *restoreRun) failed() bool {
+	run.mtx.Lock()
+	defer run.mtx.Unlock()
+	return run.err != nil
+}
+
+// wait waits for all files to be copied and returns the report, and the first error which occurred
+func (run *restoreRun) wait() (RestoreReport, error) {
+	run.wg.Wait()
+	run.mtx.Lock()
+	defer run.mtx.Unlock()
+	return run.report, run.err
+}
+
+// restoreDirectories restores the properties of all directories, children before their parents,
+// since restoring anything within a directory changes its modification time
+func (run *restoreRun) restoreDirectories() error {
+	for i := len(run.directories) - 1; i >= 0; i-- {
+		directory := run.directories[i]
+		err := directory.snapshot.RestoreDir(directory.dstPath, directory.stat)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bandwidthLimiter spreads the bytes copied by all workers of a restore evenly over time
+type bandwidthLimiter struct {
+	mtx            sync.Mutex
+	bytesPerSecond int64
+	// next is the point in time from which on further bytes may be copied
+	next time.Time
+}
+
+func (l *bandwidthLimiter) setLimit(bytesPerSecond int64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.bytesPerSecond = bytesPerSecond
+	l.next = time.Time{}
+}
+
+func (l *bandwidthLimiter) isLimited() bool {
+	if l == nil {
+		return false
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.bytesPerSecond > 0
+}
+
+// wait blocks until n more bytes may be copied without exceeding the limit
+func (l *bandwidthLimiter) wait(n int64) {
+	if l == nil {
+		return
+	}
+	l.mtx.Lock()
+	if l.bytesPerSecond <= 0 {
+		l.mtx.Unlock()
+		return
+	}
+	start := l.next
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(time.Duration(float64(n) / float64(l.bytesPerSecond) * float64(time.Second)))
+	l.mtx.Unlock()
+
+	time.Sleep(time.Until(start))
+}
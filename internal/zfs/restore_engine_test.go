@@ -0,0 +1,101 @@
+package zfs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+	"zfs-file-history/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreEngine_RestoreRecursive_RestoresDirectoryPropertiesAfterContent(t *testing.T) {
+	snapshot, _ := createNestedDatasets(t, []string{"snap1"}, nil)
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var directories []string
+	for i := 0; i < 5; i++ {
+		dir := filepath.Join(snapshot.Path, fmt.Sprintf("dir-%d", i), "nested")
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		for j := 0; j < 20; j++ {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file-%d", j)), []byte(dir), 0644))
+		}
+		directories = append(directories, dir, filepath.Dir(dir))
+	}
+	for _, dir := range directories {
+		assert.NoError(t, os.Chtimes(dir, modTime, modTime))
+	}
+
+	engine := NewRestoreEngine(RestoreSettings{Workers: 8})
+	report, err := engine.RestoreRecursive(snapshot, snapshot.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, countRestoredFiles(report))
+
+	for _, dir := range directories {
+		stat, err := os.Stat(snapshot.GetRealPath(dir))
+		assert.NoError(t, err)
+		assert.True(t, modTime.Equal(stat.ModTime()), dir)
+	}
+}
+
+func TestRestoreEngine_SetSettings(t *testing.T) {
+	engine := NewRestoreEngine(RestoreSettings{Workers: 0, BandwidthLimit: -1})
+	assert.Equal(t, RestoreSettings{Workers: 1}, engine.Settings())
+
+	engine.SetSettings(RestoreSettings{Workers: 1000, BandwidthLimit: 1000, IdleIoPriority: true})
+	assert.Equal(t, RestoreSettings{Workers: util.MaxRestoreWorkers, BandwidthLimit: 1000, IdleIoPriority: true}, engine.Settings())
+	assert.True(t, engine.limiter.isLimited())
+}
+
+func TestRestoreEngine_Restore_IdleIoPriority(t *testing.T) {
+	snapshot, _ := createNestedDatasets(t, []string{"snap1"}, nil)
+	srcPath := filepath.Join(snapshot.Path, "file.txt")
+	assert.NoError(t, os.WriteFile(srcPath, []byte("restored"), 0644))
+
+	engine := NewRestoreEngine(RestoreSettings{Workers: 1, IdleIoPriority: true})
+	report, err := engine.Restore(snapshot, srcPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, countRestoredFiles(report))
+
+	content, err := os.ReadFile(snapshot.GetRealPath(srcPath))
+	assert.NoError(t, err)
+	assert.Equal(t, "restored", string(content))
+}
+
+func TestSetIdleIoPriority(t *testing.T) {
+	done := make(chan error)
+	go func() {
+		// the thread is discarded when the goroutine ends
+		runtime.LockOSThread()
+		done <- setIdleIoPriority()
+	}()
+	assert.NoError(t, <-done)
+}
+
+func TestBandwidthLimiter(t *testing.T) {
+	limiter := &bandwidthLimiter{}
+	assert.False(t, limiter.isLimited())
+	var nilLimiter *bandwidthLimiter
+	assert.False(t, nilLimiter.isLimited())
+	nilLimiter.wait(1000)
+
+	limiter.setLimit(1000 * 1000)
+	assert.True(t, limiter.isLimited())
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		limiter.wait(50 * 1000)
+	}
+	// the first chunk is copied immediately, every further one after the previous one has been "used up"
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+// countRestoredFiles returns the number of restored files, regardless of how the file system copied them
+func countRestoredFiles(report RestoreReport) int {
+	count := 0
+	for _, files := range report.CopyMethods {
+		count += files
+	}
+	return count
+}
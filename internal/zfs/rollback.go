@@ -0,0 +1,269 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"zfs-file-history/internal/data/diff_state"
+)
+
+// RollbackImpact describes what would be destroyed when rolling back a dataset to a snapshot
+type RollbackImpact struct {
+	// NewerSnapshots contains the names of all snapshots which are more recent than the target
+	// snapshot and would be destroyed by `zfs rollback -r`.
+	NewerSnapshots []string
+	// Clones contains the names of all datasets cloned from one of the NewerSnapshots,
+	// which would additionally be destroyed by `zfs rollback -R`.
+	Clones []string
+}
+
+// ErrRollbackImpactChanged is returned by RollbackConfirmed, if snapshots or clones have been created or destroyed
+// since the impact has been shown to the user
+var ErrRollbackImpactChanged = errors.New("the snapshots or clones destroyed by the rollback have changed since it has been confirmed, please review it again")
+
+// IsEqual reports whether both impacts destroy the same snapshots and clones
+func (impact *RollbackImpact) IsEqual(other *RollbackImpact) bool {
+	sorted := func(names []string) []string {
+		names = slices.Clone(names)
+		slices.Sort(names)
+		return names
+	}
+	return slices.Equal(sorted(impact.NewerSnapshots), sorted(other.NewerSnapshots)) &&
+		slices.Equal(sorted(impact.Clones), sorted(other.Clones))
+}
+
+// GetRollbackImpact determines which snapshots and clones would be destroyed
+// when rolling back the parent dataset to this snapshot.
+func (s *Snapshot) GetRollbackImpact() (*RollbackImpact, error) {
+	output, err := runZfsCommand(
+		"list", "-H", "-p",
+		"-t", "snapshot",
+		"-d", "1",
+		"-s", "createtxg",
+		"-o", "name,createtxg,clones",
+		s.ParentDataset.GetName(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseRollbackImpact(output, s.FullName)
+}
+
+// parseRollbackImpact parses the output of `zfs list -H -p -o name,createtxg,clones`
+func parseRollbackImpact(output string, snapshotFullName string) (*RollbackImpact, error) {
+	type snapshotLine struct {
+		name      string
+		createTxg uint64
+		clones    []string
+	}
+
+	var lines []snapshotLine
+	var targetTxg uint64
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, errors.New("unexpected zfs list output: " + line)
+		}
+		createTxg, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		var clones []string
+		for _, clone := range strings.Split(fields[2], ",") {
+			if clone != "" && clone != "-" {
+				clones = append(clones, clone)
+			}
+		}
+
+		if fields[0] == snapshotFullName {
+			targetTxg = createTxg
+			found = true
+		}
+		lines = append(lines, snapshotLine{name: fields[0], createTxg: createTxg, clones: clones})
+	}
+
+	if !found {
+		return nil, errors.New("snapshot not found: " + snapshotFullName)
+	}
+
+	impact := &RollbackImpact{}
+	for _, line := range lines {
+		if line.createTxg <= targetTxg {
+			continue
+		}
+		_, name, _ := strings.Cut(line.name, "@")
+		impact.NewerSnapshots = append(impact.NewerSnapshots, name)
+		impact.Clones = append(impact.Clones, line.clones...)
+	}
+	return impact, nil
+}
+
+// RollbackConfirmed rolls back the parent dataset to this snapshot, destroying the snapshots and clones of the
+// confirmed impact. The impact is determined again right before, to never destroy anything the user has not seen.
+// Returns ErrRollbackImpactChanged without rolling back, if it differs from the confirmed one.
+func (s *Snapshot) RollbackConfirmed(confirmed *RollbackImpact) error {
+	current, err := s.GetRollbackImpact()
+	if err != nil {
+		return err
+	}
+	if !current.IsEqual(confirmed) {
+		return ErrRollbackImpactChanged
+	}
+	return s.Rollback(len(confirmed.NewerSnapshots) > 0, len(confirmed.Clones) > 0)
+}
+
+// Rollback rolls back the parent dataset to this snapshot.
+// destroyMoreRecent - destroy all snapshots which are more recent than this one (-r)
+// destroyClones - additionally destroy all clones of those snapshots (-R)
+func (s *Snapshot) Rollback(destroyMoreRecent bool, destroyClones bool) error {
+	args := []string{"rollback"}
+	if destroyClones {
+		args = append(args, "-R")
+	} else if destroyMoreRecent {
+		args = append(args, "-r")
+	}
+	args = append(args, s.FullName)
+	_, err := runZfsCommand(args...)
+	return err
+}
+
+// WorkingCopyChange is a single path which differs between the working copy and a snapshot
+type WorkingCopyChange struct {
+	Path  string
+	State diff_state.DiffState
+}
+
+// WorkingCopyChanges summarizes the differences between the working copy of a dataset and a snapshot
+type WorkingCopyChanges struct {
+	Added    int
+	Modified int
+	Deleted  int
+	// Examples contains a limited amount of the detected changes
+	Examples []WorkingCopyChange
+	// Incomplete is set, if the comparison was aborted before all files have been visited
+	Incomplete bool
+}
+
+func (c *WorkingCopyChanges) Total() int {
+	return c.Added + c.Modified + c.Deleted
+}
+
+func (c *WorkingCopyChanges) add(path string, state diff_state.DiffState, maxExamples int) {
+	switch state {
+	case diff_state.Added:
+		c.Added++
+	case diff_state.Modified:
+		c.Modified++
+	case diff_state.Deleted:
+		c.Deleted++
+	default:
+		return
+	}
+	if len(c.Examples) < maxExamples {
+		c.Examples = append(c.Examples, WorkingCopyChange{Path: path, State: state})
+	}
+}
+
+// DiffWorkingCopy compares the working copy of the parent dataset with this snapshot.
+// Nested datasets are not descended into. Directories are only reported when they have been added or deleted,
+// since their modification time changes with their content anyway.
+// If ctx is done before the comparison has finished, the partial result is returned and marked as incomplete.
+func (s *Snapshot) DiffWorkingCopy(ctx context.Context, maxExamples int) (*WorkingCopyChanges, error) {
+	changes := &WorkingCopyChanges{}
+	rootPath := s.ParentDataset.Path
+
+	rootStat, err := os.Lstat(rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	errAborted := errors.New("aborted")
+
+	err = filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return errAborted
+		}
+		if err != nil {
+			return nil
+		}
+		if path == rootPath {
+			return nil
+		}
+		if path == s.ParentDataset.HiddenZfsPath {
+			return fs.SkipDir
+		}
+		if d.IsDir() && !isSameDevice(rootStat, path) {
+			return fs.SkipDir
+		}
+
+		state := s.DetermineDiffState(path)
+		if d.IsDir() && state == diff_state.Modified {
+			return nil
+		}
+		changes.add(path, state, maxExamples)
+		if d.IsDir() && state == diff_state.Added {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if errors.Is(err, errAborted) {
+		changes.Incomplete = true
+		return changes, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	snapshotRootPath := s.GetSnapshotPath(rootPath)
+	err = filepath.WalkDir(snapshotRootPath, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return errAborted
+		}
+		if err != nil || path == snapshotRootPath {
+			return nil
+		}
+
+		realPath := s.GetRealPath(path)
+		if _, statErr := os.Lstat(realPath); os.IsNotExist(statErr) {
+			changes.add(realPath, diff_state.Deleted, maxExamples)
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errAborted) {
+		changes.Incomplete = true
+		return changes, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func isSameDevice(root os.FileInfo, path string) bool {
+	rootSys, ok := root.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+	stat, err := os.Lstat(path)
+	if err != nil {
+		return true
+	}
+	sys, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+	return rootSys.Dev == sys.Dev
+}
@@ -0,0 +1,95 @@
+package zfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRollbackImpact(t *testing.T) {
+	output := "pool/ds@snap1\t100\t-\n" +
+		"pool/ds@snap2\t200\t\n" +
+		"pool/ds@snap3\t300\tpool/clone1,pool/clone2\n" +
+		"pool/ds@snap4\t400\t-\n"
+
+	impact, err := parseRollbackImpact(output, "pool/ds@snap2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"snap3", "snap4"}, impact.NewerSnapshots)
+	assert.Equal(t, []string{"pool/clone1", "pool/clone2"}, impact.Clones)
+
+	impact, err = parseRollbackImpact(output, "pool/ds@snap4")
+	assert.NoError(t, err)
+	assert.Empty(t, impact.NewerSnapshots)
+	assert.Empty(t, impact.Clones)
+
+	_, err = parseRollbackImpact(output, "pool/ds@missing")
+	assert.Error(t, err)
+
+	_, err = parseRollbackImpact("pool/ds@snap1 100", "pool/ds@snap1")
+	assert.Error(t, err)
+}
+
+func TestRollbackImpact_IsEqual(t *testing.T) {
+	impact := &RollbackImpact{NewerSnapshots: []string{"snap3", "snap4"}, Clones: []string{"pool/clone1", "pool/clone2"}}
+
+	assert.True(t, impact.IsEqual(&RollbackImpact{NewerSnapshots: []string{"snap3", "snap4"}, Clones: []string{"pool/clone2", "pool/clone1"}}))
+	// a snapshot created while the rollback dialog was open
+	assert.False(t, impact.IsEqual(&RollbackImpact{NewerSnapshots: []string{"snap3", "snap4", "snap5"}, Clones: impact.Clones}))
+	assert.False(t, impact.IsEqual(&RollbackImpact{NewerSnapshots: impact.NewerSnapshots, Clones: []string{"pool/clone1"}}))
+	assert.True(t, (&RollbackImpact{}).IsEqual(&RollbackImpact{NewerSnapshots: []string{}}))
+}
+
+func TestDiffWorkingCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	datasetPath := filepath.Join(tempDir, "dataset")
+	snapPath := filepath.Join(datasetPath, ".zfs", "snapshot", "snap1")
+
+	snapshot := &Snapshot{
+		Name: "snap1",
+		Path: snapPath,
+		ParentDataset: &Dataset{
+			Path:          datasetPath,
+			HiddenZfsPath: filepath.Join(datasetPath, ".zfs"),
+		},
+	}
+
+	now := time.Now().Truncate(time.Second)
+	writeFile := func(path string, content string) {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		assert.NoError(t, os.Chtimes(path, now, now))
+	}
+
+	writeFile(filepath.Join(datasetPath, "equal.txt"), "same")
+	writeFile(filepath.Join(snapPath, "equal.txt"), "same")
+	writeFile(filepath.Join(datasetPath, "modified.txt"), "new content")
+	writeFile(filepath.Join(snapPath, "modified.txt"), "old")
+	writeFile(filepath.Join(datasetPath, "new_dir", "a.txt"), "a")
+	writeFile(filepath.Join(datasetPath, "new_dir", "b.txt"), "b")
+	writeFile(filepath.Join(snapPath, "deleted.txt"), "gone")
+	writeFile(filepath.Join(snapPath, "deleted_dir", "c.txt"), "c")
+
+	changes, err := snapshot.DiffWorkingCopy(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.False(t, changes.Incomplete)
+	assert.Equal(t, 1, changes.Added)
+	assert.Equal(t, 1, changes.Modified)
+	assert.Equal(t, 2, changes.Deleted)
+	assert.Equal(t, 4, changes.Total())
+	assert.Len(t, changes.Examples, 4)
+
+	limited, err := snapshot.DiffWorkingCopy(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, limited.Total())
+	assert.Len(t, limited.Examples, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	aborted, err := snapshot.DiffWorkingCopy(ctx, 10)
+	assert.NoError(t, err)
+	assert.True(t, aborted.Incomplete)
+}
@@ -2,7 +2,6 @@ package zfs
 
 import (
 	"fmt"
-	"io"
 	"os"
 	path2 "path"
 	"strconv"
@@ -83,6 +82,8 @@ func NewSnapshot(name string, path string, parentDataset *Dataset, s *golibzfs.D
 	rawGoufsData, err := gozfs.Snapshots(fullName)
 	if err != nil {
 		logging.Error("NewSnapshot: gozfs snapshot failed: %s", err.Error())
+		// without any metadata, the name is the only source of the creation date
+		snapshot.Properties.CreationDate, snapshot.Properties.CreationDateSource, snapshot.Properties.CreationDateParser = snapshot.resolveCreationDate()
 		return snapshot
 	} else if len(rawGoufsData) > 0 {
 		snapshot.rawGozfsData = rawGoufsData[0]
@@ -96,82 +97,30 @@ func NewSnapshot(name string, path string, parentDataset *Dataset, s *golibzfs.D
 // GetSnapshotPath returns the corresponding snapshot path of a file on the dataset
 func (s *Snapshot) GetSnapshotPath(path string) string {
 	fileWithoutBasePath := strings.Replace(path, s.ParentDataset.Path, "", 1)
-	snapshotPath := path2.Join(s.ParentDataset.GetSnapshotsDir(), s.Name, fileWithoutBasePath)
+	if !s.ParentDataset.IsZfs() {
+		return path2.Join(s.Path, fileWithoutBasePath)
+	}
+	snapshotPath := path2.Join(s.ParentDataset.GetSnapshotsDir(), s.Name, s.ParentDataset.BindRoot, fileWithoutBasePath)
 	return snapshotPath
 }
 
 // GetRealPath returns the corresponding "real" path of a file on the dataset
 func (s *Snapshot) GetRealPath(path string) string {
-	fileWithoutBasePath := strings.Replace(path, s.Path, "", 1)
+	fileWithoutBasePath := strings.Replace(path, path2.Join(s.Path, s.ParentDataset.BindRoot), "", 1)
 	realPath := path2.Join(s.ParentDataset.Path, fileWithoutBasePath)
 	return realPath
 }
 
-func (s *Snapshot) RestoreRecursive(srcPath string) error {
-	stat, err := os.Lstat(srcPath)
-	if err != nil {
-		return err
-	}
-	dstPath := s.GetRealPath(srcPath)
-	if stat.IsDir() {
-		err = s.RestoreDir(dstPath, stat)
-		if err != nil {
-			return err
-		}
-
-		files, err := util.ListFilesIn(srcPath)
-		if err != nil {
-			logging.Fatal("Cannot list path: %s", err.Error())
-			return err
-		}
-		for _, file := range files {
-			stat, err = os.Lstat(file)
-			if err != nil {
-				return err
-			}
-			if stat.IsDir() {
-				err = s.RestoreRecursive(file)
-				if err != nil {
-					return err
-				}
-			} else {
-				err = s.RestoreFile(file)
-				if err != nil {
-					return err
-				}
-			}
-		}
-	} else {
-		err = s.RestoreFile(srcPath)
-		if err != nil {
-			return err
-		}
-	}
-
-	// TODO: we have to sync file properties from bottom to top, to avoid
-	//  affecting the modtime of folders due to changes of files within them
-
-	return err
+// RestoreRecursive restores the given snapshot path and everything below it using the configured RestoreSettings.
+// Child datasets are restored from their snapshot with the same name, if one exists.
+// Child datasets without such a snapshot are skipped and reported in the warnings of the report.
+func (s *Snapshot) RestoreRecursive(srcPath string) (RestoreReport, error) {
+	return NewRestoreEngine(GetRestoreSettings()).RestoreRecursive(s, srcPath)
 }
 
-func (s *Snapshot) Restore(srcPath string) error {
-	stat, err := os.Lstat(srcPath)
-	if err != nil {
-		return err
-	}
-	dstPath := s.GetRealPath(srcPath)
-	if stat.IsDir() {
-		err = s.RestoreDir(dstPath, stat)
-		if err != nil {
-			return err
-		}
-	} else {
-		err = s.RestoreFile(srcPath)
-		if err != nil {
-			return err
-		}
-	}
-	return err
+// Restore restores the given snapshot path using the configured RestoreSettings, the content of directories is not restored
+func (s *Snapshot) Restore(srcPath string) (RestoreReport, error) {
+	return NewRestoreEngine(GetRestoreSettings()).Restore(s, srcPath)
 }
 
 func (s *Snapshot) RestoreDir(dstPath string, stat os.FileInfo) error {
@@ -203,15 +152,23 @@ func (s *Snapshot) RestoreDir(dstPath string, stat os.FileInfo) error {
 	return err
 }
 
-func (s *Snapshot) RestoreFile(srcPath string) error {
+// RestoreFile restores a single file of the snapshot, cloning its blocks if the file system supports it.
+// Returns how the content of the file has been copied.
+func (s *Snapshot) RestoreFile(srcPath string) (CopyMethod, error) {
+	return s.restoreFile(srcPath, nil)
+}
+
+// restoreFile restores a single file, the bytes copied are limited by the given limiter, which may be nil
+func (s *Snapshot) restoreFile(srcPath string, limiter *bandwidthLimiter) (CopyMethod, error) {
 	srcFile, err := os.Open(srcPath)
 	if err != nil {
-		return err
+		return CopyMethodBuffered, err
 	}
+	defer func() { _ = srcFile.Close() }()
 
 	stat, err := os.Lstat(srcPath)
 	if err != nil {
-		return err
+		return CopyMethodBuffered, err
 	}
 
 	dstPath := s.GetRealPath(srcPath)
@@ -223,47 +180,41 @@ func (s *Snapshot) RestoreFile(srcPath string) error {
 	fileMode := stat.Mode() | OS_USER_X
 	err = os.MkdirAll(parentDir, fileMode)
 	if err != nil {
-		return err
+		return CopyMethodBuffered, err
 	}
 
 	destFile, err := os.Create(dstPath) // creates if file doesn't exist
 	if err != nil {
-		return err
+		return CopyMethodBuffered, err
 	}
+	defer func() { _ = destFile.Close() }()
 
-	_, err = io.Copy(destFile, srcFile) // check first var for number of bytes copied
+	method, err := copyFileContent(destFile, srcFile, stat.Size(), limiter)
 	if err != nil {
-		return err
+		return method, err
 	}
 
 	err = destFile.Sync()
 	if err != nil {
-		return err
+		return method, err
 	}
 
 	err = destFile.Close()
 	if err != nil {
-		return err
-	}
-	err = srcFile.Close()
-	if err != nil {
-		return err
+		return method, err
 	}
 
 	err = syncFileProperties(dstPath, stat)
 	if err != nil {
-		return err
+		return method, err
 	}
 
-	return err
+	return method, err
 }
 
 func (s *Snapshot) IsRealFileDifferent(path string) bool {
 	realPath := path
-	snapshotPath := s.GetSnapshotPath(path)
-
 	if s.IsSnapshotPath(path) {
-		snapshotPath = path
 		realPath = s.GetRealPath(path)
 	}
 
@@ -272,16 +223,12 @@ func (s *Snapshot) IsRealFileDifferent(path string) bool {
 		return false
 	}
 
-	snapStat, err := os.Lstat(snapshotPath)
-	if err != nil {
+	snapMeta, err := s.StatFile(realPath)
+	if err != nil || !snapMeta.Exists {
 		return false
 	}
 
-	return realStat.IsDir() != snapStat.IsDir() ||
-		realStat.Mode() != snapStat.Mode() ||
-		realStat.ModTime() != snapStat.ModTime() ||
-		realStat.Size() != snapStat.Size() ||
-		realStat.Name() != snapStat.Name()
+	return snapMeta.IsDifferentFrom(realStat)
 }
 
 func (s *Snapshot) IsSnapshotPath(path string) bool {
@@ -289,14 +236,11 @@ func (s *Snapshot) IsSnapshotPath(path string) bool {
 }
 
 func (s *Snapshot) ContainsFile(entry string) (bool, error) {
-	realPath := s.GetSnapshotPath(entry)
-	_, err := os.Lstat(realPath)
-	if os.IsNotExist(err) {
-		return false, nil
-	} else if err != nil {
+	meta, err := s.StatFile(entry)
+	if err != nil {
 		return false, err
 	}
-	return true, nil
+	return meta.Exists, nil
 }
 
 // DetermineDiffState Determine the diff state between a real file and its snapshot counterpart
@@ -343,22 +287,19 @@ func (s *Snapshot) DetermineDiffStateBetween(path string, prev *Snapshot) diff_s
 	}
 
 	if sContains && prevContains {
-		sPath := s.GetSnapshotPath(path)
-		prevPath := prev.GetSnapshotPath(path)
-
-		sStat, err := os.Lstat(sPath)
+		sMeta, err := s.StatFile(path)
 		if err != nil {
 			return diff_state.Unknown
 		}
-		prevStat, err := os.Lstat(prevPath)
+		prevMeta, err := prev.StatFile(path)
 		if err != nil {
 			return diff_state.Unknown
 		}
 
-		if sStat.IsDir() != prevStat.IsDir() ||
-			sStat.Mode() != prevStat.Mode() ||
-			sStat.ModTime() != prevStat.ModTime() ||
-			sStat.Size() != prevStat.Size() {
+		if sMeta.IsDir != prevMeta.IsDir ||
+			sMeta.Mode != prevMeta.Mode ||
+			!sMeta.ModTime.Equal(prevMeta.ModTime) ||
+			sMeta.Size != prevMeta.Size {
 			return diff_state.Modified
 		}
 		return diff_state.Equal
@@ -376,6 +317,130 @@ func (s *Snapshot) Destroy(recursive bool, dependantClones bool) error {
 	return ds.DestroySnapshot(s.Name, recursive, dependantClones)
 }
 
+// Rename renames this snapshot within its parent dataset
+func (s *Snapshot) Rename(newName string) error {
+	if err := ValidateSnapshotName(newName); err != nil {
+		return err
+	}
+	newFullName := fmt.Sprintf("%s@%s", s.ParentDataset.GetName(), newName)
+	if len(newFullName) > maxDatasetNameLength {
+		return fmt.Errorf("full snapshot name '%s' must not be longer than %d characters", newFullName, maxDatasetNameLength)
+	}
+	newPath := path2.Join(s.ParentDataset.GetSnapshotsDir(), newName)
+	if newName != s.Name && util.FileExists(newPath) {
+		return fmt.Errorf("snapshot '%s' already exists", newName)
+	}
+	if err := s.lazyLoadGozfsData(); err != nil {
+		return fmt.Errorf("cannot rename snapshot: %w", err)
+	}
+
+	renamed, err := s.rawGozfsData.Rename(newFullName, false, false)
+	if err != nil {
+		return err
+	}
+
+	s.Name = newName
+	s.FullName = newFullName
+	s.Path = newPath
+	s.rawGozfsData = renamed
+	return nil
+}
+
+// SnapshotClone is a writable dataset which originates from a snapshot
+type SnapshotClone struct {
+	Name       string
+	Mountpoint string
+	Mounted    bool
+}
+
+// IsBrowsable reports whether the clone is mounted at a path that can be opened in the file browser
+func (c *SnapshotClone) IsBrowsable() bool {
+	return c.Mounted && strings.HasPrefix(c.Mountpoint, "/")
+}
+
+func newSnapshotClone(ds *gozfs.Dataset) *SnapshotClone {
+	mounted, err := ds.GetProperty(propMounted)
+	if err != nil {
+		logging.Error("Could not get mounted property for %s: %s", ds.Name, err.Error())
+	}
+	return &SnapshotClone{
+		Name:       ds.Name,
+		Mountpoint: ds.Mountpoint,
+		Mounted:    mounted == "yes",
+	}
+}
+
+func (s *Snapshot) lazyLoadGozfsData() error {
+	if s.rawGozfsData != nil {
+		return nil
+	}
+	snapshots, err := gozfs.Snapshots(s.FullName)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("snapshot not found: %s", s.FullName)
+	}
+	s.rawGozfsData = snapshots[0]
+	return nil
+}
+
+// SuggestCloneName returns a default dataset name for a clone of this snapshot
+func (s *Snapshot) SuggestCloneName() string {
+	return suggestCloneName(s.ParentDataset.GetName(), s.Name)
+}
+
+// Clone creates a new writable dataset with the given name from this snapshot.
+// If mountpoint is empty, the clone inherits its mountpoint from its parent dataset.
+func (s *Snapshot) Clone(target string, mountpoint string) (*SnapshotClone, error) {
+	if err := ValidateDatasetName(target); err != nil {
+		return nil, err
+	}
+	if getPoolName(target) != getPoolName(s.FullName) {
+		return nil, fmt.Errorf("clone must be created in pool '%s'", getPoolName(s.FullName))
+	}
+	if err := s.lazyLoadGozfsData(); err != nil {
+		return nil, fmt.Errorf("cannot clone snapshot: %w", err)
+	}
+
+	properties := map[string]string{}
+	if mountpoint != "" {
+		properties[propMountpoint] = mountpoint
+	}
+
+	clone, err := s.rawGozfsData.Clone(target, properties)
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshotClone(clone), nil
+}
+
+// ListClones returns all datasets which have been cloned from this snapshot
+func (s *Snapshot) ListClones() ([]*SnapshotClone, error) {
+	if err := s.lazyLoadGozfsData(); err != nil {
+		return nil, fmt.Errorf("cannot list clones: %w", err)
+	}
+
+	value, err := s.rawGozfsData.GetProperty(propClones)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*SnapshotClone
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "-" {
+			continue
+		}
+		ds, err := gozfs.GetDataset(name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, newSnapshotClone(ds))
+	}
+	return result, nil
+}
+
 func (s *Snapshot) GetCreationDate() time.Time {
 	if s.rawGolibzfsData != nil {
 		prop, err := s.rawGolibzfsData.GetProperty(golibzfs.DatasetPropCreation)
@@ -405,6 +470,22 @@ func (s *Snapshot) GetCreationDate() time.Time {
 	return time.Unix(timestamp, 0)
 }
 
+func (s *Snapshot) GetGuid() string {
+	if s.rawGolibzfsData != nil {
+		prop, err := s.rawGolibzfsData.GetProperty(golibzfs.DatasetPropGUID)
+		if err == nil {
+			return prop.Value
+		}
+	}
+	if s.rawGozfsData != nil {
+		value, err := s.rawGozfsData.GetProperty("guid")
+		if err == nil {
+			return value
+		}
+	}
+	return ""
+}
+
 func (s *Snapshot) GetUsed() uint64 {
 	if s.rawGolibzfsData == nil {
 		logging.Error("No rawGolibzfsData available")
@@ -477,20 +558,40 @@ func (s *Snapshot) GetClones() uint64 {
 }
 
 type SnapshotProperties struct {
-	CreationDate     time.Time
+	CreationDate       time.Time
+	CreationDateSource CreationDateSource
+	// CreationDateParser is the name of the NameTimestampParser used if CreationDateSource is CreationDateSourceName
+	CreationDateParser string
+	// Guid identifies a snapshot across datasets it has been sent to, empty if unknown
+	Guid             string
 	Used             uint64
 	Referenced       uint64
+	Written          uint64
 	CompressionRatio float64
 	Clones           uint64
+	// User contains arbitrary user properties (e.g. "zfh:note"), keyed by property name
+	User map[string]string
+	// UserSources contains where each value of User comes from, see Snapshot.GetUserPropertySource
+	UserSources map[string]string
 }
 
 func (s *Snapshot) FetchDetails() {
+	userProperties, userPropertySources := s.Properties.User, s.Properties.UserSources
+	guid := s.GetGuid()
+	immutableProperties := s.fetchImmutableProperties(guid)
+	creationDate, creationDateSource, creationDateParser := resolveCreationDateOf(s.Name, immutableProperties.Creation)
 	s.Properties = SnapshotProperties{
-		CreationDate:     s.GetCreationDate(),
-		Used:             s.GetUsed(),
-		Referenced:       s.GetReferenced(),
-		CompressionRatio: s.GetRatio(),
-		Clones:           s.GetClones(),
+		CreationDate:       creationDate,
+		CreationDateSource: creationDateSource,
+		CreationDateParser: creationDateParser,
+		Guid:               guid,
+		Used:               s.GetUsed(),
+		Referenced:         immutableProperties.Referenced,
+		Written:            s.GetWritten(),
+		CompressionRatio:   immutableProperties.CompressionRatio,
+		Clones:             s.GetClones(),
+		User:               userProperties,
+		UserSources:        userPropertySources,
 	}
 }
 
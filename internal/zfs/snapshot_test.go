@@ -3,6 +3,7 @@ package zfs
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 	"zfs-file-history/internal/data/diff_state"
@@ -121,3 +122,35 @@ func setupFile(t *testing.T, path string, state fileState) {
 		}
 	}
 }
+
+func TestSnapshotRename_Validation(t *testing.T) {
+	tempDir := t.TempDir()
+	datasetPath := filepath.Join(tempDir, "dataset")
+	snapDirBase := filepath.Join(datasetPath, ".zfs", "snapshot")
+	if err := os.MkdirAll(filepath.Join(snapDirBase, "existing"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := &Snapshot{
+		Name: "snap1",
+		Path: filepath.Join(snapDirBase, "snap1"),
+		ParentDataset: &Dataset{
+			Path:          datasetPath,
+			HiddenZfsPath: filepath.Join(datasetPath, ".zfs"),
+		},
+	}
+
+	err := snapshot.Rename("invalid/name")
+	if err == nil || !strings.Contains(err.Error(), "invalid character") {
+		t.Errorf("expected invalid character error, got %v", err)
+	}
+
+	err = snapshot.Rename("existing")
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+
+	if snapshot.Name != "snap1" {
+		t.Errorf("snapshot name must not change on failure, got %s", snapshot.Name)
+	}
+}
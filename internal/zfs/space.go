@@ -0,0 +1,97 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"zfs-file-history/internal/logging"
+
+	golibzfs "github.com/kraudcloud/go-libzfs"
+)
+
+const (
+	propWritten              = "written"
+	propUsedBySnapshots      = "usedbysnapshots"
+	propUsedByDataset        = "usedbydataset"
+	propUsedByChildren       = "usedbychildren"
+	propUsedByRefreservation = "usedbyrefreservation"
+)
+
+// SpaceUsage is the breakdown of the space used by a dataset, see zfsprops(7)
+type SpaceUsage struct {
+	Snapshots      uint64
+	Dataset        uint64
+	Children       uint64
+	Refreservation uint64
+}
+
+// Total returns the sum of all parts, which equals the used property of the dataset
+func (u SpaceUsage) Total() uint64 {
+	return u.Snapshots + u.Dataset + u.Children + u.Refreservation
+}
+
+func (dataset *Dataset) GetSpaceUsage() SpaceUsage {
+	return SpaceUsage{
+		Snapshots:      dataset.getPropertyUint64(golibzfs.DatasetPropUsedsnap, propUsedBySnapshots),
+		Dataset:        dataset.getPropertyUint64(golibzfs.DatasetPropUsedds, propUsedByDataset),
+		Children:       dataset.getPropertyUint64(golibzfs.DatasetPropUsedchild, propUsedByChildren),
+		Refreservation: dataset.getPropertyUint64(golibzfs.DatasetPropUsedrefreserv, propUsedByRefreservation),
+	}
+}
+
+// PoolSpace describes the capacity of a pool
+type PoolSpace struct {
+	Name string
+	Size uint64
+	Free uint64
+}
+
+// GetPoolSpace returns the capacity of the pool containing this dataset
+func (dataset *Dataset) GetPoolSpace() (*PoolSpace, error) {
+	datasetName := dataset.GetName()
+	if datasetName == "" {
+		return nil, errors.New("cannot get pool space: no dataset metadata available")
+	}
+	output, err := runZpoolCommand("list", "-H", "-p", "-o", "name,size,free", getPoolName(datasetName))
+	if err != nil {
+		return nil, err
+	}
+	return parsePoolSpace(output)
+}
+
+// parsePoolSpace parses the output of "zpool list -H -p -o name,size,free" for a single pool
+func parsePoolSpace(output string) (*PoolSpace, error) {
+	line := strings.TrimSpace(output)
+	fields := strings.Split(line, "\t")
+	if len(fields) != 3 {
+		return nil, errors.New("unexpected zpool list output: " + line)
+	}
+	size, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected size of pool %s: %w", fields[0], err)
+	}
+	free, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected free space of pool %s: %w", fields[0], err)
+	}
+	return &PoolSpace{Name: fields[0], Size: size, Free: free}, nil
+}
+
+// GetWritten returns the amount of data written to the dataset between the previous snapshot and this one
+func (s *Snapshot) GetWritten() uint64 {
+	if s.rawGolibzfsData == nil {
+		return 0
+	}
+	prop, err := s.rawGolibzfsData.GetProperty(golibzfs.DatasetPropWritten)
+	if err != nil {
+		logging.Error("Could not get written property for %s: %s", s.FullName, err.Error())
+		return 0
+	}
+	written, err := strconv.ParseUint(prop.Value, 10, 64)
+	if err != nil {
+		logging.Error("Could not parse written property for %s: %s", s.FullName, err.Error())
+		return 0
+	}
+	return written
+}
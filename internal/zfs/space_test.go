@@ -0,0 +1,23 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePoolSpace(t *testing.T) {
+	result, err := parsePoolSpace("tank\t1000000\t250000\n")
+	assert.NoError(t, err)
+	assert.Equal(t, &PoolSpace{Name: "tank", Size: 1000000, Free: 250000}, result)
+
+	_, err = parsePoolSpace("")
+	assert.Error(t, err)
+	_, err = parsePoolSpace("tank\t1000000\tlots\n")
+	assert.Error(t, err)
+}
+
+func TestSpaceUsageTotal(t *testing.T) {
+	usage := SpaceUsage{Snapshots: 1, Dataset: 2, Children: 4, Refreservation: 8}
+	assert.Equal(t, uint64(15), usage.Total())
+}
@@ -0,0 +1,181 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"zfs-file-history/internal/util"
+)
+
+// sources of user property values, as reported by "zfs get"
+const (
+	userPropertySourceLocal = "local"
+	// userPropertySourceInherited is followed by the name of the dataset the value is inherited from
+	userPropertySourceInherited = "inherited"
+)
+
+// LoadSnapshotUserProperties fetches the given user properties of all snapshots of this dataset
+// with a single zfs call and stores them in SnapshotProperties.User of the matching snapshots.
+// Besides values set on the snapshot itself, values inherited from its dataset (e.g. "com.sun:auto-snapshot")
+// are loaded as well, their source is stored in SnapshotProperties.UserSources. Properties which are not set are omitted.
+func (dataset *Dataset) LoadSnapshotUserProperties(snapshots []*Snapshot, keys []string) error {
+	// only zfs snapshots have user properties
+	if len(snapshots) == 0 || len(keys) == 0 || !dataset.IsZfs() {
+		return nil
+	}
+	for _, key := range keys {
+		if err := util.ValidateUserPropertyName(key); err != nil {
+			return err
+		}
+	}
+
+	_ = dataset.lazyLoadGozfsData()
+	datasetName := dataset.GetName()
+	if datasetName == "" {
+		return errors.New("cannot load user properties: no dataset metadata available")
+	}
+
+	output, err := runZfsCommand(
+		"get", "-H", "-p",
+		"-t", "snapshot", "-d", "1",
+		"-s", "local,received,inherited",
+		"-o", "name,property,source,value",
+		strings.Join(keys, ","),
+		datasetName,
+	)
+	if err != nil {
+		return err
+	}
+
+	properties, sources, err := parseSnapshotUserProperties(output)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		snapshot.Properties.User = properties[snapshot.FullName]
+		snapshot.Properties.UserSources = sources[snapshot.FullName]
+	}
+	return nil
+}
+
+// LoadUserProperties fetches the given user properties of snapshots which may belong to different datasets,
+// e.g. a dataset and its replicas, see Dataset.LoadSnapshotUserProperties
+func LoadUserProperties(snapshots []*Snapshot, keys []string) error {
+	var datasets []*Dataset
+	snapshotsByDataset := map[*Dataset][]*Snapshot{}
+	for _, snapshot := range snapshots {
+		if _, ok := snapshotsByDataset[snapshot.ParentDataset]; !ok {
+			datasets = append(datasets, snapshot.ParentDataset)
+		}
+		snapshotsByDataset[snapshot.ParentDataset] = append(snapshotsByDataset[snapshot.ParentDataset], snapshot)
+	}
+
+	var errs []error
+	for _, dataset := range datasets {
+		if err := dataset.LoadSnapshotUserProperties(snapshotsByDataset[dataset], keys); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dataset.GetName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseSnapshotProperties parses the output of "zfs get -H -o name,property,value"
+// into a map of snapshot full name -> property -> value
+func parseSnapshotProperties(output string) (map[string]map[string]string, error) {
+	result := map[string]map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return nil, errors.New("unexpected zfs get output: " + line)
+		}
+		name, property, value := fields[0], fields[1], fields[2]
+		if result[name] == nil {
+			result[name] = map[string]string{}
+		}
+		result[name][property] = value
+	}
+	return result, nil
+}
+
+// parseSnapshotUserProperties parses the output of "zfs get -H -o name,property,source,value"
+// into maps of snapshot full name -> property -> value and snapshot full name -> property -> source
+func parseSnapshotUserProperties(output string) (map[string]map[string]string, map[string]map[string]string, error) {
+	values := map[string]map[string]string{}
+	sources := map[string]map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// the value is the last column, since it may contain tabs
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			return nil, nil, errors.New("unexpected zfs get output: " + line)
+		}
+		name, property, source, value := fields[0], fields[1], fields[2], fields[3]
+		if values[name] == nil {
+			values[name] = map[string]string{}
+			sources[name] = map[string]string{}
+		}
+		values[name][property] = value
+		sources[name][property] = source
+	}
+	return values, sources, nil
+}
+
+// GetUserProperty returns the value of the given user property, if it has been loaded
+// via Dataset.LoadSnapshotUserProperties or set via SetUserProperty.
+func (s *Snapshot) GetUserProperty(key string) (string, bool) {
+	value, ok := s.Properties.User[key]
+	return value, ok
+}
+
+// GetUserPropertySource returns where the value of the given user property comes from,
+// e.g. "local", "received" or "inherited from tank/data". Empty if it is unknown.
+func (s *Snapshot) GetUserPropertySource(key string) string {
+	return s.Properties.UserSources[key]
+}
+
+// IsUserPropertyInherited reports whether the value of the given user property is inherited from a dataset
+func (s *Snapshot) IsUserPropertyInherited(key string) bool {
+	return strings.HasPrefix(s.GetUserPropertySource(key), userPropertySourceInherited)
+}
+
+// SetUserProperty sets the given user property on this snapshot.
+// An empty value removes the property from the snapshot.
+func (s *Snapshot) SetUserProperty(key string, value string) error {
+	if err := util.ValidateUserPropertyName(key); err != nil {
+		return err
+	}
+	if err := ValidateUserPropertyValue(key, value); err != nil {
+		return err
+	}
+
+	var err error
+	if value == "" {
+		_, err = runZfsCommand("inherit", key, s.FullName)
+	} else {
+		_, err = runZfsCommand("set", fmt.Sprintf("%s=%s", key, value), s.FullName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		// a value inherited from the dataset is only known after the properties have been loaded again
+		delete(s.Properties.User, key)
+		delete(s.Properties.UserSources, key)
+	} else {
+		if s.Properties.User == nil {
+			s.Properties.User = map[string]string{}
+		}
+		if s.Properties.UserSources == nil {
+			s.Properties.UserSources = map[string]string{}
+		}
+		s.Properties.User[key] = value
+		s.Properties.UserSources[key] = userPropertySourceLocal
+	}
+	return nil
+}
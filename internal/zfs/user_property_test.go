@@ -0,0 +1,70 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSnapshotProperties(t *testing.T) {
+	output := "tank/data@snap1\tzfh:note\tpre-deploy\n" +
+		"tank/data@snap1\tcom.sun:auto-snapshot\tfalse\n" +
+		"tank/data@snap2\tzfh:note\tvalue\twith tab\n"
+
+	result, err := parseSnapshotProperties(output)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"tank/data@snap1": {"zfh:note": "pre-deploy", "com.sun:auto-snapshot": "false"},
+		"tank/data@snap2": {"zfh:note": "value\twith tab"},
+	}, result)
+
+	result, err = parseSnapshotProperties("")
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	_, err = parseSnapshotProperties("tank/data@snap1\tzfh:note\n")
+	assert.Error(t, err)
+}
+
+func TestParseSnapshotUserProperties(t *testing.T) {
+	output := "tank/data@snap1\tzfh:note\tlocal\tpre-deploy\n" +
+		"tank/data@snap1\tcom.sun:auto-snapshot\tinherited from tank\tfalse\n" +
+		"tank/data@snap2\tzfh:note\treceived\tvalue\twith tab\n"
+
+	values, sources, err := parseSnapshotUserProperties(output)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"tank/data@snap1": {"zfh:note": "pre-deploy", "com.sun:auto-snapshot": "false"},
+		"tank/data@snap2": {"zfh:note": "value\twith tab"},
+	}, values)
+	assert.Equal(t, map[string]map[string]string{
+		"tank/data@snap1": {"zfh:note": "local", "com.sun:auto-snapshot": "inherited from tank"},
+		"tank/data@snap2": {"zfh:note": "received"},
+	}, sources)
+
+	snapshot := &Snapshot{Properties: SnapshotProperties{User: values["tank/data@snap1"], UserSources: sources["tank/data@snap1"]}}
+	assert.True(t, snapshot.IsUserPropertyInherited("com.sun:auto-snapshot"))
+	assert.Equal(t, "inherited from tank", snapshot.GetUserPropertySource("com.sun:auto-snapshot"))
+	assert.False(t, snapshot.IsUserPropertyInherited("zfh:note"))
+
+	_, _, err = parseSnapshotUserProperties("tank/data@snap1\tzfh:note\tpre-deploy\n")
+	assert.Error(t, err)
+}
+
+func TestSnapshotSetUserProperty_Validation(t *testing.T) {
+	s := &Snapshot{FullName: "tank/data@snap1"}
+	assert.Error(t, s.SetUserProperty("compression", "lz4"))
+	assert.Error(t, s.SetUserProperty("zfh:note", string(make([]byte, maxUserPropertyValueLength+1))))
+}
+
+func TestSnapshotGetUserProperty(t *testing.T) {
+	s := &Snapshot{Properties: SnapshotProperties{User: map[string]string{"zfh:note": "pre-deploy"}}}
+	value, ok := s.GetUserProperty("zfh:note")
+	assert.True(t, ok)
+	assert.Equal(t, "pre-deploy", value)
+
+	_, ok = s.GetUserProperty("zfh:other")
+	assert.False(t, ok)
+	_, ok = (&Snapshot{}).GetUserProperty("zfh:note")
+	assert.False(t, ok)
+}